@@ -6,159 +6,79 @@ import (
 	"github.com/eaglewu/luban/compiler/token"
 )
 
-// const (
-// 	SpecialShift     = 6
-// 	IsListShift      = 7
-// 	NumChildrenShift = 8
-// )
-// const (
-// // special nodes
-// Zval Kind = 1 << SpecialShift
-// Znode
-
-// // declaration nodes
-// FuncDecl
-// Closure
-// Method
-// Class
-
-// // list nodes
-// ArgList = 1 << IsListShift
-// Array
-// EncapsList
-// ExprList
-// StmtList
-// If
-// SwitchList
-// CatchList
-// ParamList
-// ClosureUses
-// PropDecl
-// ConstDecl
-// ClassConstDecl
-// NameList
-// TraitAdaptations
-// Use
-
-// // 0 child nodes
-// MagicConst = 0 << NumChildrenShift
-// Type
-
-// // 1 child node
-// Var = 1 << NumChildrenShift
-// Const
-// Unpack
-// UnaryPlus
-// UnaryMinus
-// C
-// Empty
-// Isset
-// Silence
-// ShellExec
-// Clone
-// Exit
-// Print
-// IncludeOrEval
-// UnaryOp
-// PreInc
-// PreDec
-// PostInc
-// PostDec
-// YieldFrom
-
-// Global
-// Unset
-// Return
-// Label
-// Ref
-// HaltCompiler
-// Echo
-// Throw
-// Goto
-// Break
-// Continue
-
-// // 2 child nodes
-// Dim = 2 << NumChildrenShift
-// Prop
-// StaticProp
-// Call
-// ClassConst
-// Assign
-// AssignRef
-// AssignOp
-// BinaryOp
-// Greater
-// GreaterEqual
-// And
-// Or
-// ArrayElem
-// New
-// Instanceof
-// Yield
-// Coalesce
-
-// Static
-// While
-// DoWhile
-// IfElem
-// Switch
-// SwitchCase
-// Declare
-// UseTrait
-// TraitPrecedence
-// MethodReference
-// Namespace
-// UseElem
-// TraitAlias
-// GroupUse
-
-// // 3 child nodes
-// MethodCall = 3 << NumChildrenShift
-// StaticCall
-// Conditional
-
-// Try
-// Catch
-// Param
-// PropElem
-// ConstElem
-
-// // 4 child nodes
-// For = 4 << NumChildrenShift
-// Foreach
-// )
-
+// BaseNode holds the token a node is created from and the range of
+// source text it covers, from its first token to its last one. The nodes
+// built by hand may have a nil BaseNode, its methods return zero values.
 type BaseNode struct {
 	Token  token.Token
+	pos    token.Position
+	end    token.Position
 	isStmt bool
 }
 
 func (b *BaseNode) Line() int {
+	if b == nil {
+		return 0
+	}
+	if b.pos.IsValid() {
+		return b.pos.Line
+	}
 	return b.Token.Line
 }
 
+// Pos returns the position of the first character of the node, it is
+// not valid when the range is not set.
+func (b *BaseNode) Pos() token.Position {
+	if b == nil {
+		return token.Position{}
+	}
+	return b.pos
+}
+
+// End returns the position right after the last character of the node
+func (b *BaseNode) End() token.Position {
+	if b == nil {
+		return token.Position{}
+	}
+	return b.end
+}
+
+// SetRange sets the source range of the node, pos is the position of its
+// first character and end the one right after its last character.
+func (b *BaseNode) SetRange(pos, end token.Position) {
+	if b != nil {
+		b.pos, b.end = pos, end
+	}
+}
+
 func (b *BaseNode) IsExp() bool {
-	return !b.isStmt
+	return b == nil || !b.isStmt
 }
 
 func (b *BaseNode) IsStmt() bool {
-	return b.isStmt
+	return b != nil && b.isStmt
 }
 
 func (b *BaseNode) MarkAsStmt() {
-	b.isStmt = true
+	if b != nil {
+		b.isStmt = true
+	}
 }
 
 func (b *BaseNode) MarkAsExp() {
-	b.isStmt = false
+	if b != nil {
+		b.isStmt = false
+	}
 }
 
 // Node all node types implement the Node interface.
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Kind() Kind
 	Line() int
+	Pos() token.Position
+	End() token.Position
 	IsExp() bool
 	IsStmt() bool
 
@@ -181,6 +101,11 @@ type Statement interface {
 // Program is the root node of entire AST
 type Program struct {
 	Statements []Statement
+	// Comments holds the comments when parsed with the ParseComments mode
+	Comments []token.Token
+	// Trivia holds the comments, whitespace and PHP tags when parsed with
+	// the ParseTrivia mode
+	Trivia []token.Token
 }
 
 func (p *Program) TokenLiteral() string {
@@ -190,6 +115,35 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+// Line returns the line of the first statement, 0 for an empty program
+func (p *Program) Line() int {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Line()
+	}
+	return 0
+}
+
+// Pos returns the position of the first statement
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+// End returns the end of the last statement
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
+
+func (p *Program) IsExp() bool  { return false }
+func (p *Program) IsStmt() bool { return false }
+func (p *Program) MarkAsStmt()  {}
+func (p *Program) MarkAsExp()   {}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 	for _, s := range p.Statements {
@@ -197,3 +151,18 @@ func (p *Program) String() string {
 	}
 	return out.String()
 }
+
+func (p *Program) Kind() Kind {
+	return KindStmtList
+}
+
+// Source returns the text of src covered by the node, src being the
+// source the node was parsed from. It returns "" for the nodes without
+// a range, like the ones built by hand.
+func Source(src string, n Node) string {
+	pos, end := n.Pos(), n.End()
+	if !pos.IsValid() || !end.IsValid() || pos.Offset > end.Offset || end.Offset > len(src) {
+		return ""
+	}
+	return src[pos.Offset:end.Offset]
+}
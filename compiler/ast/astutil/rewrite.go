@@ -0,0 +1,564 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE-GO file.
+//
+// Apply and Cursor are adapted from golang.org/x/tools/go/ast/astutil,
+// rewrite.go, to the PHP syntax trees and their source ranges.
+
+// Package astutil contains utilities working on the PHP syntax trees of
+// the ast package.
+package astutil
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// An ApplyFunc is invoked by Apply for each node n, even if n is nil,
+// before and/or after the node's children, using a Cursor describing
+// the current node and providing operations on it.
+//
+// The return value of ApplyFunc controls the syntax tree traversal.
+// See Apply for details.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses a syntax tree recursively, starting with root,
+// and calling pre and post for each node as described below.
+// Apply returns the syntax tree, possibly modified.
+//
+// If pre is not nil, it is called for each node before the node's
+// children are traversed (pre-order). If pre returns false, no
+// children are traversed, and post is not called for that node.
+//
+// If post is not nil, and a prior call of pre didn't return false,
+// post is called for each node after its children are traversed
+// (post-order). If post returns false, traversal is terminated and
+// Apply returns immediately.
+//
+// Only fields that refer to AST nodes are considered children, they are
+// traversed in the order ast.Walk visits them. Nil fields are visited
+// too so that optional parts, e.g. the else block of an if statement,
+// can be added with Cursor.Replace.
+//
+// The source ranges keep referring to the source the tree was parsed
+// from, the Cursor methods keep every range inside the one of its
+// parent: the nodes put in the tree without a range get the range of
+// the node they replace, or an empty range where they are inserted, and
+// the ancestors of a node moved from elsewhere are widened to contain
+// it. Ranges are never shrunk, after Delete the parent still covers the
+// source of the deleted node.
+func Apply(root ast.Node, pre, post ApplyFunc) (result ast.Node) {
+	parent := &struct{ ast.Node }{root}
+	defer func() {
+		if r := recover(); r != nil && r != abort {
+			panic(r)
+		}
+		result = parent.Node
+	}()
+	a := &application{pre: pre, post: post}
+	a.cursor.app = a
+	a.apply(parent, "Node", nil, root)
+	return
+}
+
+var abort = new(int) // singleton, to signal termination of Apply
+
+// A Cursor describes a node encountered during Apply.
+// Information about the node and its parent is available
+// from the Node, Parent, Name, and Index methods.
+//
+// If p is a variable of type and value of the current parent node
+// c.Parent(), and f is the field identifier with name c.Name(),
+// the following invariants hold:
+//
+//	p.f            == c.Node()  if c.Index() <  0
+//	p.f[c.Index()] == c.Node()  if c.Index() >= 0
+//
+// The methods Replace, Delete, InsertBefore, and InsertAfter
+// can be used to change the AST without disrupting Apply.
+type Cursor struct {
+	parent ast.Node
+	name   string
+	iter   *iterator // valid if non-nil
+	node   ast.Node
+	app    *application
+}
+
+// Node returns the current Node.
+func (c *Cursor) Node() ast.Node { return c.node }
+
+// Parent returns the parent of the current Node.
+func (c *Cursor) Parent() ast.Node { return c.parent }
+
+// Name returns the name of the parent Node field that contains the
+// current Node, e.g. "Statements" for the statements of a *ast.Program.
+func (c *Cursor) Name() string { return c.name }
+
+// Index reports the index >= 0 of the current Node in the slice of
+// Nodes that contains it, or a value < 0 if the current Node is not
+// part of a slice. The index of the current node changes if
+// InsertBefore is called while processing the current node.
+func (c *Cursor) Index() int {
+	if c.iter != nil {
+		return c.iter.index
+	}
+	return -1
+}
+
+// field returns the current node's parent field value.
+func (c *Cursor) field() reflect.Value {
+	return reflect.Indirect(reflect.ValueOf(c.parent)).FieldByName(c.name)
+}
+
+// Replace replaces the current Node with n, which must be assignable
+// to the field holding it: a *ast.BlockStatement cannot be replaced by
+// an expression. When called from pre, the children of n are traversed
+// in place of those of the replaced node.
+func (c *Cursor) Replace(n ast.Node) {
+	v := c.field()
+	if i := c.Index(); i >= 0 {
+		v = v.Index(i)
+	}
+	if n == nil {
+		v.Set(reflect.Zero(v.Type()))
+	} else {
+		v.Set(reflect.ValueOf(n))
+		// a missing optional part is added at the end of its parent
+		pos, end := c.parent.End(), c.parent.End()
+		if c.node != nil {
+			pos, end = c.node.Pos(), c.node.End()
+		}
+		c.place(n, pos, end)
+	}
+	c.node = n
+}
+
+// Delete deletes the current Node from its containing slice.
+// If the current Node is not part of a slice, Delete panics.
+func (c *Cursor) Delete() {
+	i := c.Index()
+	if i < 0 {
+		panic("Delete node not contained in slice")
+	}
+	v := c.field()
+	l := v.Len()
+	reflect.Copy(v.Slice(i, l), v.Slice(i+1, l))
+	v.Index(l - 1).Set(reflect.Zero(v.Type().Elem()))
+	v.SetLen(l - 1)
+	c.iter.step--
+}
+
+// InsertAfter inserts n after the current Node in its containing slice.
+// If the current Node is not part of a slice, InsertAfter panics.
+// Apply does not walk n.
+func (c *Cursor) InsertAfter(n ast.Node) {
+	i := c.Index()
+	if i < 0 {
+		panic("InsertAfter node not contained in slice")
+	}
+	v := c.field()
+	v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	l := v.Len()
+	reflect.Copy(v.Slice(i+2, l), v.Slice(i+1, l))
+	v.Index(i + 1).Set(reflect.ValueOf(n))
+	c.iter.step++
+	if c.node != nil {
+		c.place(n, c.node.End(), c.node.End())
+	}
+}
+
+// InsertBefore inserts n before the current Node in its containing slice.
+// If the current Node is not part of a slice, InsertBefore panics.
+// Apply will not walk n.
+func (c *Cursor) InsertBefore(n ast.Node) {
+	i := c.Index()
+	if i < 0 {
+		panic("InsertBefore node not contained in slice")
+	}
+	v := c.field()
+	v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	l := v.Len()
+	reflect.Copy(v.Slice(i+1, l), v.Slice(i, l))
+	v.Index(i).Set(reflect.ValueOf(n))
+	c.iter.index++
+	if c.node != nil {
+		c.place(n, c.node.Pos(), c.node.Pos())
+	}
+}
+
+// place sets the range of n, put in the tree at pos-end: the nodes of n
+// without a range get pos-end, then n and its ancestors are widened to
+// contain their children.
+func (c *Cursor) place(n ast.Node, pos, end token.Position) {
+	if pos.IsValid() && end.IsValid() {
+		ast.Inspect(n, func(n ast.Node) bool {
+			if n != nil && !n.Pos().IsValid() {
+				setRange(n, pos, end)
+			}
+			return true
+		})
+	}
+	cover(n)
+	if !n.Pos().IsValid() {
+		return
+	}
+	for _, parent := range c.app.ancestors {
+		extend(parent, n.Pos(), n.End())
+	}
+}
+
+// cover widens the ranges of n and its descendants to contain the ranges
+// of their children.
+func cover(n ast.Node) {
+	for _, child := range ast.Children(n) {
+		cover(child)
+		if child.Pos().IsValid() {
+			extend(n, child.Pos(), child.End())
+		}
+	}
+}
+
+// extend widens the range of n to contain pos-end
+func extend(n ast.Node, pos, end token.Position) {
+	from, to := n.Pos(), n.End()
+	if !from.IsValid() {
+		return
+	}
+	if pos.Offset < from.Offset {
+		from = pos
+	}
+	if end.Offset > to.Offset {
+		to = end
+	}
+	setRange(n, from, to)
+}
+
+// setRange sets the range of n, a BaseNode is allocated for the nodes
+// built without one.
+func setRange(n ast.Node, pos, end token.Position) {
+	v := reflect.ValueOf(n)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	f := v.Elem().FieldByName("BaseNode")
+	if !f.IsValid() || f.Type() != reflect.TypeOf(&ast.BaseNode{}) {
+		return // the range of a *ast.Program is the one of its statements
+	}
+	if f.IsNil() {
+		f.Set(reflect.ValueOf(&ast.BaseNode{}))
+	}
+	f.Interface().(*ast.BaseNode).SetRange(pos, end)
+}
+
+// application carries all the shared data so we can pass it around cheaply.
+type application struct {
+	pre, post ApplyFunc
+	cursor    Cursor
+	iter      iterator
+	// ancestors holds the nodes whose children are being traversed
+	ancestors []ast.Node
+}
+
+// An iterator controls iteration over a slice of nodes.
+type iterator struct {
+	index, step int
+}
+
+func (a *application) apply(parent ast.Node, name string, iter *iterator, n ast.Node) {
+	// convert typed nil into untyped nil
+	if v := reflect.ValueOf(n); v.Kind() == reflect.Ptr && v.IsNil() {
+		n = nil
+	}
+
+	// avoid heap-allocating a new cursor for each apply call; reuse a.cursor instead
+	saved := a.cursor
+	a.cursor.parent = parent
+	a.cursor.name = name
+	a.cursor.iter = iter
+	a.cursor.node = n
+
+	if a.pre != nil && !a.pre(&a.cursor) {
+		a.cursor = saved
+		return
+	}
+
+	if a.cursor.node != nil {
+		a.ancestors = append(a.ancestors, a.cursor.node)
+	}
+
+	// walk children
+	// (the order of the cases matches the order of the corresponding node
+	// types in ast.Walk)
+	switch n := a.cursor.node.(type) {
+	case nil:
+		// nothing to do
+
+	case *ast.Program:
+		a.applyList(n, "Statements")
+
+	// statements
+	case *ast.IfStatement:
+		a.applyList(n, "Conditionals")
+		a.apply(n, "Alternative", nil, n.Alternative)
+	case *ast.ClassStatement:
+		a.applyList(n, "Attributes")
+		a.apply(n, "SuperClass", nil, n.SuperClass)
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.InterfaceStatement:
+		a.applyList(n, "Attributes")
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.TraitStatement:
+		a.applyList(n, "Attributes")
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.EnumStatement:
+		a.applyList(n, "Attributes")
+		a.apply(n, "BackingType", nil, n.BackingType)
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.BlockStatement:
+		a.applyList(n, "Statements")
+	case *ast.ReturnStatement:
+		a.apply(n, "ReturnValue", nil, n.ReturnValue)
+	case *ast.ExpressionStatement:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.WhileStatement:
+		a.apply(n, "Condition", nil, n.Condition)
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.DoStatement:
+		a.apply(n, "Body", nil, n.Body)
+		a.apply(n, "Condition", nil, n.Condition)
+	case *ast.ForStatement:
+		a.applyList(n, "Init")
+		a.applyList(n, "Condition")
+		a.applyList(n, "Loop")
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.ForeachStatement:
+		a.apply(n, "Expression", nil, n.Expression)
+		a.apply(n, "Key", nil, n.Key)
+		a.apply(n, "Value", nil, n.Value)
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.SwitchStatement:
+		a.apply(n, "Condition", nil, n.Condition)
+		a.applyList(n, "Cases")
+	case *ast.CaseStatement:
+		a.apply(n, "Value", nil, n.Value)
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.BreakStatement:
+		a.apply(n, "Level", nil, n.Level)
+	case *ast.ContinueStatement:
+		a.apply(n, "Level", nil, n.Level)
+	case *ast.TryStatement:
+		a.apply(n, "Body", nil, n.Body)
+		a.applyList(n, "Catches")
+		a.apply(n, "Finally", nil, n.Finally)
+	case *ast.CatchStatement:
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.ThrowStatement:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.EchoStatement:
+		a.applyList(n, "Expressions")
+	case *ast.GlobalStatement:
+		a.applyList(n, "Names")
+	case *ast.StaticStatement:
+		a.applyList(n, "Vars")
+	case *ast.StaticVar:
+		a.apply(n, "Default", nil, n.Default)
+	case *ast.UnsetStatement:
+		a.applyList(n, "Variables")
+	case *ast.LabelStatement, *ast.GotoStatement, *ast.InlineHtmlStatement, *ast.BadStatement:
+		// nothing to do
+
+	// declarations
+	case *ast.AttributeGroup:
+		a.applyList(n, "Attributes")
+	case *ast.Attribute:
+		a.applyList(n, "Arguments")
+	case *ast.Parameter:
+		a.applyList(n, "Attributes")
+		a.apply(n, "Type", nil, n.Type)
+		a.apply(n, "Default", nil, n.Default)
+	case *ast.FunctionStatement:
+		a.applyList(n, "Attributes")
+		a.applyList(n, "Parameters")
+		a.apply(n, "ReturnType", nil, n.ReturnType)
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.ClosureExpression:
+		a.applyList(n, "Attributes")
+		a.applyList(n, "Parameters")
+		a.applyList(n, "Uses")
+		a.apply(n, "ReturnType", nil, n.ReturnType)
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.ArrowFunctionExpression:
+		a.applyList(n, "Attributes")
+		a.applyList(n, "Parameters")
+		a.apply(n, "ReturnType", nil, n.ReturnType)
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.PropertyStatement:
+		a.applyList(n, "Attributes")
+		a.apply(n, "Type", nil, n.Type)
+		a.applyList(n, "Properties")
+	case *ast.PropertyItem:
+		a.apply(n, "Default", nil, n.Default)
+	case *ast.ClassConstStatement:
+		a.applyList(n, "Attributes")
+		a.applyList(n, "Constants")
+	case *ast.ConstantItem:
+		a.apply(n, "Value", nil, n.Value)
+	case *ast.EnumCaseStatement:
+		a.applyList(n, "Attributes")
+		a.apply(n, "Value", nil, n.Value)
+	case *ast.MethodStatement:
+		a.applyList(n, "Attributes")
+		a.applyList(n, "Parameters")
+		a.apply(n, "ReturnType", nil, n.ReturnType)
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.TraitUseStatement:
+		a.applyList(n, "Adaptations")
+	case *ast.NamespaceStatement:
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.UseStatement:
+		a.applyList(n, "Uses")
+	case *ast.DeclareStatement:
+		a.applyList(n, "Directives")
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.ConstStatement:
+		a.applyList(n, "Constants")
+	case *ast.TypeHint, *ast.ClosureUse, *ast.TraitPrecedenceStatement, *ast.TraitAliasStatement, *ast.UseItem:
+		// nothing to do
+
+	// expressions
+	case *ast.ArrayExpression:
+		a.applyList(n, "Items")
+	case *ast.ListExpression:
+		a.applyList(n, "Items")
+	case *ast.ArrayItem:
+		a.apply(n, "Key", nil, n.Key)
+		a.apply(n, "Value", nil, n.Value)
+	case *ast.ConditionalExpression:
+		a.apply(n, "Condition", nil, n.Condition)
+		a.apply(n, "Consequence", nil, n.Consequence)
+	case *ast.DynamicVariable:
+		a.apply(n, "Name", nil, n.Name)
+	case *ast.IndexExpression:
+		a.apply(n, "Left", nil, n.Left)
+		a.apply(n, "Index", nil, n.Index)
+	case *ast.PropertyFetchExpression:
+		a.apply(n, "Object", nil, n.Object)
+		a.apply(n, "Property", nil, n.Property)
+	case *ast.StaticPropertyFetchExpression:
+		a.apply(n, "Class", nil, n.Class)
+		a.apply(n, "Property", nil, n.Property)
+	case *ast.ClassConstFetchExpression:
+		a.apply(n, "Class", nil, n.Class)
+	case *ast.CallExpression:
+		a.apply(n, "Function", nil, n.Function)
+		a.applyList(n, "Arguments")
+	case *ast.MethodCallExpression:
+		a.apply(n, "Object", nil, n.Object)
+		a.apply(n, "Method", nil, n.Method)
+		a.applyList(n, "Arguments")
+	case *ast.StaticCallExpression:
+		a.apply(n, "Class", nil, n.Class)
+		a.apply(n, "Method", nil, n.Method)
+		a.applyList(n, "Arguments")
+	case *ast.Argument:
+		a.apply(n, "Value", nil, n.Value)
+	case *ast.NewExpression:
+		a.apply(n, "Class", nil, n.Class)
+		a.applyList(n, "Arguments")
+		a.apply(n, "AnonymousClass", nil, n.AnonymousClass)
+	case *ast.CloneExpression:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.PrefixExpression:
+		a.apply(n, "Right", nil, n.Right)
+	case *ast.ThrowExpression:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.PostfixExpression:
+		a.apply(n, "Left", nil, n.Left)
+	case *ast.InfixExpression:
+		a.apply(n, "Left", nil, n.Left)
+		a.apply(n, "Right", nil, n.Right)
+	case *ast.TernaryExpression:
+		a.apply(n, "Condition", nil, n.Condition)
+		a.apply(n, "Consequence", nil, n.Consequence)
+		a.apply(n, "Alternative", nil, n.Alternative)
+	case *ast.CastExpression:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.AssignExpression:
+		a.apply(n, "Left", nil, n.Left)
+		a.apply(n, "Right", nil, n.Right)
+	case *ast.AssignRefExpression:
+		a.apply(n, "Left", nil, n.Left)
+		a.apply(n, "Right", nil, n.Right)
+	case *ast.CompoundAssignExpression:
+		a.apply(n, "Left", nil, n.Left)
+		a.apply(n, "Right", nil, n.Right)
+	case *ast.InterpolatedStringExpression:
+		a.applyList(n, "Parts")
+	case *ast.ShellExecExpression:
+		a.applyList(n, "Parts")
+	case *ast.IncludeOrEvalExpression:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.PrintExpression:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.YieldExpression:
+		a.apply(n, "Key", nil, n.Key)
+		a.apply(n, "Value", nil, n.Value)
+	case *ast.YieldFromExpression:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.IssetExpression:
+		a.applyList(n, "Variables")
+	case *ast.EmptyExpression:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.ExitExpression:
+		a.apply(n, "Expression", nil, n.Expression)
+	case *ast.MatchExpression:
+		a.apply(n, "Subject", nil, n.Subject)
+		a.applyList(n, "Arms")
+	case *ast.MatchArm:
+		a.applyList(n, "Conditions")
+		a.apply(n, "Body", nil, n.Body)
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Identifier,
+		*ast.BooleanExpression, *ast.NullExpression, *ast.Constant, *ast.Variable:
+		// nothing to do
+
+	default:
+		panic(fmt.Sprintf("Apply: unexpected node type %T", n))
+	}
+
+	if a.cursor.node != nil {
+		a.ancestors = a.ancestors[:len(a.ancestors)-1]
+	}
+
+	if a.post != nil && !a.post(&a.cursor) {
+		panic(abort)
+	}
+
+	a.cursor = saved
+}
+
+func (a *application) applyList(parent ast.Node, name string) {
+	// avoid heap-allocating a new iterator for each applyList call; reuse a.iter instead
+	saved := a.iter
+	a.iter.index = 0
+	for {
+		// must reload parent.name each time, since cursor modifications might change it
+		v := reflect.Indirect(reflect.ValueOf(parent)).FieldByName(name)
+		if a.iter.index >= v.Len() {
+			break
+		}
+
+		// element x may be nil, e.g. the skipped entries of list(, $a)
+		var x ast.Node
+		if e := v.Index(a.iter.index); e.IsValid() {
+			x, _ = e.Interface().(ast.Node)
+		}
+
+		a.iter.step = 1
+		a.apply(parent, name, &a.iter, x)
+		a.iter.index += a.iter.step
+	}
+	a.iter = saved
+}
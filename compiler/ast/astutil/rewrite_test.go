@@ -0,0 +1,316 @@
+package astutil
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/parser"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+const source = `<?php
+namespace App;
+use Foo\Bar;
+#[Attr(1)]
+function f(int $a = 1, ...$rest): ?int {
+	static $s = 1;
+	if ($a) { echo 1; } elseif ($b) { echo 2; } else { echo 3; }
+	for ($i = 0; $i < 10; $i++) { continue; }
+	foreach ($arr as $k => &$v) { break; }
+	switch ($a) { case 1: break; default: }
+	try { throw new Exception('x'); } catch (A $e) {} finally {}
+	return $a;
+}
+abstract class A extends B {
+	use T1, T2 { T1::foo insteadof T2; }
+	public const Z = 1;
+	public ?int $p = 1;
+	abstract public function m();
+}
+enum E: string { case A = 'a'; }
+[$a, , [$b]] = [1, 'k' => &$y, ...$z];
+$o?->p->q + A::$sp + A::C + A::m(...) + $o->m(1);
+$fn = static function ($a) use ($b): int { return 1; };
+$af = fn($x) => $x * 2;
+$n = new class(1) {};
+$s = "a $b {$c->d}";
+$m = match ($a) { 1, 2 => 'a', default => throw new E() };
+isset($a, $b) ? -$a : (int) $f ?? !$b;
+`
+
+func parse(t *testing.T, src string) *ast.Program {
+	program, err := parser.ParseFile(nil, "a.php", src, 0)
+	if err != nil {
+		t.Fatalf("parser error: %s", err)
+	}
+	return program
+}
+
+func variable(name string) *ast.Variable {
+	return &ast.Variable{BaseNode: &ast.BaseNode{Token: token.Token{Type: token.Variable, Literal: "$" + name}}, Name: name}
+}
+
+func echo(value int) *ast.EchoStatement {
+	tok := token.Token{Type: token.Lnumber, Literal: strconv.Itoa(value)}
+	return &ast.EchoStatement{
+		BaseNode:    &ast.BaseNode{Token: token.Token{Type: token.Echo, Literal: "echo"}},
+		Expressions: []ast.Expression{&ast.IntegerLiteral{BaseNode: &ast.BaseNode{Token: tok}, Value: value}},
+	}
+}
+
+// checkCursor verifies the invariants documented on Cursor
+func checkCursor(t *testing.T, c *Cursor) {
+	f := reflect.Indirect(reflect.ValueOf(c.Parent())).FieldByName(c.Name())
+	if i := c.Index(); i >= 0 {
+		f = f.Index(i)
+	}
+	var got ast.Node
+	if f.Kind() != reflect.Ptr || !f.IsNil() {
+		if f.Kind() != reflect.Interface || !f.IsNil() {
+			got = f.Interface().(ast.Node)
+		}
+	}
+	if got != c.Node() {
+		t.Errorf("%T.%s[%d] holds %T, the cursor is on %T", c.Parent(), c.Name(), c.Index(), got, c.Node())
+	}
+}
+
+func Test_ApplyVisitsLikeWalk(t *testing.T) {
+	program := parse(t, source)
+	var walked, applied []ast.Node
+	ast.Inspect(program, func(n ast.Node) bool {
+		if n != nil {
+			walked = append(walked, n)
+		}
+		return true
+	})
+	Apply(program, func(c *Cursor) bool {
+		checkCursor(t, c)
+		if c.Node() != nil {
+			applied = append(applied, c.Node())
+		}
+		return true
+	}, nil)
+	if len(walked) != len(applied) {
+		t.Fatalf("Walk visited %d nodes, Apply %d", len(walked), len(applied))
+	}
+	for i := range walked {
+		if walked[i] != applied[i] {
+			t.Fatalf("node %d: Walk visited %T, Apply %T", i, walked[i], applied[i])
+		}
+	}
+}
+
+func Test_Replace(t *testing.T) {
+	program := parse(t, `<?php $a = $a + foo($a); if ($b) { echo $a; }`)
+	Apply(program, func(c *Cursor) bool {
+		if v, ok := c.Node().(*ast.Variable); ok && v.Name == "a" {
+			c.Replace(variable("x"))
+		}
+		// fill the missing else block
+		if _, ok := c.Parent().(*ast.IfStatement); ok && c.Name() == "Alternative" && c.Node() == nil {
+			c.Replace(&ast.BlockStatement{BaseNode: &ast.BaseNode{}, Statements: []ast.Statement{echo(2)}})
+		}
+		return true
+	}, nil)
+	s := program.String()
+	if strings.Contains(s, "$a") || strings.Count(s, "$x") != 4 {
+		t.Errorf("expected $a to be replaced, got %q", s)
+	}
+	if alt := program.Statements[1].(*ast.IfStatement).Alternative; alt == nil || len(alt.Statements) != 1 {
+		t.Errorf("expected the else block to be added")
+	}
+
+	// the root can be replaced as well
+	root := Apply(program, func(c *Cursor) bool {
+		if _, ok := c.Node().(*ast.Program); ok {
+			c.Replace(&ast.Program{Statements: []ast.Statement{echo(1)}})
+		}
+		return true
+	}, nil)
+	if p, ok := root.(*ast.Program); !ok || p == program || len(p.Statements) != 1 {
+		t.Errorf("expected the new root, got %v", root)
+	}
+}
+
+func Test_DeleteAndInsert(t *testing.T) {
+	program := parse(t, `<?php echo 1; $a = 1; echo 2; function f() { echo 3; $b = 2; echo 4; }`)
+	var visited []string
+	Apply(program, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.EchoStatement:
+			visited = append(visited, n.Expressions[0].String())
+			c.Delete()
+			return false
+		case *ast.ExpressionStatement:
+			checkCursor(t, c)
+			c.InsertBefore(echo(10))
+			checkCursor(t, c)
+			c.InsertAfter(echo(20))
+			checkCursor(t, c)
+		}
+		return true
+	}, nil)
+
+	// deleted nodes don't shift the iteration and inserted ones are not visited
+	if s := strings.Join(visited, ","); s != "1,2,3,4" {
+		t.Errorf("expected each echo to be visited once, got %q", s)
+	}
+	var got []string
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.EchoStatement:
+			got = append(got, n.Expressions[0].String())
+		case *ast.ExpressionStatement:
+			got = append(got, n.Expression.String())
+		}
+		return true
+	})
+	if s := strings.Join(got, " "); s != "10 ($a = 1) 20 10 ($b = 2) 20" {
+		t.Errorf("unexpected statements %q", s)
+	}
+}
+
+func Test_DeleteListItems(t *testing.T) {
+	program := parse(t, `<?php foo(1, 2, 3); [$a, , $b] = $c;`)
+	Apply(program, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.Argument:
+			if n.Value.String() == "2" {
+				c.Delete()
+			}
+		case nil:
+			if c.Name() == "Items" {
+				c.Delete() // the skipped entry of the list
+			}
+		}
+		return true
+	}, nil)
+	call := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	list := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression).Left.(*ast.ListExpression)
+	if len(call.Arguments) != 2 || len(list.Items) != 2 {
+		t.Errorf("expected 2 arguments and 2 list items, got %d and %d", len(call.Arguments), len(list.Items))
+	}
+}
+
+func Test_ApplyAbort(t *testing.T) {
+	program := parse(t, `<?php $a; $b; $c;`)
+	var names []string
+	Apply(program, nil, func(c *Cursor) bool {
+		if v, ok := c.Node().(*ast.Variable); ok {
+			names = append(names, v.Name)
+			return v.Name != "b"
+		}
+		return true
+	})
+	if s := strings.Join(names, ","); s != "a,b" {
+		t.Errorf("expected the traversal to stop after $b, got %q", s)
+	}
+}
+
+func Test_CursorPanics(t *testing.T) {
+	program := parse(t, `<?php $a = 1;`)
+	for name, op := range map[string]func(*Cursor){
+		"Delete":       func(c *Cursor) { c.Delete() },
+		"InsertBefore": func(c *Cursor) { c.InsertBefore(variable("b")) },
+		"InsertAfter":  func(c *Cursor) { c.InsertAfter(variable("b")) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("expected %s to panic outside of a slice", name)
+				}
+			}()
+			Apply(program, func(c *Cursor) bool {
+				if _, ok := c.Node().(*ast.Variable); ok {
+					op(c)
+				}
+				return true
+			}, nil)
+		}()
+	}
+}
+
+// checkRanges verifies that every node has a range inside the one of its
+// parent
+func checkRanges(t *testing.T, root ast.Node) {
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		pos, end := n.Pos(), n.End()
+		if !pos.IsValid() || !end.IsValid() || pos.Offset > end.Offset {
+			t.Errorf("%T %q has the invalid range %s-%s", n, n.String(), pos, end)
+			return true
+		}
+		for _, c := range ast.Children(n) {
+			if c.Pos().Offset < pos.Offset || c.End().Offset > end.Offset {
+				t.Errorf("%T %q: child %T %q is outside of it", n, n.String(), c, c.String())
+			}
+		}
+		return true
+	})
+}
+
+func Test_ApplyRanges(t *testing.T) {
+	src := `<?php $a = $a + foo($a); if ($b) { echo 1; $c = 2; echo 3; } $d;`
+	program := parse(t, src)
+	moved := program.Statements[2].(*ast.ExpressionStatement).Expression
+	var inserted, replaced []ast.Node
+	Apply(program, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.Variable:
+			if n.Name == "a" {
+				x := variable("x")
+				c.Replace(x)
+				replaced = append(replaced, x)
+			}
+		case *ast.EchoStatement:
+			c.Delete()
+			return false
+		case *ast.ExpressionStatement:
+			if _, ok := c.Parent().(*ast.BlockStatement); ok {
+				before, after := echo(10), echo(20)
+				c.InsertBefore(before)
+				c.InsertAfter(after)
+				inserted = append(inserted, before, after)
+			}
+		case *ast.IntegerLiteral:
+			// $c = $d, the variable moved from the end of the program
+			if n.Value == 2 {
+				c.Replace(moved)
+			}
+		case nil:
+			if _, ok := c.Parent().(*ast.IfStatement); ok && c.Name() == "Alternative" {
+				c.Replace(&ast.BlockStatement{Statements: []ast.Statement{echo(4)}})
+				return false
+			}
+		}
+		return true
+	}, nil)
+	checkRanges(t, program)
+
+	for _, x := range replaced {
+		if s := ast.Source(src, x); s != "$a" {
+			t.Errorf("expected the replacing node to get the range of $a, got %q", s)
+		}
+	}
+	ifStmt := program.Statements[1].(*ast.IfStatement)
+	if pos := ifStmt.Alternative.Statements[0].Pos(); pos != ifStmt.End() {
+		t.Errorf("expected the added else block at the end of the if statement, got %s", pos)
+	}
+	block := ifStmt.Conditionals[0].Consequence
+	assign := block.Statements[1]
+	if pos, end := inserted[0].Pos(), inserted[0].End(); pos != assign.Pos() || end != pos {
+		t.Errorf("expected an empty range before the statement, got %s-%s", pos, end)
+	}
+	if end := assign.End(); end != moved.End() {
+		t.Errorf("expected the statement widened to the moved variable, got %s", end)
+	}
+	if s := ast.Source(src, block); !strings.HasPrefix(s, "{ echo 1;") || !strings.HasSuffix(s, "$d") {
+		t.Errorf("expected the block widened to the moved variable, got %q", s)
+	}
+}
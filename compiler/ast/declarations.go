@@ -0,0 +1,886 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Modifier is a set of class member modifiers, combined with bitwise or
+type Modifier int
+
+const (
+	ModifierPublic Modifier = 1 << iota
+	ModifierProtected
+	ModifierPrivate
+	ModifierStatic
+	ModifierAbstract
+	ModifierFinal
+	ModifierReadonly
+
+	ModifierVisibility = ModifierPublic | ModifierProtected | ModifierPrivate
+)
+
+var modifierNames = []struct {
+	m    Modifier
+	name string
+}{
+	{ModifierAbstract, "abstract"},
+	{ModifierFinal, "final"},
+	{ModifierPublic, "public"},
+	{ModifierProtected, "protected"},
+	{ModifierPrivate, "private"},
+	{ModifierStatic, "static"},
+	{ModifierReadonly, "readonly"},
+}
+
+// Has reports whether all modifiers of m are set
+func (ms Modifier) Has(m Modifier) bool {
+	return ms&m == m
+}
+
+// String returns the modifiers in source form, e.g. "abstract public static"
+func (ms Modifier) String() string {
+	names := []string{}
+	for _, mn := range modifierNames {
+		if ms.Has(mn.m) {
+			names = append(names, mn.name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func writeModifiers(out *bytes.Buffer, ms Modifier) {
+	if ms != 0 {
+		out.WriteString(ms.String())
+		out.WriteString(" ")
+	}
+}
+
+// ----------------Attribute----------------
+
+// Attribute represents `Name(args)` in an attribute group, Arguments is
+// nil when there are no parentheses.
+type Attribute struct {
+	*BaseNode
+	Name      string
+	Arguments []*Argument
+}
+
+func (at *Attribute) TokenLiteral() string {
+	return at.Token.Literal
+}
+
+func (at *Attribute) String() string {
+	if at.Arguments == nil {
+		return at.Name
+	}
+	return at.Name + writeArguments(at.Arguments)
+}
+
+func (at *Attribute) Kind() Kind {
+	return KindAttribute
+}
+
+// AttributeGroup represents `#[A, B(args)]`
+type AttributeGroup struct {
+	*BaseNode
+	Attributes []*Attribute
+}
+
+func (ag *AttributeGroup) TokenLiteral() string {
+	return ag.Token.Literal
+}
+
+func (ag *AttributeGroup) String() string {
+	var out bytes.Buffer
+	out.WriteString("#[")
+	for i, attr := range ag.Attributes {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(attr.String())
+	}
+	out.WriteString("]")
+	return out.String()
+}
+
+func (ag *AttributeGroup) Kind() Kind {
+	return KindAttributeGroup
+}
+
+// writeAttributes writes the attribute groups preceding a declaration
+func writeAttributes(out *bytes.Buffer, groups []*AttributeGroup) {
+	for _, g := range groups {
+		out.WriteString(g.String())
+		out.WriteString(" ")
+	}
+}
+
+// ----------------TypeHint----------------
+
+// TypeHint represents a parameter, return or property type declaration,
+// Types holds more than one name for union types like `int|string`.
+type TypeHint struct {
+	*BaseNode
+	Types    []string
+	Nullable bool
+}
+
+func (th *TypeHint) TokenLiteral() string {
+	return th.Token.Literal
+}
+
+func (th *TypeHint) String() string {
+	if th.Nullable {
+		return "?" + th.Types[0]
+	}
+	return strings.Join(th.Types, "|")
+}
+
+func (th *TypeHint) Kind() Kind {
+	switch {
+	case len(th.Types) > 1:
+		return KindTypeUnion
+	case th.Nullable:
+		return KindNullableType
+	case isBuiltinType(th.Types[0]):
+		return KindType
+	}
+	return KindName
+}
+
+// ----------------Parameter----------------
+
+// Parameter represents a function, method or closure parameter. Modifiers
+// are set for promoted constructor parameters like `public int $x`.
+type Parameter struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Name       string
+	Type       *TypeHint
+	Default    Expression
+	ByRef      bool
+	Variadic   bool
+	Modifiers  Modifier
+}
+
+func (pa *Parameter) TokenLiteral() string {
+	return pa.Token.Literal
+}
+
+func (pa *Parameter) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, pa.Attributes)
+	writeModifiers(&out, pa.Modifiers)
+	if pa.Type != nil {
+		out.WriteString(pa.Type.String())
+		out.WriteString(" ")
+	}
+	if pa.ByRef {
+		out.WriteString("&")
+	}
+	if pa.Variadic {
+		out.WriteString("...")
+	}
+	out.WriteString("$")
+	out.WriteString(pa.Name)
+	if pa.Default != nil {
+		out.WriteString(" = ")
+		out.WriteString(pa.Default.String())
+	}
+	return out.String()
+}
+
+func (pa *Parameter) Kind() Kind {
+	return KindParam
+}
+
+// IsPromoted reports whether the parameter declares a constructor promoted property
+func (pa *Parameter) IsPromoted() bool {
+	return pa.Modifiers != 0
+}
+
+func writeParameters(out *bytes.Buffer, params []*Parameter) {
+	out.WriteString("(")
+	for i, param := range params {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(param.String())
+	}
+	out.WriteString(")")
+}
+
+func writeReturnType(out *bytes.Buffer, typ *TypeHint) {
+	if typ != nil {
+		out.WriteString(": ")
+		out.WriteString(typ.String())
+	}
+}
+
+// ----------------FunctionStatement----------------
+
+// FunctionStatement represents a named function declaration
+type FunctionStatement struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Name       string
+	Parameters []*Parameter
+	ReturnType *TypeHint
+	ByRef      bool
+	Body       *BlockStatement
+}
+
+func (st *FunctionStatement) stmtNode() {}
+
+func (fs *FunctionStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *FunctionStatement) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, fs.Attributes)
+	out.WriteString("function ")
+	if fs.ByRef {
+		out.WriteString("&")
+	}
+	out.WriteString(fs.Name)
+	writeParameters(&out, fs.Parameters)
+	writeReturnType(&out, fs.ReturnType)
+	out.WriteString(" {\n")
+	out.WriteString(fs.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (fs *FunctionStatement) Kind() Kind {
+	return KindFuncDecl
+}
+
+// ----------------ClosureExpression----------------
+
+// ClosureUse is a variable imported by `use ($a, &$b)` of a closure
+type ClosureUse struct {
+	*BaseNode
+	Name  string
+	ByRef bool
+}
+
+func (cu *ClosureUse) TokenLiteral() string {
+	return cu.Token.Literal
+}
+
+func (cu *ClosureUse) String() string {
+	if cu.ByRef {
+		return "&$" + cu.Name
+	}
+	return "$" + cu.Name
+}
+
+func (cu *ClosureUse) Kind() Kind {
+	return KindClosureVar
+}
+
+// ClosureExpression represents `[static] function [&](params) [use (vars)] [: type] { body }`
+type ClosureExpression struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Parameters []*Parameter
+	Uses       []*ClosureUse
+	ReturnType *TypeHint
+	ByRef      bool
+	Static     bool
+	Body       *BlockStatement
+}
+
+func (e *ClosureExpression) exprNode() {}
+
+func (ce *ClosureExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+
+func (ce *ClosureExpression) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, ce.Attributes)
+	if ce.Static {
+		out.WriteString("static ")
+	}
+	out.WriteString("function ")
+	if ce.ByRef {
+		out.WriteString("&")
+	}
+	writeParameters(&out, ce.Parameters)
+	if len(ce.Uses) > 0 {
+		out.WriteString(" use (")
+		for i, use := range ce.Uses {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+			out.WriteString(use.String())
+		}
+		out.WriteString(")")
+	}
+	writeReturnType(&out, ce.ReturnType)
+	out.WriteString(" {\n")
+	out.WriteString(ce.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (ce *ClosureExpression) Kind() Kind {
+	return KindClosure
+}
+
+// ----------------ArrowFunctionExpression----------------
+
+// ArrowFunctionExpression represents `[static] fn [&](params) [: type] => expr`
+type ArrowFunctionExpression struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Parameters []*Parameter
+	ReturnType *TypeHint
+	ByRef      bool
+	Static     bool
+	Expression Expression
+}
+
+func (e *ArrowFunctionExpression) exprNode() {}
+
+func (af *ArrowFunctionExpression) TokenLiteral() string {
+	return af.Token.Literal
+}
+
+func (af *ArrowFunctionExpression) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, af.Attributes)
+	if af.Static {
+		out.WriteString("static ")
+	}
+	out.WriteString("fn")
+	if af.ByRef {
+		out.WriteString("&")
+	}
+	writeParameters(&out, af.Parameters)
+	writeReturnType(&out, af.ReturnType)
+	out.WriteString(" => ")
+	out.WriteString(af.Expression.String())
+	return out.String()
+}
+
+func (af *ArrowFunctionExpression) Kind() Kind {
+	return KindArrowFunc
+}
+
+// ----------------PropertyStatement----------------
+
+// PropertyStatement represents `modifiers [type] $a [= expr], $b ...;` in a class body
+type PropertyStatement struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Modifiers  Modifier
+	Type       *TypeHint
+	Properties []*PropertyItem
+}
+
+func (st *PropertyStatement) stmtNode() {}
+
+func (ps *PropertyStatement) TokenLiteral() string {
+	return ps.Token.Literal
+}
+
+func (ps *PropertyStatement) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, ps.Attributes)
+	writeModifiers(&out, ps.Modifiers)
+	if ps.Type != nil {
+		out.WriteString(ps.Type.String())
+		out.WriteString(" ")
+	}
+	for i, prop := range ps.Properties {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(prop.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (ps *PropertyStatement) Kind() Kind {
+	return KindPropGroup
+}
+
+// PropertyItem is a single property of a PropertyStatement, Default is nil
+// if there is no initializer.
+type PropertyItem struct {
+	*BaseNode
+	Name    string
+	Default Expression
+}
+
+func (pi *PropertyItem) TokenLiteral() string {
+	return pi.Token.Literal
+}
+
+func (pi *PropertyItem) String() string {
+	if pi.Default != nil {
+		return "$" + pi.Name + " = " + pi.Default.String()
+	}
+	return "$" + pi.Name
+}
+
+func (pi *PropertyItem) Kind() Kind {
+	return KindPropElem
+}
+
+// ----------------ClassConstStatement----------------
+
+// ClassConstStatement represents `[modifiers] const A = expr, B = expr;` in a class body
+type ClassConstStatement struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Modifiers  Modifier
+	Constants  []*ConstantItem
+}
+
+func (st *ClassConstStatement) stmtNode() {}
+
+func (cs *ClassConstStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ClassConstStatement) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, cs.Attributes)
+	writeModifiers(&out, cs.Modifiers)
+	out.WriteString("const ")
+	writeConstants(&out, cs.Constants)
+	out.WriteString(";")
+	return out.String()
+}
+
+func (cs *ClassConstStatement) Kind() Kind {
+	return KindClassConstGroup
+}
+
+// ConstantItem is a single `NAME = expr` of a constant declaration
+type ConstantItem struct {
+	*BaseNode
+	Name  string
+	Value Expression
+}
+
+func (ci *ConstantItem) TokenLiteral() string {
+	return ci.Token.Literal
+}
+
+func (ci *ConstantItem) String() string {
+	return ci.Name + " = " + ci.Value.String()
+}
+
+func (ci *ConstantItem) Kind() Kind {
+	return KindConstElem
+}
+
+func writeConstants(out *bytes.Buffer, consts []*ConstantItem) {
+	for i, c := range consts {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(c.String())
+	}
+}
+
+// ----------------EnumCaseStatement----------------
+
+// EnumCaseStatement represents `case NAME [= expr];` in an enum body,
+// Value is nil for the cases of pure enums.
+type EnumCaseStatement struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Name       string
+	Value      Expression
+}
+
+func (st *EnumCaseStatement) stmtNode() {}
+
+func (ec *EnumCaseStatement) TokenLiteral() string {
+	return ec.Token.Literal
+}
+
+func (ec *EnumCaseStatement) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, ec.Attributes)
+	out.WriteString("case ")
+	out.WriteString(ec.Name)
+	if ec.Value != nil {
+		out.WriteString(" = ")
+		out.WriteString(ec.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (ec *EnumCaseStatement) Kind() Kind {
+	return KindEnumCase
+}
+
+// ----------------MethodStatement----------------
+
+// MethodStatement represents a method declaration, Body is nil for
+// abstract and interface methods.
+type MethodStatement struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Modifiers  Modifier
+	Name       string
+	Parameters []*Parameter
+	ReturnType *TypeHint
+	ByRef      bool
+	Body       *BlockStatement
+}
+
+func (st *MethodStatement) stmtNode() {}
+
+func (ms *MethodStatement) TokenLiteral() string {
+	return ms.Token.Literal
+}
+
+func (ms *MethodStatement) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, ms.Attributes)
+	writeModifiers(&out, ms.Modifiers)
+	out.WriteString("function ")
+	if ms.ByRef {
+		out.WriteString("&")
+	}
+	out.WriteString(ms.Name)
+	writeParameters(&out, ms.Parameters)
+	writeReturnType(&out, ms.ReturnType)
+	if ms.Body == nil {
+		out.WriteString(";")
+		return out.String()
+	}
+	out.WriteString(" {\n")
+	out.WriteString(ms.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (ms *MethodStatement) Kind() Kind {
+	return KindMethod
+}
+
+// IsAbstract reports whether the method has no body
+func (ms *MethodStatement) IsAbstract() bool {
+	return ms.Body == nil
+}
+
+// ----------------TraitUseStatement----------------
+
+// TraitUseStatement represents `use A, B { adaptations }` in a class body,
+// Adaptations holds TraitPrecedenceStatement and TraitAliasStatement nodes.
+type TraitUseStatement struct {
+	*BaseNode
+	Traits      []string
+	Adaptations []Statement
+}
+
+func (st *TraitUseStatement) stmtNode() {}
+
+func (tu *TraitUseStatement) TokenLiteral() string {
+	return tu.Token.Literal
+}
+
+func (tu *TraitUseStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("use ")
+	out.WriteString(strings.Join(tu.Traits, ", "))
+	if len(tu.Adaptations) == 0 {
+		out.WriteString(";")
+		return out.String()
+	}
+	out.WriteString(" {\n")
+	for _, a := range tu.Adaptations {
+		out.WriteString(a.String())
+		out.WriteString("\n")
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+func (tu *TraitUseStatement) Kind() Kind {
+	return KindUseTrait
+}
+
+// TraitPrecedenceStatement represents `A::foo insteadof B, C;`
+type TraitPrecedenceStatement struct {
+	*BaseNode
+	Trait     string
+	Method    string
+	InsteadOf []string
+}
+
+func (st *TraitPrecedenceStatement) stmtNode() {}
+
+func (tp *TraitPrecedenceStatement) TokenLiteral() string {
+	return tp.Token.Literal
+}
+
+func (tp *TraitPrecedenceStatement) String() string {
+	return tp.Trait + "::" + tp.Method + " insteadof " + strings.Join(tp.InsteadOf, ", ") + ";"
+}
+
+func (tp *TraitPrecedenceStatement) Kind() Kind {
+	return KindTraitPrecedence
+}
+
+// TraitAliasStatement represents `[A::]foo as [modifier] [alias];`,
+// Trait and Alias may be empty.
+type TraitAliasStatement struct {
+	*BaseNode
+	Trait     string
+	Method    string
+	Modifiers Modifier
+	Alias     string
+}
+
+func (st *TraitAliasStatement) stmtNode() {}
+
+func (ta *TraitAliasStatement) TokenLiteral() string {
+	return ta.Token.Literal
+}
+
+func (ta *TraitAliasStatement) String() string {
+	var out bytes.Buffer
+	if ta.Trait != "" {
+		out.WriteString(ta.Trait)
+		out.WriteString("::")
+	}
+	out.WriteString(ta.Method)
+	out.WriteString(" as")
+	if ta.Modifiers != 0 {
+		out.WriteString(" ")
+		out.WriteString(ta.Modifiers.String())
+	}
+	if ta.Alias != "" {
+		out.WriteString(" ")
+		out.WriteString(ta.Alias)
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (ta *TraitAliasStatement) Kind() Kind {
+	return KindTraitAlias
+}
+
+// ----------------NamespaceStatement----------------
+
+// NamespaceStatement represents `namespace Name;` or `namespace [Name] { ... }`.
+// Like zend_ast, the unbraced form has a nil Body and the statements
+// following it are its siblings.
+type NamespaceStatement struct {
+	*BaseNode
+	Name string // empty for the global namespace `namespace { ... }`
+	Body *BlockStatement
+}
+
+func (st *NamespaceStatement) stmtNode() {}
+
+func (ns *NamespaceStatement) TokenLiteral() string {
+	return ns.Token.Literal
+}
+
+func (ns *NamespaceStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("namespace")
+	if ns.Name != "" {
+		out.WriteString(" ")
+		out.WriteString(ns.Name)
+	}
+	if ns.Body == nil {
+		out.WriteString(";")
+		return out.String()
+	}
+	out.WriteString(" {\n")
+	out.WriteString(ns.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (ns *NamespaceStatement) Kind() Kind {
+	return KindNamespace
+}
+
+// IsBraced reports whether the namespace uses the `namespace Name { ... }` form
+func (ns *NamespaceStatement) IsBraced() bool {
+	return ns.Body != nil
+}
+
+// ----------------UseStatement----------------
+
+// UseKind tells what a use statement imports
+type UseKind int
+
+const (
+	UseNormal UseKind = iota // classes, interfaces, traits and namespaces
+	UseFunction
+	UseConst
+)
+
+func (k UseKind) String() string {
+	switch k {
+	case UseFunction:
+		return "function"
+	case UseConst:
+		return "const"
+	}
+	return ""
+}
+
+// UseStatement represents `use [function|const] A\B [as C], ...;` and the
+// group form `use A\{B, C as D};` which has a non-empty Prefix.
+type UseStatement struct {
+	*BaseNode
+	Type   UseKind
+	Prefix string
+	Uses   []*UseItem
+}
+
+func (st *UseStatement) stmtNode() {}
+
+func (us *UseStatement) TokenLiteral() string {
+	return us.Token.Literal
+}
+
+func (us *UseStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("use ")
+	if us.Type != UseNormal {
+		out.WriteString(us.Type.String())
+		out.WriteString(" ")
+	}
+	if us.Prefix != "" {
+		out.WriteString(us.Prefix)
+		out.WriteString("\\{")
+	}
+	for i, use := range us.Uses {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(use.String())
+	}
+	if us.Prefix != "" {
+		out.WriteString("}")
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (us *UseStatement) Kind() Kind {
+	if us.Prefix != "" {
+		return KindGroupUse
+	}
+	return KindUse
+}
+
+// UseItem is a single imported name, Type is only set for the items of a
+// mixed group use like `use A\{function b, const C}`.
+type UseItem struct {
+	*BaseNode
+	Type  UseKind
+	Name  string
+	Alias string
+}
+
+func (ui *UseItem) TokenLiteral() string {
+	return ui.Token.Literal
+}
+
+func (ui *UseItem) String() string {
+	var out bytes.Buffer
+	if ui.Type != UseNormal {
+		out.WriteString(ui.Type.String())
+		out.WriteString(" ")
+	}
+	out.WriteString(ui.Name)
+	if ui.Alias != "" {
+		out.WriteString(" as ")
+		out.WriteString(ui.Alias)
+	}
+	return out.String()
+}
+
+func (ui *UseItem) Kind() Kind {
+	return KindUseElem
+}
+
+// ----------------DeclareStatement----------------
+
+// DeclareStatement represents `declare(name=value, ...)` followed by `;`,
+// a statement, a block or `: ... enddeclare;`. Body is nil for the
+// statement form `declare(strict_types=1);`.
+type DeclareStatement struct {
+	*BaseNode
+	Directives []*ConstantItem
+	Body       *BlockStatement
+}
+
+func (st *DeclareStatement) stmtNode() {}
+
+func (ds *DeclareStatement) TokenLiteral() string {
+	return ds.Token.Literal
+}
+
+func (ds *DeclareStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("declare(")
+	for i, d := range ds.Directives {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(d.Name)
+		out.WriteString("=")
+		out.WriteString(d.Value.String())
+	}
+	out.WriteString(")")
+	if ds.Body == nil {
+		out.WriteString(";")
+		return out.String()
+	}
+	out.WriteString(" {\n")
+	out.WriteString(ds.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (ds *DeclareStatement) Kind() Kind {
+	return KindDeclare
+}
+
+// ----------------ConstStatement----------------
+
+// ConstStatement represents a namespace level `const A = expr, B = expr;`
+type ConstStatement struct {
+	*BaseNode
+	Constants []*ConstantItem
+}
+
+func (st *ConstStatement) stmtNode() {}
+
+func (cs *ConstStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ConstStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("const ")
+	writeConstants(&out, cs.Constants)
+	out.WriteString(";")
+	return out.String()
+}
+
+func (cs *ConstStatement) Kind() Kind {
+	return KindConstDecl
+}
@@ -1,6 +1,9 @@
 package ast
 
-import "bytes"
+import (
+	"bytes"
+	"strings"
+)
 
 // IntegerLiteral contains the node expression and its value
 type IntegerLiteral struct {
@@ -20,6 +23,10 @@ func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+func (il *IntegerLiteral) Kind() Kind {
+	return KindZval
+}
+
 // FloatLiteral contains the node expression and its value
 type FloatLiteral struct {
 	*BaseNode
@@ -38,6 +45,10 @@ func (il *FloatLiteral) String() string {
 	return il.Token.Literal
 }
 
+func (il *FloatLiteral) Kind() Kind {
+	return KindZval
+}
+
 // StringLiteral contains the node expression and its value
 type StringLiteral struct {
 	*BaseNode
@@ -52,12 +63,16 @@ func (sl *StringLiteral) TokenLiteral() string {
 
 func (sl *StringLiteral) String() string {
 	var out bytes.Buffer
-	out.WriteString("\"")
-	out.WriteString(sl.Token.Literal)
-	out.WriteString("\"")
+	out.WriteString("'")
+	out.WriteString(strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(sl.Value))
+	out.WriteString("'")
 	return out.String()
 }
 
+func (sl *StringLiteral) Kind() Kind {
+	return KindZval
+}
+
 type Identifier struct {
 	*BaseNode
 	Value string
@@ -73,6 +88,10 @@ func (i *Identifier) String() string {
 	return i.Value
 }
 
+func (i *Identifier) Kind() Kind {
+	return KindZval
+}
+
 type BooleanExpression struct {
 	*BaseNode
 	Value bool
@@ -88,6 +107,10 @@ func (b *BooleanExpression) String() string {
 	return b.Token.Literal
 }
 
+func (b *BooleanExpression) Kind() Kind {
+	return KindConst
+}
+
 type NullExpression struct {
 	*BaseNode
 	Value string
@@ -103,9 +126,16 @@ func (b *NullExpression) String() string {
 	return b.Token.Literal
 }
 
+func (b *NullExpression) Kind() Kind {
+	return KindConst
+}
+
+// ArrayExpression represents `[...]` or `array(...)`, a nil item stands
+// for an empty slot, which is only valid once converted to a list.
 type ArrayExpression struct {
 	*BaseNode
-	Elements []Expression
+	Items   []*ArrayItem
+	IsShort bool
 }
 
 func (e *ArrayExpression) exprNode() {}
@@ -115,23 +145,69 @@ func (ae *ArrayExpression) TokenLiteral() string {
 }
 
 func (ae *ArrayExpression) String() string {
-	var out bytes.Buffer
+	return writeArrayItems(ae.Items, ae.IsShort, "array(")
+}
 
-	out.WriteString("[")
+func (ae *ArrayExpression) Kind() Kind {
+	return KindArray
+}
 
-	if len(ae.Elements) == 0 {
-		out.WriteString("]")
-		return out.String()
-	}
+// ArrayItem is an element of an array or a list, `[key =>] [&]value` or
+// `...value`
+type ArrayItem struct {
+	*BaseNode
+	Key    Expression
+	Value  Expression
+	ByRef  bool
+	Unpack bool
+}
 
-	out.WriteString(ae.Elements[0].String())
+func (ai *ArrayItem) TokenLiteral() string {
+	return ai.Token.Literal
+}
+
+func (ai *ArrayItem) String() string {
+	var out bytes.Buffer
+	if ai.Key != nil {
+		out.WriteString(ai.Key.String())
+		out.WriteString(" => ")
+	}
+	if ai.ByRef {
+		out.WriteString("&")
+	}
+	if ai.Unpack {
+		out.WriteString("...")
+	}
+	out.WriteString(ai.Value.String())
+	return out.String()
+}
 
-	for _, elem := range ae.Elements[1:] {
-		out.WriteString(", ")
-		out.WriteString(elem.String())
+func (ai *ArrayItem) Kind() Kind {
+	if ai.Unpack {
+		return KindUnpack
 	}
+	return KindArrayElem
+}
 
-	out.WriteString("]")
+func writeArrayItems(items []*ArrayItem, isShort bool, open string) string {
+	var out bytes.Buffer
+	if isShort {
+		open = "["
+	}
+	out.WriteString(open)
+	for i, item := range items {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		if item != nil {
+			out.WriteString(item.String())
+		}
+	}
+	if isShort {
+		out.WriteString("]")
+	} else {
+		out.WriteString(")")
+	}
 	return out.String()
 }
 
@@ -161,6 +237,12 @@ func (ce *ConditionalExpression) String() string {
 	return out.String()
 }
 
+func (ce *ConditionalExpression) Kind() Kind {
+	return KindIfElem
+}
+
+// Constant represents a constant name, IsNamespace is set for qualified
+// names like `\Foo\BAR`, `Foo\BAR` or `namespace\BAR`.
 type Constant struct {
 	*BaseNode
 	Value       string
@@ -175,3 +257,931 @@ func (c *Constant) TokenLiteral() string {
 func (c *Constant) String() string {
 	return c.Value
 }
+
+func (c *Constant) Kind() Kind {
+	if isMagicConstant(c.Value) {
+		return KindMagicConst
+	}
+	return KindConst
+}
+
+// Variable represents `$name`, Name is stored without the leading `$`
+type Variable struct {
+	*BaseNode
+	Name string
+}
+
+func (e *Variable) exprNode() {}
+
+func (v *Variable) TokenLiteral() string {
+	return v.Token.Literal
+}
+
+func (v *Variable) String() string {
+	return "$" + v.Name
+}
+
+func (v *Variable) Kind() Kind {
+	return KindVar
+}
+
+// DynamicVariable represents a variable whose name is computed, like
+// `${expr}` inside a string.
+type DynamicVariable struct {
+	*BaseNode
+	Name Expression
+}
+
+func (e *DynamicVariable) exprNode() {}
+
+func (dv *DynamicVariable) TokenLiteral() string {
+	return dv.Token.Literal
+}
+
+func (dv *DynamicVariable) String() string {
+	return "${" + dv.Name.String() + "}"
+}
+
+func (dv *DynamicVariable) Kind() Kind {
+	return KindVar
+}
+
+// IndexExpression represents `$a[index]`, Index is nil for `$a[]`
+type IndexExpression struct {
+	*BaseNode
+	Left  Expression
+	Index Expression
+}
+
+func (e *IndexExpression) exprNode() {}
+
+func (ie *IndexExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IndexExpression) String() string {
+	if ie.Index == nil {
+		return ie.Left.String() + "[]"
+	}
+	return ie.Left.String() + "[" + ie.Index.String() + "]"
+}
+
+func (ie *IndexExpression) Kind() Kind {
+	return KindDim
+}
+
+// PropertyFetchExpression represents `$a->b` and the nullsafe `$a?->b`,
+// Property is an *Identifier for plain names and any expression for the
+// dynamic `$a->$b` or `$a->{expr}`.
+type PropertyFetchExpression struct {
+	*BaseNode
+	Object   Expression
+	Property Expression
+	Nullsafe bool
+}
+
+func (e *PropertyFetchExpression) exprNode() {}
+
+func (pf *PropertyFetchExpression) TokenLiteral() string {
+	return pf.Token.Literal
+}
+
+func (pf *PropertyFetchExpression) String() string {
+	return pf.Object.String() + objectOperator(pf.Nullsafe) + memberName(pf.Property)
+}
+
+func (pf *PropertyFetchExpression) Kind() Kind {
+	if pf.Nullsafe {
+		return KindNullsafeProp
+	}
+	return KindProp
+}
+
+// StaticPropertyFetchExpression represents `A::$b`, Property is a
+// *Variable or, for `A::$$b` and `A::${expr}`, a *DynamicVariable.
+type StaticPropertyFetchExpression struct {
+	*BaseNode
+	Class    Expression
+	Property Expression
+}
+
+func (e *StaticPropertyFetchExpression) exprNode() {}
+
+func (sp *StaticPropertyFetchExpression) TokenLiteral() string {
+	return sp.Token.Literal
+}
+
+func (sp *StaticPropertyFetchExpression) String() string {
+	return sp.Class.String() + "::" + sp.Property.String()
+}
+
+func (sp *StaticPropertyFetchExpression) Kind() Kind {
+	return KindStaticProp
+}
+
+// ClassConstFetchExpression represents `A::B` and `A::class`
+type ClassConstFetchExpression struct {
+	*BaseNode
+	Class Expression
+	Name  string
+}
+
+func (e *ClassConstFetchExpression) exprNode() {}
+
+func (cc *ClassConstFetchExpression) TokenLiteral() string {
+	return cc.Token.Literal
+}
+
+func (cc *ClassConstFetchExpression) String() string {
+	return cc.Class.String() + "::" + cc.Name
+}
+
+func (cc *ClassConstFetchExpression) Kind() Kind {
+	if strings.EqualFold(cc.Name, "class") {
+		return KindClassName
+	}
+	return KindClassConst
+}
+
+// CallExpression represents `foo(args)`, Function is a *Constant for
+// named functions and any expression for `$f()` or `(expr)()`.
+type CallExpression struct {
+	*BaseNode
+	Function  Expression
+	Arguments []*Argument
+	// FirstClassCallable is set for the `foo(...)` syntax creating a Closure
+	FirstClassCallable bool
+}
+
+func (e *CallExpression) exprNode() {}
+
+func (ce *CallExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+
+func (ce *CallExpression) String() string {
+	return ce.Function.String() + writeCallArguments(ce.Arguments, ce.FirstClassCallable)
+}
+
+func (ce *CallExpression) Kind() Kind {
+	return KindCall
+}
+
+// MethodCallExpression represents `$a->b(args)` and `$a?->b(args)`,
+// Method is an *Identifier for plain names.
+type MethodCallExpression struct {
+	*BaseNode
+	Object             Expression
+	Method             Expression
+	Arguments          []*Argument
+	Nullsafe           bool
+	FirstClassCallable bool
+}
+
+func (e *MethodCallExpression) exprNode() {}
+
+func (mc *MethodCallExpression) TokenLiteral() string {
+	return mc.Token.Literal
+}
+
+func (mc *MethodCallExpression) String() string {
+	return mc.Object.String() + objectOperator(mc.Nullsafe) + memberName(mc.Method) + writeCallArguments(mc.Arguments, mc.FirstClassCallable)
+}
+
+func (mc *MethodCallExpression) Kind() Kind {
+	if mc.Nullsafe {
+		return KindNullsafeMethodCall
+	}
+	return KindMethodCall
+}
+
+// StaticCallExpression represents `A::b(args)`, Method is an *Identifier
+// for plain names, a *Variable for `A::$b()` or any expression for
+// `A::{expr}()`.
+type StaticCallExpression struct {
+	*BaseNode
+	Class              Expression
+	Method             Expression
+	Arguments          []*Argument
+	FirstClassCallable bool
+}
+
+func (e *StaticCallExpression) exprNode() {}
+
+func (sc *StaticCallExpression) TokenLiteral() string {
+	return sc.Token.Literal
+}
+
+func (sc *StaticCallExpression) String() string {
+	return sc.Class.String() + "::" + memberName(sc.Method) + writeCallArguments(sc.Arguments, sc.FirstClassCallable)
+}
+
+func (sc *StaticCallExpression) Kind() Kind {
+	return KindStaticCall
+}
+
+// Argument is an argument of a call, `value`, `name: value` or `...value`
+type Argument struct {
+	*BaseNode
+	Name   string
+	Value  Expression
+	Unpack bool
+}
+
+func (a *Argument) TokenLiteral() string {
+	return a.Token.Literal
+}
+
+func (a *Argument) String() string {
+	switch {
+	case a.Unpack:
+		return "..." + a.Value.String()
+	case a.Name != "":
+		return a.Name + ": " + a.Value.String()
+	}
+	return a.Value.String()
+}
+
+func (a *Argument) Kind() Kind {
+	switch {
+	case a.Unpack:
+		return KindUnpack
+	case a.Name != "":
+		return KindNamedArg
+	}
+	return KindArg
+}
+
+// NewExpression represents `new Class(args)`, Class is a *Constant for
+// names and any expression for `new $class`. Anonymous classes have a nil
+// Class and their declaration in AnonymousClass.
+type NewExpression struct {
+	*BaseNode
+	Class          Expression
+	AnonymousClass *ClassStatement
+	Arguments      []*Argument
+}
+
+func (e *NewExpression) exprNode() {}
+
+func (ne *NewExpression) TokenLiteral() string {
+	return ne.Token.Literal
+}
+
+func (ne *NewExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("new ")
+	if ne.AnonymousClass != nil {
+		writeAttributes(&out, ne.AnonymousClass.Attributes)
+		out.WriteString("class")
+		out.WriteString(writeArguments(ne.Arguments))
+		writeClassRest(&out, ne.AnonymousClass)
+		return out.String()
+	}
+	out.WriteString(ne.Class.String())
+	out.WriteString(writeArguments(ne.Arguments))
+	return out.String()
+}
+
+func (ne *NewExpression) Kind() Kind {
+	return KindNew
+}
+
+// CloneExpression represents `clone expr`
+type CloneExpression struct {
+	*BaseNode
+	Expression Expression
+}
+
+func (e *CloneExpression) exprNode() {}
+
+func (ce *CloneExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+
+func (ce *CloneExpression) String() string {
+	return "clone " + ce.Expression.String()
+}
+
+func (ce *CloneExpression) Kind() Kind {
+	return KindClone
+}
+
+// writeCallArguments writes the arguments of a call, or `(...)` for the
+// first-class callable syntax.
+func writeCallArguments(args []*Argument, firstClassCallable bool) string {
+	if firstClassCallable {
+		return "(...)"
+	}
+	return writeArguments(args)
+}
+
+func writeArguments(args []*Argument) string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	for i, arg := range args {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(arg.String())
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+func objectOperator(nullsafe bool) string {
+	if nullsafe {
+		return "?->"
+	}
+	return "->"
+}
+
+// memberName writes a member name, wrapping the dynamic ones in braces
+func memberName(name Expression) string {
+	switch name.(type) {
+	case *Identifier, *Variable:
+		return name.String()
+	}
+	return "{" + name.String() + "}"
+}
+
+// PrefixExpression represents unary operators such as `!$a`, `-$a` or `++$a`
+type PrefixExpression struct {
+	*BaseNode
+	Operator string
+	Right    Expression
+}
+
+func (e *PrefixExpression) exprNode() {}
+
+func (pe *PrefixExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+
+func (pe *PrefixExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(pe.Operator)
+	out.WriteString(pe.Right.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+func (pe *PrefixExpression) Kind() Kind {
+	switch pe.Operator {
+	case "-":
+		return KindUnaryMinus
+	case "+":
+		return KindUnaryPlus
+	case "@":
+		return KindSilence
+	case "++":
+		return KindPreInc
+	case "--":
+		return KindPreDec
+	}
+	return KindUnaryOp
+}
+
+// ThrowExpression represents PHP 8's `throw expr` used as an expression,
+// like `$a ?? throw new E()`.
+type ThrowExpression struct {
+	*BaseNode
+	Expression Expression
+}
+
+func (e *ThrowExpression) exprNode() {}
+
+func (te *ThrowExpression) TokenLiteral() string {
+	return te.Token.Literal
+}
+
+func (te *ThrowExpression) String() string {
+	return "(throw " + te.Expression.String() + ")"
+}
+
+func (te *ThrowExpression) Kind() Kind {
+	return KindThrow
+}
+
+// PostfixExpression represents `$a++` and `$a--`
+type PostfixExpression struct {
+	*BaseNode
+	Left     Expression
+	Operator string
+}
+
+func (e *PostfixExpression) exprNode() {}
+
+func (pe *PostfixExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+
+func (pe *PostfixExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(pe.Operator)
+	out.WriteString(")")
+	return out.String()
+}
+
+func (pe *PostfixExpression) Kind() Kind {
+	if pe.Operator == "--" {
+		return KindPostDec
+	}
+	return KindPostInc
+}
+
+// InfixExpression represents binary operators
+type InfixExpression struct {
+	*BaseNode
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+func (e *InfixExpression) exprNode() {}
+
+func (ie *InfixExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *InfixExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString(" ")
+	out.WriteString(ie.Operator)
+	out.WriteString(" ")
+	out.WriteString(ie.Right.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+func (ie *InfixExpression) Kind() Kind {
+	switch strings.ToLower(ie.Operator) {
+	case ">":
+		return KindGreater
+	case ">=":
+		return KindGreaterEqual
+	case "&&", "and":
+		return KindAnd
+	case "||", "or":
+		return KindOr
+	case "??":
+		return KindCoalesce
+	case "instanceof":
+		return KindInstanceof
+	}
+	return KindBinaryOp
+}
+
+// TernaryExpression represents `a ? b : c`, Consequence is nil for `a ?: c`
+type TernaryExpression struct {
+	*BaseNode
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (e *TernaryExpression) exprNode() {}
+
+func (te *TernaryExpression) TokenLiteral() string {
+	return te.Token.Literal
+}
+
+func (te *TernaryExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(te.Condition.String())
+	if te.Consequence != nil {
+		out.WriteString(" ? ")
+		out.WriteString(te.Consequence.String())
+		out.WriteString(" : ")
+	} else {
+		out.WriteString(" ?: ")
+	}
+	out.WriteString(te.Alternative.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+func (te *TernaryExpression) Kind() Kind {
+	return KindConditional
+}
+
+// CastExpression represents `(int) $a`, Type is the normalized type name
+type CastExpression struct {
+	*BaseNode
+	Type       string
+	Expression Expression
+}
+
+func (e *CastExpression) exprNode() {}
+
+func (ce *CastExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+
+func (ce *CastExpression) String() string {
+	return "(" + ce.Type + ")" + ce.Expression.String()
+}
+
+func (ce *CastExpression) Kind() Kind {
+	return KindCast
+}
+
+// ListExpression represents `list(...)` or `[...]` on the left side of a
+// destructuring, a nil item stands for a skipped slot.
+type ListExpression struct {
+	*BaseNode
+	Items   []*ArrayItem
+	IsShort bool
+}
+
+func (e *ListExpression) exprNode() {}
+
+func (le *ListExpression) TokenLiteral() string {
+	return le.Token.Literal
+}
+
+func (le *ListExpression) String() string {
+	return writeArrayItems(le.Items, le.IsShort, "list(")
+}
+
+func (le *ListExpression) Kind() Kind {
+	return KindArray
+}
+
+// AssignExpression represents `left = right`
+type AssignExpression struct {
+	*BaseNode
+	Left  Expression
+	Right Expression
+}
+
+func (e *AssignExpression) exprNode() {}
+
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+func (ae *AssignExpression) String() string {
+	return "(" + ae.Left.String() + " = " + ae.Right.String() + ")"
+}
+
+func (ae *AssignExpression) Kind() Kind {
+	return KindAssign
+}
+
+// AssignRefExpression represents `left = &right`
+type AssignRefExpression struct {
+	*BaseNode
+	Left  Expression
+	Right Expression
+}
+
+func (e *AssignRefExpression) exprNode() {}
+
+func (ar *AssignRefExpression) TokenLiteral() string {
+	return ar.Token.Literal
+}
+
+func (ar *AssignRefExpression) String() string {
+	return "(" + ar.Left.String() + " = &" + ar.Right.String() + ")"
+}
+
+func (ar *AssignRefExpression) Kind() Kind {
+	return KindAssignRef
+}
+
+// CompoundAssignExpression represents `left op= right`, Operator is the
+// binary operator without the `=`, like `+` for `+=` or `??` for `??=`.
+type CompoundAssignExpression struct {
+	*BaseNode
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+func (e *CompoundAssignExpression) exprNode() {}
+
+func (ca *CompoundAssignExpression) TokenLiteral() string {
+	return ca.Token.Literal
+}
+
+func (ca *CompoundAssignExpression) String() string {
+	return "(" + ca.Left.String() + " " + ca.Operator + "= " + ca.Right.String() + ")"
+}
+
+func (ca *CompoundAssignExpression) Kind() Kind {
+	if ca.Operator == "??" {
+		return KindAssignCoalesce
+	}
+	return KindAssignOp
+}
+
+// InterpolatedStringExpression represents a double quoted string or a
+// heredoc with embedded variables. Parts holds, in order, *StringLiteral
+// for the literal text and the embedded expressions.
+type InterpolatedStringExpression struct {
+	*BaseNode
+	Parts []Expression
+}
+
+func (e *InterpolatedStringExpression) exprNode() {}
+
+func (is *InterpolatedStringExpression) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *InterpolatedStringExpression) String() string {
+	return "\"" + writeParts(is.Parts) + "\""
+}
+
+func (is *InterpolatedStringExpression) Kind() Kind {
+	return KindEncapsList
+}
+
+// ShellExecExpression represents a backtick string like `ls $dir`
+type ShellExecExpression struct {
+	*BaseNode
+	Parts []Expression
+}
+
+func (e *ShellExecExpression) exprNode() {}
+
+func (se *ShellExecExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *ShellExecExpression) String() string {
+	return "`" + writeParts(se.Parts) + "`"
+}
+
+func (se *ShellExecExpression) Kind() Kind {
+	return KindShellExec
+}
+
+// writeParts writes the literal parts of an interpolated string as they
+// appear in the source and wraps the embedded expressions in braces.
+func writeParts(parts []Expression) string {
+	var out bytes.Buffer
+	for _, part := range parts {
+		if lit, ok := part.(*StringLiteral); ok {
+			out.WriteString(lit.Token.Literal)
+			continue
+		}
+		out.WriteString("{")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	return out.String()
+}
+
+// IncludeKind tells which of include, require and eval an
+// IncludeOrEvalExpression is
+type IncludeKind int
+
+const (
+	Include IncludeKind = iota
+	IncludeOnce
+	Require
+	RequireOnce
+	Eval
+)
+
+var includeKindNames = [...]string{"include", "include_once", "require", "require_once", "eval"}
+
+func (k IncludeKind) String() string {
+	return includeKindNames[k]
+}
+
+// IncludeOrEvalExpression represents `include expr`, `include_once expr`,
+// `require expr`, `require_once expr` and `eval(expr)`
+type IncludeOrEvalExpression struct {
+	*BaseNode
+	Type       IncludeKind
+	Expression Expression
+}
+
+func (e *IncludeOrEvalExpression) exprNode() {}
+
+func (ie *IncludeOrEvalExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IncludeOrEvalExpression) String() string {
+	if ie.Type == Eval {
+		return "eval(" + ie.Expression.String() + ")"
+	}
+	return "(" + ie.Type.String() + " " + ie.Expression.String() + ")"
+}
+
+func (ie *IncludeOrEvalExpression) Kind() Kind {
+	return KindIncludeOrEval
+}
+
+// PrintExpression represents `print expr`, its value is always 1
+type PrintExpression struct {
+	*BaseNode
+	Expression Expression
+}
+
+func (e *PrintExpression) exprNode() {}
+
+func (pe *PrintExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+
+func (pe *PrintExpression) String() string {
+	return "(print " + pe.Expression.String() + ")"
+}
+
+func (pe *PrintExpression) Kind() Kind {
+	return KindPrint
+}
+
+// YieldExpression represents `yield`, `yield value` and `yield key =>
+// value` in a generator, Key and Value are nil when left out
+type YieldExpression struct {
+	*BaseNode
+	Key   Expression
+	Value Expression
+}
+
+func (e *YieldExpression) exprNode() {}
+
+func (ye *YieldExpression) TokenLiteral() string {
+	return ye.Token.Literal
+}
+
+func (ye *YieldExpression) String() string {
+	switch {
+	case ye.Value == nil:
+		return "(yield)"
+	case ye.Key == nil:
+		return "(yield " + ye.Value.String() + ")"
+	}
+	return "(yield " + ye.Key.String() + " => " + ye.Value.String() + ")"
+}
+
+func (ye *YieldExpression) Kind() Kind {
+	return KindYield
+}
+
+// YieldFromExpression represents `yield from expr`, delegating to another
+// generator or to an array or Traversable
+type YieldFromExpression struct {
+	*BaseNode
+	Expression Expression
+}
+
+func (e *YieldFromExpression) exprNode() {}
+
+func (yf *YieldFromExpression) TokenLiteral() string {
+	return yf.Token.Literal
+}
+
+func (yf *YieldFromExpression) String() string {
+	return "(yield from " + yf.Expression.String() + ")"
+}
+
+func (yf *YieldFromExpression) Kind() Kind {
+	return KindYieldFrom
+}
+
+// IssetExpression represents `isset($a, $b, ...)`
+type IssetExpression struct {
+	*BaseNode
+	Variables []Expression
+}
+
+func (e *IssetExpression) exprNode() {}
+
+func (ie *IssetExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IssetExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("isset(")
+	writeExpressions(&out, ie.Variables)
+	out.WriteString(")")
+	return out.String()
+}
+
+func (ie *IssetExpression) Kind() Kind {
+	return KindIsset
+}
+
+// EmptyExpression represents `empty(expr)`
+type EmptyExpression struct {
+	*BaseNode
+	Expression Expression
+}
+
+func (e *EmptyExpression) exprNode() {}
+
+func (ee *EmptyExpression) TokenLiteral() string {
+	return ee.Token.Literal
+}
+
+func (ee *EmptyExpression) String() string {
+	return "empty(" + ee.Expression.String() + ")"
+}
+
+func (ee *EmptyExpression) Kind() Kind {
+	return KindEmpty
+}
+
+// ExitExpression represents `exit`, `exit(expr)` and their `die` alias,
+// Expression is nil when there is no status.
+type ExitExpression struct {
+	*BaseNode
+	Expression Expression
+}
+
+func (e *ExitExpression) exprNode() {}
+
+func (ee *ExitExpression) TokenLiteral() string {
+	return ee.Token.Literal
+}
+
+func (ee *ExitExpression) String() string {
+	if ee.Expression == nil {
+		return "exit"
+	}
+	return "exit(" + ee.Expression.String() + ")"
+}
+
+func (ee *ExitExpression) Kind() Kind {
+	return KindExit
+}
+
+// MatchExpression represents `match (subject) { arms }`
+type MatchExpression struct {
+	*BaseNode
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (e *MatchExpression) exprNode() {}
+
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") {")
+	for i, arm := range me.Arms {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(" ")
+		out.WriteString(arm.String())
+	}
+	out.WriteString(" }")
+	return out.String()
+}
+
+func (me *MatchExpression) Kind() Kind {
+	return KindMatch
+}
+
+// MatchArm is a `cond, ... => expr` arm of a match, Conditions is nil for
+// the default arm.
+type MatchArm struct {
+	*BaseNode
+	Conditions []Expression
+	Body       Expression
+}
+
+func (ma *MatchArm) TokenLiteral() string {
+	return ma.Token.Literal
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+	if ma.Conditions == nil {
+		out.WriteString("default")
+	} else {
+		writeExpressions(&out, ma.Conditions)
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+	return out.String()
+}
+
+func (ma *MatchArm) Kind() Kind {
+	return KindMatchArm
+}
+
+// IsDefault reports whether the arm is the default arm
+func (ma *MatchArm) IsDefault() bool {
+	return ma.Conditions == nil
+}
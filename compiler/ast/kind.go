@@ -0,0 +1,405 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind classifies the nodes like zend_ast_kind of PHP 8.1, the kinds
+// have the same values so that tools written against php-ast can be
+// ported. The bits of a kind tell its shape: special nodes (values and
+// declarations), list nodes with any number of children, and the other
+// nodes with the fixed number of children given by the high bits.
+type Kind uint16
+
+const (
+	SpecialShift     = 6
+	IsListShift      = 7
+	NumChildrenShift = 8
+)
+
+// special nodes
+const (
+	KindZval Kind = 1<<SpecialShift + iota
+	KindConstant
+	KindZnode
+
+	// declaration nodes
+	KindFuncDecl
+	KindClosure
+	KindMethod
+	KindClass
+	KindArrowFunc
+)
+
+// list nodes
+const (
+	KindArgList Kind = 1<<IsListShift + iota
+	KindArray
+	KindEncapsList
+	KindExprList
+	KindStmtList
+	KindIf
+	KindSwitchList
+	KindCatchList
+	KindParamList
+	KindClosureUses
+	KindPropDecl
+	KindConstDecl
+	KindClassConstDecl
+	KindNameList
+	KindTraitAdaptations
+	KindUse
+	KindTypeUnion
+	KindTypeIntersection
+	KindAttributeList
+	KindAttributeGroup
+	KindMatchArmList
+)
+
+// 0 child nodes
+const (
+	KindMagicConst Kind = 0<<NumChildrenShift + iota
+	KindType
+	KindConstantClass
+	KindCallableConvert
+)
+
+// 1 child node
+const (
+	KindVar Kind = 1<<NumChildrenShift + iota
+	KindConst
+	KindUnpack
+	KindUnaryPlus
+	KindUnaryMinus
+	KindCast
+	KindEmpty
+	KindIsset
+	KindSilence
+	KindShellExec
+	KindClone
+	KindExit
+	KindPrint
+	KindIncludeOrEval
+	KindUnaryOp
+	KindPreInc
+	KindPreDec
+	KindPostInc
+	KindPostDec
+	KindYieldFrom
+	KindClassName
+
+	KindGlobal
+	KindUnset
+	KindReturn
+	KindLabel
+	KindRef
+	KindHaltCompiler
+	KindEcho
+	KindThrow
+	KindGoto
+	KindBreak
+	KindContinue
+)
+
+// 2 child nodes
+const (
+	KindDim Kind = 2<<NumChildrenShift + iota
+	KindProp
+	KindNullsafeProp
+	KindStaticProp
+	KindCall
+	KindClassConst
+	KindAssign
+	KindAssignRef
+	KindAssignOp
+	KindBinaryOp
+	KindGreater
+	KindGreaterEqual
+	KindAnd
+	KindOr
+	KindArrayElem
+	KindNew
+	KindInstanceof
+	KindYield
+	KindCoalesce
+	KindAssignCoalesce
+
+	KindStatic
+	KindWhile
+	KindDoWhile
+	KindIfElem
+	KindSwitch
+	KindSwitchCase
+	KindDeclare
+	KindUseTrait
+	KindTraitPrecedence
+	KindMethodReference
+	KindNamespace
+	KindUseElem
+	KindTraitAlias
+	KindGroupUse
+	KindClassConstGroup
+	KindAttribute
+	KindMatch
+	KindMatchArm
+	KindNamedArg
+)
+
+// 3 child nodes
+const (
+	KindMethodCall Kind = 3<<NumChildrenShift + iota
+	KindNullsafeMethodCall
+	KindStaticCall
+	KindConditional
+
+	KindTry
+	KindCatch
+	KindPropGroup
+	KindPropElem
+	KindConstElem
+	KindConstEnumInit
+)
+
+// 4 child nodes
+const (
+	KindFor Kind = 4<<NumChildrenShift + iota
+	KindForeach
+	KindEnumCase
+)
+
+// 5 child nodes
+const (
+	KindParam Kind = 5 << NumChildrenShift
+)
+
+// The pseudo kinds of php-ast, for the names it does not keep as plain
+// values, followed by the kinds of the nodes luban has and zend has not.
+// They are neither special nor lists.
+const (
+	KindName Kind = 1<<11 + iota
+	KindClosureVar
+	KindNullableType
+
+	// KindExprStmt is an expression used as a statement
+	KindExprStmt
+	// KindArg is a positional argument of a call
+	KindArg
+	// KindBad is a statement that failed to parse
+	KindBad
+)
+
+// pseudoChildren is the number of children of the pseudo kinds
+var pseudoChildren = map[Kind]int{
+	KindName:         1,
+	KindClosureVar:   1,
+	KindNullableType: 1,
+	KindExprStmt:     1,
+	KindArg:          1,
+	KindBad:          0,
+}
+
+var kindNames = map[Kind]string{
+	KindZval:               "AST_ZVAL",
+	KindConstant:           "AST_CONSTANT",
+	KindZnode:              "AST_ZNODE",
+	KindFuncDecl:           "AST_FUNC_DECL",
+	KindClosure:            "AST_CLOSURE",
+	KindMethod:             "AST_METHOD",
+	KindClass:              "AST_CLASS",
+	KindArrowFunc:          "AST_ARROW_FUNC",
+	KindArgList:            "AST_ARG_LIST",
+	KindArray:              "AST_ARRAY",
+	KindEncapsList:         "AST_ENCAPS_LIST",
+	KindExprList:           "AST_EXPR_LIST",
+	KindStmtList:           "AST_STMT_LIST",
+	KindIf:                 "AST_IF",
+	KindSwitchList:         "AST_SWITCH_LIST",
+	KindCatchList:          "AST_CATCH_LIST",
+	KindParamList:          "AST_PARAM_LIST",
+	KindClosureUses:        "AST_CLOSURE_USES",
+	KindPropDecl:           "AST_PROP_DECL",
+	KindConstDecl:          "AST_CONST_DECL",
+	KindClassConstDecl:     "AST_CLASS_CONST_DECL",
+	KindNameList:           "AST_NAME_LIST",
+	KindTraitAdaptations:   "AST_TRAIT_ADAPTATIONS",
+	KindUse:                "AST_USE",
+	KindTypeUnion:          "AST_TYPE_UNION",
+	KindTypeIntersection:   "AST_TYPE_INTERSECTION",
+	KindAttributeList:      "AST_ATTRIBUTE_LIST",
+	KindAttributeGroup:     "AST_ATTRIBUTE_GROUP",
+	KindMatchArmList:       "AST_MATCH_ARM_LIST",
+	KindMagicConst:         "AST_MAGIC_CONST",
+	KindType:               "AST_TYPE",
+	KindConstantClass:      "AST_CONSTANT_CLASS",
+	KindCallableConvert:    "AST_CALLABLE_CONVERT",
+	KindVar:                "AST_VAR",
+	KindConst:              "AST_CONST",
+	KindUnpack:             "AST_UNPACK",
+	KindUnaryPlus:          "AST_UNARY_PLUS",
+	KindUnaryMinus:         "AST_UNARY_MINUS",
+	KindCast:               "AST_CAST",
+	KindEmpty:              "AST_EMPTY",
+	KindIsset:              "AST_ISSET",
+	KindSilence:            "AST_SILENCE",
+	KindShellExec:          "AST_SHELL_EXEC",
+	KindClone:              "AST_CLONE",
+	KindExit:               "AST_EXIT",
+	KindPrint:              "AST_PRINT",
+	KindIncludeOrEval:      "AST_INCLUDE_OR_EVAL",
+	KindUnaryOp:            "AST_UNARY_OP",
+	KindPreInc:             "AST_PRE_INC",
+	KindPreDec:             "AST_PRE_DEC",
+	KindPostInc:            "AST_POST_INC",
+	KindPostDec:            "AST_POST_DEC",
+	KindYieldFrom:          "AST_YIELD_FROM",
+	KindClassName:          "AST_CLASS_NAME",
+	KindGlobal:             "AST_GLOBAL",
+	KindUnset:              "AST_UNSET",
+	KindReturn:             "AST_RETURN",
+	KindLabel:              "AST_LABEL",
+	KindRef:                "AST_REF",
+	KindHaltCompiler:       "AST_HALT_COMPILER",
+	KindEcho:               "AST_ECHO",
+	KindThrow:              "AST_THROW",
+	KindGoto:               "AST_GOTO",
+	KindBreak:              "AST_BREAK",
+	KindContinue:           "AST_CONTINUE",
+	KindDim:                "AST_DIM",
+	KindProp:               "AST_PROP",
+	KindNullsafeProp:       "AST_NULLSAFE_PROP",
+	KindStaticProp:         "AST_STATIC_PROP",
+	KindCall:               "AST_CALL",
+	KindClassConst:         "AST_CLASS_CONST",
+	KindAssign:             "AST_ASSIGN",
+	KindAssignRef:          "AST_ASSIGN_REF",
+	KindAssignOp:           "AST_ASSIGN_OP",
+	KindBinaryOp:           "AST_BINARY_OP",
+	KindGreater:            "AST_GREATER",
+	KindGreaterEqual:       "AST_GREATER_EQUAL",
+	KindAnd:                "AST_AND",
+	KindOr:                 "AST_OR",
+	KindArrayElem:          "AST_ARRAY_ELEM",
+	KindNew:                "AST_NEW",
+	KindInstanceof:         "AST_INSTANCEOF",
+	KindYield:              "AST_YIELD",
+	KindCoalesce:           "AST_COALESCE",
+	KindAssignCoalesce:     "AST_ASSIGN_COALESCE",
+	KindStatic:             "AST_STATIC",
+	KindWhile:              "AST_WHILE",
+	KindDoWhile:            "AST_DO_WHILE",
+	KindIfElem:             "AST_IF_ELEM",
+	KindSwitch:             "AST_SWITCH",
+	KindSwitchCase:         "AST_SWITCH_CASE",
+	KindDeclare:            "AST_DECLARE",
+	KindUseTrait:           "AST_USE_TRAIT",
+	KindTraitPrecedence:    "AST_TRAIT_PRECEDENCE",
+	KindMethodReference:    "AST_METHOD_REFERENCE",
+	KindNamespace:          "AST_NAMESPACE",
+	KindUseElem:            "AST_USE_ELEM",
+	KindTraitAlias:         "AST_TRAIT_ALIAS",
+	KindGroupUse:           "AST_GROUP_USE",
+	KindClassConstGroup:    "AST_CLASS_CONST_GROUP",
+	KindAttribute:          "AST_ATTRIBUTE",
+	KindMatch:              "AST_MATCH",
+	KindMatchArm:           "AST_MATCH_ARM",
+	KindNamedArg:           "AST_NAMED_ARG",
+	KindMethodCall:         "AST_METHOD_CALL",
+	KindNullsafeMethodCall: "AST_NULLSAFE_METHOD_CALL",
+	KindStaticCall:         "AST_STATIC_CALL",
+	KindConditional:        "AST_CONDITIONAL",
+	KindTry:                "AST_TRY",
+	KindCatch:              "AST_CATCH",
+	KindPropGroup:          "AST_PROP_GROUP",
+	KindPropElem:           "AST_PROP_ELEM",
+	KindConstElem:          "AST_CONST_ELEM",
+	KindConstEnumInit:      "AST_CONST_ENUM_INIT",
+	KindFor:                "AST_FOR",
+	KindForeach:            "AST_FOREACH",
+	KindEnumCase:           "AST_ENUM_CASE",
+	KindParam:              "AST_PARAM",
+	KindName:               "AST_NAME",
+	KindClosureVar:         "AST_CLOSURE_VAR",
+	KindNullableType:       "AST_NULLABLE_TYPE",
+	KindExprStmt:           "AST_EXPR_STMT",
+	KindArg:                "AST_ARG",
+	KindBad:                "AST_BAD",
+}
+
+// String returns the name php-ast gives to the kind, e.g. "AST_STMT_LIST"
+func (k Kind) String() string {
+	if n, ok := kindNames[k]; ok {
+		return n
+	}
+	return fmt.Sprintf("Kind(%d)", uint16(k))
+}
+
+// IsSpecial reports whether the kind is a value or a declaration
+func (k Kind) IsSpecial() bool {
+	return !k.isPseudo() && (k>>SpecialShift)&1 == 1
+}
+
+// IsList reports whether the nodes of the kind have any number of
+// children
+func (k Kind) IsList() bool {
+	return !k.isPseudo() && (k>>IsListShift)&1 == 1
+}
+
+// NumChildren returns the number of children zend gives to the nodes
+// of a kind that is neither special nor a list. The count includes the
+// children luban keeps as plain values, e.g. the name of a variable,
+// and the lists zend nests are inlined, e.g. the cases of a switch, so
+// Children may return fewer or more nodes than that.
+func (k Kind) NumChildren() int {
+	if k.isPseudo() {
+		return pseudoChildren[k]
+	}
+	if k.IsSpecial() || k.IsList() {
+		return 0
+	}
+	return int(k >> NumChildrenShift)
+}
+
+func (k Kind) isPseudo() bool {
+	return k >= KindName
+}
+
+// Children returns the children of n in source order, as Walk visits
+// them: the elements of list nodes or the child nodes of the others,
+// leaving out those that are missing.
+func Children(n Node) []Node {
+	var children []Node
+	Inspect(n, func(c Node) bool {
+		if c == n {
+			return true
+		}
+		if c != nil {
+			children = append(children, c)
+		}
+		return false
+	})
+	return children
+}
+
+// magicConstants are the names parsed as constants that zend compiles
+// to AST_MAGIC_CONST
+var magicConstants = map[string]bool{
+	"__LINE__": true, "__FILE__": true, "__DIR__": true, "__FUNCTION__": true,
+	"__CLASS__": true, "__TRAIT__": true, "__METHOD__": true, "__NAMESPACE__": true,
+}
+
+// builtinTypes are the types php-ast reports as AST_TYPE, the others
+// are class names
+var builtinTypes = map[string]bool{
+	"array": true, "callable": true, "bool": true, "int": true, "float": true,
+	"string": true, "iterable": true, "object": true, "mixed": true, "void": true,
+	"static": true, "never": true, "null": true, "false": true,
+}
+
+func isMagicConstant(name string) bool {
+	return magicConstants[strings.ToUpper(name)]
+}
+
+func isBuiltinType(name string) bool {
+	return builtinTypes[strings.ToLower(name)]
+}
@@ -0,0 +1,119 @@
+package ast_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/parser"
+)
+
+func Test_KindValues(t *testing.T) {
+	// the values of zend_ast_kind and of the php-ast pseudo kinds
+	tests := []struct {
+		kind     ast.Kind
+		value    int
+		name     string
+		special  bool
+		list     bool
+		children int
+	}{
+		{ast.KindZval, 64, "AST_ZVAL", true, false, 0},
+		{ast.KindClass, 70, "AST_CLASS", true, false, 0},
+		{ast.KindArrowFunc, 71, "AST_ARROW_FUNC", true, false, 0},
+		{ast.KindArgList, 128, "AST_ARG_LIST", false, true, 0},
+		{ast.KindStmtList, 132, "AST_STMT_LIST", false, true, 0},
+		{ast.KindMatchArmList, 148, "AST_MATCH_ARM_LIST", false, true, 0},
+		{ast.KindMagicConst, 0, "AST_MAGIC_CONST", false, false, 0},
+		{ast.KindCallableConvert, 3, "AST_CALLABLE_CONVERT", false, false, 0},
+		{ast.KindVar, 256, "AST_VAR", false, false, 1},
+		{ast.KindContinue, 287, "AST_CONTINUE", false, false, 1},
+		{ast.KindDim, 512, "AST_DIM", false, false, 2},
+		{ast.KindNamedArg, 550, "AST_NAMED_ARG", false, false, 2},
+		{ast.KindMethodCall, 768, "AST_METHOD_CALL", false, false, 3},
+		{ast.KindConstEnumInit, 777, "AST_CONST_ENUM_INIT", false, false, 3},
+		{ast.KindEnumCase, 1026, "AST_ENUM_CASE", false, false, 4},
+		{ast.KindParam, 1280, "AST_PARAM", false, false, 5},
+		{ast.KindName, 2048, "AST_NAME", false, false, 1},
+		{ast.KindNullableType, 2050, "AST_NULLABLE_TYPE", false, false, 1},
+		{ast.KindBad, 2053, "AST_BAD", false, false, 0},
+		{ast.Kind(5000), 5000, "Kind(5000)", false, false, 0},
+	}
+	for i, tt := range tests {
+		k := tt.kind
+		if int(k) != tt.value || k.String() != tt.name || k.IsSpecial() != tt.special ||
+			k.IsList() != tt.list || k.NumChildren() != tt.children {
+			t.Errorf("tests[%d] - expected %d %s special=%t list=%t children=%d, got %d %s special=%t list=%t children=%d",
+				i, tt.value, tt.name, tt.special, tt.list, tt.children,
+				int(k), k, k.IsSpecial(), k.IsList(), k.NumChildren())
+		}
+	}
+}
+
+func Test_KindAllNodes(t *testing.T) {
+	program, _ := parser.ParseFile(nil, "a.php", allNodes, parser.AllErrors)
+	ast.Inspect(program, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if k := n.Kind(); strings.HasPrefix(k.String(), "Kind(") {
+			t.Errorf("%T %q has the unknown kind %s", n, n.String(), k)
+		}
+
+		// Children returns the nodes Walk visits below n
+		var walked []ast.Node
+		ast.Inspect(n, func(c ast.Node) bool {
+			if c == n {
+				return true
+			}
+			if c != nil {
+				walked = append(walked, c)
+			}
+			return false
+		})
+		children := ast.Children(n)
+		if len(children) != len(walked) {
+			t.Errorf("%T: expected %d children, got %d", n, len(walked), len(children))
+			return true
+		}
+		for i := range walked {
+			if children[i] != walked[i] {
+				t.Errorf("%T: child %d is %T, expected %T", n, i, children[i], walked[i])
+			}
+		}
+		return true
+	})
+}
+
+func Test_Kind(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`<?php $a = 1;`, "AST_STMT_LIST AST_EXPR_STMT AST_ASSIGN AST_VAR AST_ZVAL"},
+		{`<?php if ($a) {} else {}`, "AST_STMT_LIST AST_IF AST_IF_ELEM AST_VAR AST_STMT_LIST AST_STMT_LIST"},
+		{`<?php $a?->b(...$c, d: 1);`, "AST_STMT_LIST AST_EXPR_STMT AST_NULLSAFE_METHOD_CALL AST_VAR AST_ZVAL AST_UNPACK AST_VAR AST_NAMED_ARG AST_ZVAL"},
+		{`<?php -$a + +$b > $c && !$d;`, "AST_STMT_LIST AST_EXPR_STMT AST_AND AST_GREATER AST_BINARY_OP AST_UNARY_MINUS AST_VAR AST_UNARY_PLUS AST_VAR AST_VAR AST_UNARY_OP AST_VAR"},
+		{`<?php $a ??= A::class ?? B::C;`, "AST_STMT_LIST AST_EXPR_STMT AST_ASSIGN_COALESCE AST_VAR AST_COALESCE AST_CLASS_NAME AST_CONST AST_CLASS_CONST AST_CONST"},
+		{`<?php function f(?A $a, int|string $b, int $c, B $d) {}`, "AST_STMT_LIST AST_FUNC_DECL AST_PARAM AST_NULLABLE_TYPE AST_PARAM AST_TYPE_UNION AST_PARAM AST_TYPE AST_PARAM AST_NAME AST_STMT_LIST"},
+		{`<?php use A\{B, C}; use D;`, "AST_STMT_LIST AST_GROUP_USE AST_USE_ELEM AST_USE_ELEM AST_USE AST_USE_ELEM"},
+		{`<?php function g() { yield 1; yield from $a; }`, "AST_STMT_LIST AST_FUNC_DECL AST_STMT_LIST AST_EXPR_STMT AST_YIELD AST_ZVAL AST_EXPR_STMT AST_YIELD_FROM AST_VAR"},
+		{`<?php $a++; --$b; @$c;`, "AST_STMT_LIST AST_EXPR_STMT AST_POST_INC AST_VAR AST_EXPR_STMT AST_PRE_DEC AST_VAR AST_EXPR_STMT AST_SILENCE AST_VAR"},
+	}
+	for i, tt := range tests {
+		program, err := parser.ParseFile(nil, "a.php", tt.input, 0)
+		if err != nil {
+			t.Fatalf("tests[%d] - parser error: %s", i, err)
+		}
+		var kinds []string
+		ast.Inspect(program, func(n ast.Node) bool {
+			if n != nil {
+				kinds = append(kinds, n.Kind().String())
+			}
+			return true
+		})
+		if s := strings.Join(kinds, " "); s != tt.expected {
+			t.Errorf("tests[%d] - expected:\n%s\ngot:\n%s", i, tt.expected, s)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package ast_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/parser"
+)
+
+func Test_RangeAllNodes(t *testing.T) {
+	program, _ := parser.ParseFile(nil, "a.php", allNodes, parser.AllErrors)
+	ast.Inspect(program, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		pos, end := n.Pos(), n.End()
+		if !pos.IsValid() || !end.IsValid() || pos.Offset >= end.Offset {
+			t.Errorf("%T %q has the invalid range %s-%s", n, n.String(), pos, end)
+			return true
+		}
+		if pos.Filename != "a.php" || n.Line() != pos.Line {
+			t.Errorf("%T %q: expected a.php:%d, got %s:%d", n, n.String(), pos.Line, pos.Filename, n.Line())
+		}
+
+		// the children are inside their parent, in source order except the
+		// arguments of `new class(args) {}` inside the class that follows them
+		var prev ast.Node
+		for _, c := range ast.Children(n) {
+			if c.Pos().Offset < pos.Offset || c.End().Offset > end.Offset {
+				t.Errorf("%T %q: child %T %q is outside of it", n, ast.Source(allNodes, n), c, ast.Source(allNodes, c))
+			}
+			if prev != nil && c.Pos().Offset < prev.End().Offset && c.End().Offset < prev.End().Offset {
+				t.Errorf("%T %q: child %T %q is before %q", n, ast.Source(allNodes, n), c, ast.Source(allNodes, c), ast.Source(allNodes, prev))
+			}
+			prev = c
+		}
+		return true
+	})
+}
+
+func Test_Source(t *testing.T) {
+	tests := []struct {
+		input    string
+		node     string // the type of the first node to check
+		expected string
+	}{
+		{`<?php $a = 1 + 2 * 3;`, "*ast.ExpressionStatement", "$a = 1 + 2 * 3;"},
+		{`<?php $a = 1 + 2 * 3;`, "*ast.InfixExpression", "1 + 2 * 3"},
+		{`<?php $a = (1 + 2) * 3;`, "*ast.InfixExpression", "(1 + 2) * 3"},
+		{`<?php $a = -(1 + 2);`, "*ast.InfixExpression", "1 + 2"},
+		{`<?php $a->b(1, ...$c)->d;`, "*ast.PropertyFetchExpression", "$a->b(1, ...$c)->d"},
+		{`<?php $a->b(1, ...$c)->d;`, "*ast.Argument", "1"},
+		{`<?php f(name: $a[0]);`, "*ast.Argument", "name: $a[0]"},
+		{`<?php $s = "a $b[1] {$c->d}";`, "*ast.InterpolatedStringExpression", `"a $b[1] {$c->d}"`},
+		{`<?php $s = "a $b[1] {$c->d}";`, "*ast.IndexExpression", "$b[1]"},
+		{`<?php $s = "abc";`, "*ast.StringLiteral", `"abc"`},
+		{`<?php $s = 'abc';`, "*ast.StringLiteral", `'abc'`},
+		{`<?php $$a = ${'b'};`, "*ast.DynamicVariable", "$$a"},
+		{`<?php if ($a) { echo 1; } else { echo 2; }`, "*ast.IfStatement", "if ($a) { echo 1; } else { echo 2; }"},
+		{`<?php if ($a): echo 1; endif;`, "*ast.IfStatement", "if ($a): echo 1; endif;"},
+		{`<?php if ($a): echo 1; endif;`, "*ast.BlockStatement", ": echo 1;"},
+		{`<?php function &f(?int $a = 1, string ...$b): int {}`, "*ast.Parameter", "?int $a = 1"},
+		{`<?php function &f(?int $a = 1, string ...$b): int {}`, "*ast.TypeHint", "?int"},
+		{`<?php #[A(1)] function f() {}`, "*ast.FunctionStatement", "#[A(1)] function f() {}"},
+		{`<?php #[A(1)] function f() {}`, "*ast.Attribute", "A(1)"},
+		{`<?php $f = #[A] static fn() => 1;`, "*ast.ArrowFunctionExpression", "#[A] static fn() => 1"},
+		{`<?php class A { public int $a = 1, $b; }`, "*ast.PropertyItem", "$a = 1"},
+		{`<?php class A { use T { T::f as protected g; } }`, "*ast.TraitAliasStatement", "T::f as protected g;"},
+		{`<?php use A\{B as C, D};`, "*ast.UseItem", "B as C"},
+		{`<?php $m = match ($a) { 1, 2 => 3, default => 4 };`, "*ast.MatchArm", "1, 2 => 3"},
+		{`<?php new class(1) extends B {};`, "*ast.NewExpression", "new class(1) extends B {}"},
+		{`<?php switch ($a) { case 1: echo 1; break; }`, "*ast.CaseStatement", "case 1: echo 1; break;"},
+		{"<?php\n$a;\n\n$b = 1;", "*ast.Program", "$a;\n\n$b = 1;"},
+	}
+	for i, tt := range tests {
+		program, err := parser.ParseFile(nil, "a.php", tt.input, 0)
+		if err != nil {
+			t.Fatalf("tests[%d] - parser error: %s", i, err)
+		}
+		var node ast.Node
+		ast.Inspect(program, func(n ast.Node) bool {
+			if node == nil && n != nil && fmt.Sprintf("%T", n) == tt.node {
+				node = n
+			}
+			return node == nil
+		})
+		if node == nil {
+			t.Errorf("tests[%d] - no %s in %q", i, tt.node, tt.input)
+			continue
+		}
+		if s := ast.Source(tt.input, node); s != tt.expected {
+			t.Errorf("tests[%d] - expected %q, got %q", i, tt.expected, s)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package ast
 
-import "bytes"
+import (
+	"bytes"
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/token"
+)
 
 // ----------------IfStatement----------------
 
@@ -19,30 +24,40 @@ func (ie *IfStatement) String() string {
 	var out bytes.Buffer
 	for i, c := range ie.Conditionals {
 		if i == 0 {
-			out.WriteString("if ( ")
+			out.WriteString("if (")
 		} else {
-			out.WriteString("} elsif ( ")
+			out.WriteString(" elseif (")
 		}
-		out.WriteString(c.String())
+		out.WriteString(c.Condition.String())
 		out.WriteString(") {\n")
+		out.WriteString(c.Consequence.String())
+		out.WriteString("\n}")
 	}
 	if ie.Alternative != nil {
-		out.WriteString("\n")
-		out.WriteString("} else {\n")
+		out.WriteString(" else {\n")
 		out.WriteString(ie.Alternative.String())
+		out.WriteString("\n}")
 	}
-	out.WriteString("\n}")
 	return out.String()
 }
 
+func (ie *IfStatement) Kind() Kind {
+	return KindIf
+}
+
 // ----------------ClassStatement----------------
 
+// ClassStatement represents a class declaration, Body holds the member
+// declarations: properties, constants, methods and trait uses.
 type ClassStatement struct {
 	*BaseNode
+	Attributes     []*AttributeGroup
 	Name           string
 	Body           *BlockStatement
 	SuperClass     Expression
 	SuperClassName string
+	Interfaces     []string
+	Modifiers      Modifier // abstract or final
 }
 
 func (st *ClassStatement) stmtNode() {}
@@ -53,15 +68,141 @@ func (cs *ClassStatement) TokenLiteral() string {
 func (cs *ClassStatement) String() string {
 	var out bytes.Buffer
 
+	writeAttributes(&out, cs.Attributes)
+	writeModifiers(&out, cs.Modifiers)
 	out.WriteString("class ")
 	out.WriteString(cs.Name)
+	writeClassRest(&out, cs)
+
+	return out.String()
+}
+
+func (cs *ClassStatement) Kind() Kind {
+	return KindClass
+}
+
+// writeClassRest writes a class declaration from its extends clause on
+func writeClassRest(out *bytes.Buffer, cs *ClassStatement) {
+	if cs.SuperClassName != "" {
+		out.WriteString(" extends ")
+		out.WriteString(cs.SuperClassName)
+	}
+	if len(cs.Interfaces) > 0 {
+		out.WriteString(" implements ")
+		out.WriteString(strings.Join(cs.Interfaces, ", "))
+	}
 	out.WriteString(" {\n")
 	out.WriteString(cs.Body.String())
 	out.WriteString("\n}")
+}
+
+// ----------------InterfaceStatement----------------
+
+type InterfaceStatement struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Name       string
+	Extends    []string
+	Body       *BlockStatement
+}
+
+func (st *InterfaceStatement) stmtNode() {}
+
+func (is *InterfaceStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *InterfaceStatement) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, is.Attributes)
+	out.WriteString("interface ")
+	out.WriteString(is.Name)
+	if len(is.Extends) > 0 {
+		out.WriteString(" extends ")
+		out.WriteString(strings.Join(is.Extends, ", "))
+	}
+	out.WriteString(" {\n")
+	out.WriteString(is.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (is *InterfaceStatement) Kind() Kind {
+	return KindClass
+}
+
+// ----------------TraitStatement----------------
 
+type TraitStatement struct {
+	*BaseNode
+	Attributes []*AttributeGroup
+	Name       string
+	Body       *BlockStatement
+}
+
+func (st *TraitStatement) stmtNode() {}
+
+func (ts *TraitStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *TraitStatement) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, ts.Attributes)
+	out.WriteString("trait ")
+	out.WriteString(ts.Name)
+	out.WriteString(" {\n")
+	out.WriteString(ts.Body.String())
+	out.WriteString("\n}")
 	return out.String()
 }
 
+func (ts *TraitStatement) Kind() Kind {
+	return KindClass
+}
+
+// ----------------EnumStatement----------------
+
+// EnumStatement represents `enum Name[: type] [implements A, B] { members }`,
+// BackingType is nil for pure enums.
+type EnumStatement struct {
+	*BaseNode
+	Attributes  []*AttributeGroup
+	Name        string
+	BackingType *TypeHint
+	Interfaces  []string
+	Body        *BlockStatement
+}
+
+func (st *EnumStatement) stmtNode() {}
+
+func (es *EnumStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+
+func (es *EnumStatement) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, es.Attributes)
+	out.WriteString("enum ")
+	out.WriteString(es.Name)
+	if es.BackingType != nil {
+		out.WriteString(": ")
+		out.WriteString(es.BackingType.String())
+	}
+	if len(es.Interfaces) > 0 {
+		out.WriteString(" implements ")
+		out.WriteString(strings.Join(es.Interfaces, ", "))
+	}
+	out.WriteString(" {\n")
+	out.WriteString(es.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (es *EnumStatement) Kind() Kind {
+	return KindClass
+}
+
 type BlockStatement struct {
 	*BaseNode
 	Statements []Statement
@@ -81,6 +222,10 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+func (bs *BlockStatement) Kind() Kind {
+	return KindStmtList
+}
+
 func (bs *BlockStatement) IsEmpty() bool {
 	return len(bs.Statements) == 0
 }
@@ -108,6 +253,10 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+func (rs *ReturnStatement) Kind() Kind {
+	return KindReturn
+}
+
 // ----------------ExpressionStatement----------------
 
 type ExpressionStatement struct {
@@ -128,6 +277,10 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 
+func (es *ExpressionStatement) Kind() Kind {
+	return KindExprStmt
+}
+
 // ----------------WhileStatement----------------
 
 type WhileStatement struct {
@@ -152,6 +305,10 @@ func (ws *WhileStatement) String() string {
 	return out.String()
 }
 
+func (ws *WhileStatement) Kind() Kind {
+	return KindWhile
+}
+
 // ----------------DoStatement----------------
 
 type DoStatement struct {
@@ -168,10 +325,525 @@ func (ws *DoStatement) TokenLiteral() string {
 
 func (ws *DoStatement) String() string {
 	var out bytes.Buffer
-	out.WriteString("while( ")
+	out.WriteString("do {\n")
+	out.WriteString(ws.Body.String())
+	out.WriteString("\n} while (")
 	out.WriteString(ws.Condition.String())
+	out.WriteString(");")
+	return out.String()
+}
+
+func (ws *DoStatement) Kind() Kind {
+	return KindDoWhile
+}
+
+// ----------------ForStatement----------------
+
+type ForStatement struct {
+	*BaseNode
+	Init      []Expression
+	Condition []Expression
+	Loop      []Expression
+	Body      *BlockStatement
+}
+
+func (st *ForStatement) stmtNode() {}
+
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("for (")
+	writeExpressions(&out, fs.Init)
+	out.WriteString("; ")
+	writeExpressions(&out, fs.Condition)
+	out.WriteString("; ")
+	writeExpressions(&out, fs.Loop)
 	out.WriteString(") {\n")
-	out.WriteString(ws.Body.String())
+	out.WriteString(fs.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (fs *ForStatement) Kind() Kind {
+	return KindFor
+}
+
+func writeExpressions(out *bytes.Buffer, exps []Expression) {
+	for i, exp := range exps {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(exp.String())
+	}
+}
+
+// ----------------ForeachStatement----------------
+
+type ForeachStatement struct {
+	*BaseNode
+	Expression Expression
+	Key        Expression // nil if there is no `$k =>`
+	Value      Expression // variable or ListExpression
+	ByRef      bool
+	Body       *BlockStatement
+}
+
+func (st *ForeachStatement) stmtNode() {}
+
+func (fs *ForeachStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *ForeachStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("foreach (")
+	out.WriteString(fs.Expression.String())
+	out.WriteString(" as ")
+	if fs.Key != nil {
+		out.WriteString(fs.Key.String())
+		out.WriteString(" => ")
+	}
+	if fs.ByRef {
+		out.WriteString("&")
+	}
+	out.WriteString(fs.Value.String())
+	out.WriteString(") {\n")
+	out.WriteString(fs.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (fs *ForeachStatement) Kind() Kind {
+	return KindForeach
+}
+
+// ----------------SwitchStatement----------------
+
+type SwitchStatement struct {
+	*BaseNode
+	Condition Expression
+	Cases     []*CaseStatement
+}
+
+func (st *SwitchStatement) stmtNode() {}
+
+func (ss *SwitchStatement) TokenLiteral() string {
+	return ss.Token.Literal
+}
+
+func (ss *SwitchStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("switch (")
+	out.WriteString(ss.Condition.String())
+	out.WriteString(") {\n")
+	for _, c := range ss.Cases {
+		out.WriteString(c.String())
+		out.WriteString("\n")
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+func (ss *SwitchStatement) Kind() Kind {
+	return KindSwitch
+}
+
+// CaseStatement is a `case expr:` or `default:` clause of a switch,
+// Value is nil for the default clause.
+type CaseStatement struct {
+	*BaseNode
+	Value Expression
+	Body  *BlockStatement
+}
+
+func (st *CaseStatement) stmtNode() {}
+
+func (cs *CaseStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *CaseStatement) String() string {
+	var out bytes.Buffer
+	if cs.IsDefault() {
+		out.WriteString("default:\n")
+	} else {
+		out.WriteString("case ")
+		out.WriteString(cs.Value.String())
+		out.WriteString(":\n")
+	}
+	out.WriteString(cs.Body.String())
+	return out.String()
+}
+
+func (cs *CaseStatement) Kind() Kind {
+	return KindSwitchCase
+}
+
+func (cs *CaseStatement) IsDefault() bool {
+	return cs.Value == nil
+}
+
+// ----------------BreakStatement----------------
+
+type BreakStatement struct {
+	*BaseNode
+	Level Expression // nil means 1
+}
+
+func (st *BreakStatement) stmtNode() {}
+
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BreakStatement) String() string {
+	if bs.Level != nil {
+		return "break " + bs.Level.String() + ";"
+	}
+	return "break;"
+}
+
+func (bs *BreakStatement) Kind() Kind {
+	return KindBreak
+}
+
+// ----------------ContinueStatement----------------
+
+type ContinueStatement struct {
+	*BaseNode
+	Level Expression // nil means 1
+}
+
+func (st *ContinueStatement) stmtNode() {}
+
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ContinueStatement) String() string {
+	if cs.Level != nil {
+		return "continue " + cs.Level.String() + ";"
+	}
+	return "continue;"
+}
+
+func (cs *ContinueStatement) Kind() Kind {
+	return KindContinue
+}
+
+// ----------------LabelStatement----------------
+
+// LabelStatement represents the `name:` target of a goto
+type LabelStatement struct {
+	*BaseNode
+	Name string
+}
+
+func (st *LabelStatement) stmtNode() {}
+
+func (ls *LabelStatement) TokenLiteral() string {
+	return ls.Token.Literal
+}
+
+func (ls *LabelStatement) String() string {
+	return ls.Name + ":"
+}
+
+func (ls *LabelStatement) Kind() Kind {
+	return KindLabel
+}
+
+// ----------------GotoStatement----------------
+
+type GotoStatement struct {
+	*BaseNode
+	Label string
+}
+
+func (st *GotoStatement) stmtNode() {}
+
+func (gs *GotoStatement) TokenLiteral() string {
+	return gs.Token.Literal
+}
+
+func (gs *GotoStatement) String() string {
+	return "goto " + gs.Label + ";"
+}
+
+func (gs *GotoStatement) Kind() Kind {
+	return KindGoto
+}
+
+// ----------------TryStatement----------------
+
+// TryStatement represents `try { } catch (...) { } finally { }`,
+// Finally is nil when there is no finally block.
+type TryStatement struct {
+	*BaseNode
+	Body    *BlockStatement
+	Catches []*CatchStatement
+	Finally *BlockStatement
+}
+
+func (st *TryStatement) stmtNode() {}
+
+func (ts *TryStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("try {\n")
+	out.WriteString(ts.Body.String())
 	out.WriteString("\n}")
+	for _, c := range ts.Catches {
+		out.WriteString(" ")
+		out.WriteString(c.String())
+	}
+	if ts.Finally != nil {
+		out.WriteString(" finally {\n")
+		out.WriteString(ts.Finally.String())
+		out.WriteString("\n}")
+	}
 	return out.String()
 }
+
+func (ts *TryStatement) Kind() Kind {
+	return KindTry
+}
+
+// CatchStatement is a `catch (A | B $e) { }` clause of a try statement,
+// Variable is empty for PHP 8's `catch (A) { }`.
+type CatchStatement struct {
+	*BaseNode
+	Types    []string
+	Variable string
+	Body     *BlockStatement
+}
+
+func (st *CatchStatement) stmtNode() {}
+
+func (cs *CatchStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *CatchStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("catch (")
+	out.WriteString(strings.Join(cs.Types, " | "))
+	if cs.Variable != "" {
+		out.WriteString(" $")
+		out.WriteString(cs.Variable)
+	}
+	out.WriteString(") {\n")
+	out.WriteString(cs.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (cs *CatchStatement) Kind() Kind {
+	return KindCatch
+}
+
+// ----------------ThrowStatement----------------
+
+type ThrowStatement struct {
+	*BaseNode
+	Expression Expression
+}
+
+func (st *ThrowStatement) stmtNode() {}
+
+func (ts *ThrowStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *ThrowStatement) String() string {
+	return "throw " + ts.Expression.String() + ";"
+}
+
+func (ts *ThrowStatement) Kind() Kind {
+	return KindThrow
+}
+
+// ----------------InlineHtmlStatement----------------
+
+// InlineHtmlStatement represents the text outside of `<?php ... ?>` tags
+type InlineHtmlStatement struct {
+	*BaseNode
+	Value string
+}
+
+func (st *InlineHtmlStatement) stmtNode() {}
+
+func (ih *InlineHtmlStatement) TokenLiteral() string {
+	return ih.Token.Literal
+}
+
+func (ih *InlineHtmlStatement) String() string {
+	return "?>" + ih.Value + "<?php "
+}
+
+func (ih *InlineHtmlStatement) Kind() Kind {
+	return KindEcho
+}
+
+// ----------------EchoStatement----------------
+
+// EchoStatement represents `echo a, b;`, it's also what `<?= a, b ?>` is
+// parsed into.
+type EchoStatement struct {
+	*BaseNode
+	Expressions []Expression
+}
+
+func (st *EchoStatement) stmtNode() {}
+
+func (es *EchoStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+
+func (es *EchoStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("echo ")
+	writeExpressions(&out, es.Expressions)
+	out.WriteString(";")
+	return out.String()
+}
+
+func (es *EchoStatement) Kind() Kind {
+	return KindEcho
+}
+
+// ----------------GlobalStatement----------------
+
+// GlobalStatement represents `global $a, $b;`, the names are either
+// *Variable or *DynamicVariable.
+type GlobalStatement struct {
+	*BaseNode
+	Names []Expression
+}
+
+func (st *GlobalStatement) stmtNode() {}
+
+func (gs *GlobalStatement) TokenLiteral() string {
+	return gs.Token.Literal
+}
+
+func (gs *GlobalStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("global ")
+	writeExpressions(&out, gs.Names)
+	out.WriteString(";")
+	return out.String()
+}
+
+func (gs *GlobalStatement) Kind() Kind {
+	return KindGlobal
+}
+
+// ----------------StaticStatement----------------
+
+// StaticStatement represents the static variables declaration of a
+// function, `static $a = 1, $b;`.
+type StaticStatement struct {
+	*BaseNode
+	Vars []*StaticVar
+}
+
+func (st *StaticStatement) stmtNode() {}
+
+func (ss *StaticStatement) TokenLiteral() string {
+	return ss.Token.Literal
+}
+
+func (ss *StaticStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("static ")
+	for i, v := range ss.Vars {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(v.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (ss *StaticStatement) Kind() Kind {
+	return KindStmtList
+}
+
+// StaticVar is a variable of a StaticStatement, Default may be nil
+type StaticVar struct {
+	*BaseNode
+	Name    string
+	Default Expression
+}
+
+func (sv *StaticVar) TokenLiteral() string {
+	return sv.Token.Literal
+}
+
+func (sv *StaticVar) String() string {
+	if sv.Default == nil {
+		return "$" + sv.Name
+	}
+	return "$" + sv.Name + " = " + sv.Default.String()
+}
+
+func (sv *StaticVar) Kind() Kind {
+	return KindStatic
+}
+
+// ----------------UnsetStatement----------------
+
+// UnsetStatement represents `unset($a, $b);`
+type UnsetStatement struct {
+	*BaseNode
+	Variables []Expression
+}
+
+func (st *UnsetStatement) stmtNode() {}
+
+func (us *UnsetStatement) TokenLiteral() string {
+	return us.Token.Literal
+}
+
+func (us *UnsetStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("unset(")
+	writeExpressions(&out, us.Variables)
+	out.WriteString(");")
+	return out.String()
+}
+
+func (us *UnsetStatement) Kind() Kind {
+	return KindUnset
+}
+
+// ----------------BadStatement----------------
+
+// BadStatement is a placeholder for the source of a statement that could
+// not be parsed, it spans from Token to Last.
+type BadStatement struct {
+	*BaseNode
+	Last token.Token
+}
+
+func (st *BadStatement) stmtNode() {}
+
+func (bs *BadStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BadStatement) String() string {
+	return "/* bad statement */"
+}
+
+func (bs *BadStatement) Kind() Kind {
+	return KindBad
+}
@@ -39,5 +39,36 @@ func Test_IfStatement(t *testing.T) {
 		Alternative: nil,
 	}
 
-	fmt.Printf("%s", stmt.String())
+	if s := stmt.String(); s != "if (123) {\n\n}" {
+		t.Errorf("expected the if statement, got %q", s)
+	}
+	stmt.Conditionals = append(stmt.Conditionals, &ConditionalExpression{
+		Condition:   &StringLiteral{Value: "it's"},
+		Consequence: &BlockStatement{Statements: []Statement{}},
+	})
+	stmt.Alternative = &BlockStatement{Statements: []Statement{}}
+	if s := stmt.String(); s != "if (123) {\n\n} elseif ('it\\'s') {\n\n} else {\n\n}" {
+		t.Errorf("expected the elseif and else branches, got %q", s)
+	}
+
+	// the nodes built without a BaseNode have no line nor range
+	if line := stmt.Line(); line != 0 {
+		t.Errorf("expected line 0, got %d", line)
+	}
+	if pos := stmt.Conditionals[0].Pos(); pos.IsValid() {
+		t.Errorf("expected an invalid position, got %s", pos)
+	}
+	if line := exp.Line(); line != 1 {
+		t.Errorf("expected line 1, got %d", line)
+	}
+}
+
+func Test_DoStatement(t *testing.T) {
+	stmt := &DoStatement{
+		Condition: &Variable{Name: "a"},
+		Body:      &BlockStatement{Statements: []Statement{}},
+	}
+	if s := stmt.String(); s != "do {\n\n} while ($a);" {
+		t.Errorf("expected the do-while statement, got %q", s)
+	}
 }
@@ -0,0 +1,390 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, in source order,
+// followed by a call of w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+
+	// statements
+	case *IfStatement:
+		for _, c := range n.Conditionals {
+			Walk(v, c)
+		}
+		walkBlock(v, n.Alternative)
+	case *ClassStatement:
+		walkAttributes(v, n.Attributes)
+		walkExpression(v, n.SuperClass)
+		walkBlock(v, n.Body)
+	case *InterfaceStatement:
+		walkAttributes(v, n.Attributes)
+		walkBlock(v, n.Body)
+	case *TraitStatement:
+		walkAttributes(v, n.Attributes)
+		walkBlock(v, n.Body)
+	case *EnumStatement:
+		walkAttributes(v, n.Attributes)
+		walkTypeHint(v, n.BackingType)
+		walkBlock(v, n.Body)
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+	case *ReturnStatement:
+		walkExpression(v, n.ReturnValue)
+	case *ExpressionStatement:
+		walkExpression(v, n.Expression)
+	case *WhileStatement:
+		walkExpression(v, n.Condition)
+		walkBlock(v, n.Body)
+	case *DoStatement:
+		walkBlock(v, n.Body)
+		walkExpression(v, n.Condition)
+	case *ForStatement:
+		walkExpressions(v, n.Init)
+		walkExpressions(v, n.Condition)
+		walkExpressions(v, n.Loop)
+		walkBlock(v, n.Body)
+	case *ForeachStatement:
+		walkExpression(v, n.Expression)
+		walkExpression(v, n.Key)
+		walkExpression(v, n.Value)
+		walkBlock(v, n.Body)
+	case *SwitchStatement:
+		walkExpression(v, n.Condition)
+		for _, c := range n.Cases {
+			Walk(v, c)
+		}
+	case *CaseStatement:
+		walkExpression(v, n.Value)
+		walkBlock(v, n.Body)
+	case *BreakStatement:
+		walkExpression(v, n.Level)
+	case *ContinueStatement:
+		walkExpression(v, n.Level)
+	case *TryStatement:
+		walkBlock(v, n.Body)
+		for _, c := range n.Catches {
+			Walk(v, c)
+		}
+		walkBlock(v, n.Finally)
+	case *CatchStatement:
+		walkBlock(v, n.Body)
+	case *ThrowStatement:
+		walkExpression(v, n.Expression)
+	case *EchoStatement:
+		walkExpressions(v, n.Expressions)
+	case *GlobalStatement:
+		walkExpressions(v, n.Names)
+	case *StaticStatement:
+		for _, s := range n.Vars {
+			Walk(v, s)
+		}
+	case *StaticVar:
+		walkExpression(v, n.Default)
+	case *UnsetStatement:
+		walkExpressions(v, n.Variables)
+	case *LabelStatement, *GotoStatement, *InlineHtmlStatement, *BadStatement:
+		// nothing to do
+
+	// declarations
+	case *AttributeGroup:
+		for _, a := range n.Attributes {
+			Walk(v, a)
+		}
+	case *Attribute:
+		walkArguments(v, n.Arguments)
+	case *Parameter:
+		walkAttributes(v, n.Attributes)
+		walkTypeHint(v, n.Type)
+		walkExpression(v, n.Default)
+	case *FunctionStatement:
+		walkAttributes(v, n.Attributes)
+		walkParameters(v, n.Parameters)
+		walkTypeHint(v, n.ReturnType)
+		walkBlock(v, n.Body)
+	case *ClosureExpression:
+		walkAttributes(v, n.Attributes)
+		walkParameters(v, n.Parameters)
+		for _, u := range n.Uses {
+			Walk(v, u)
+		}
+		walkTypeHint(v, n.ReturnType)
+		walkBlock(v, n.Body)
+	case *ArrowFunctionExpression:
+		walkAttributes(v, n.Attributes)
+		walkParameters(v, n.Parameters)
+		walkTypeHint(v, n.ReturnType)
+		walkExpression(v, n.Expression)
+	case *PropertyStatement:
+		walkAttributes(v, n.Attributes)
+		walkTypeHint(v, n.Type)
+		for _, p := range n.Properties {
+			Walk(v, p)
+		}
+	case *PropertyItem:
+		walkExpression(v, n.Default)
+	case *ClassConstStatement:
+		walkAttributes(v, n.Attributes)
+		walkConstants(v, n.Constants)
+	case *ConstantItem:
+		walkExpression(v, n.Value)
+	case *EnumCaseStatement:
+		walkAttributes(v, n.Attributes)
+		walkExpression(v, n.Value)
+	case *MethodStatement:
+		walkAttributes(v, n.Attributes)
+		walkParameters(v, n.Parameters)
+		walkTypeHint(v, n.ReturnType)
+		walkBlock(v, n.Body)
+	case *TraitUseStatement:
+		walkStatements(v, n.Adaptations)
+	case *NamespaceStatement:
+		walkBlock(v, n.Body)
+	case *UseStatement:
+		for _, u := range n.Uses {
+			Walk(v, u)
+		}
+	case *DeclareStatement:
+		walkConstants(v, n.Directives)
+		walkBlock(v, n.Body)
+	case *ConstStatement:
+		walkConstants(v, n.Constants)
+	case *TypeHint, *ClosureUse, *TraitPrecedenceStatement, *TraitAliasStatement, *UseItem:
+		// nothing to do
+
+	// expressions
+	case *ArrayExpression:
+		walkArrayItems(v, n.Items)
+	case *ListExpression:
+		walkArrayItems(v, n.Items)
+	case *ArrayItem:
+		walkExpression(v, n.Key)
+		walkExpression(v, n.Value)
+	case *ConditionalExpression:
+		walkExpression(v, n.Condition)
+		walkBlock(v, n.Consequence)
+	case *DynamicVariable:
+		walkExpression(v, n.Name)
+	case *IndexExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Index)
+	case *PropertyFetchExpression:
+		walkExpression(v, n.Object)
+		walkExpression(v, n.Property)
+	case *StaticPropertyFetchExpression:
+		walkExpression(v, n.Class)
+		walkExpression(v, n.Property)
+	case *ClassConstFetchExpression:
+		walkExpression(v, n.Class)
+	case *CallExpression:
+		walkExpression(v, n.Function)
+		walkArguments(v, n.Arguments)
+	case *MethodCallExpression:
+		walkExpression(v, n.Object)
+		walkExpression(v, n.Method)
+		walkArguments(v, n.Arguments)
+	case *StaticCallExpression:
+		walkExpression(v, n.Class)
+		walkExpression(v, n.Method)
+		walkArguments(v, n.Arguments)
+	case *Argument:
+		walkExpression(v, n.Value)
+	case *NewExpression:
+		walkExpression(v, n.Class)
+		walkArguments(v, n.Arguments)
+		if n.AnonymousClass != nil {
+			Walk(v, n.AnonymousClass)
+		}
+	case *CloneExpression:
+		walkExpression(v, n.Expression)
+	case *PrefixExpression:
+		walkExpression(v, n.Right)
+	case *ThrowExpression:
+		walkExpression(v, n.Expression)
+	case *PostfixExpression:
+		walkExpression(v, n.Left)
+	case *InfixExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *TernaryExpression:
+		walkExpression(v, n.Condition)
+		walkExpression(v, n.Consequence)
+		walkExpression(v, n.Alternative)
+	case *CastExpression:
+		walkExpression(v, n.Expression)
+	case *AssignExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *AssignRefExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *CompoundAssignExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *InterpolatedStringExpression:
+		walkExpressions(v, n.Parts)
+	case *ShellExecExpression:
+		walkExpressions(v, n.Parts)
+	case *IncludeOrEvalExpression:
+		walkExpression(v, n.Expression)
+	case *PrintExpression:
+		walkExpression(v, n.Expression)
+	case *YieldExpression:
+		walkExpression(v, n.Key)
+		walkExpression(v, n.Value)
+	case *YieldFromExpression:
+		walkExpression(v, n.Expression)
+	case *IssetExpression:
+		walkExpressions(v, n.Variables)
+	case *EmptyExpression:
+		walkExpression(v, n.Expression)
+	case *ExitExpression:
+		walkExpression(v, n.Expression)
+	case *MatchExpression:
+		walkExpression(v, n.Subject)
+		for _, a := range n.Arms {
+			Walk(v, a)
+		}
+	case *MatchArm:
+		walkExpressions(v, n.Conditions)
+		walkExpression(v, n.Body)
+	case *IntegerLiteral, *FloatLiteral, *StringLiteral, *Identifier, *BooleanExpression,
+		*NullExpression, *Constant, *Variable:
+		// nothing to do
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, s := range list {
+		Walk(v, s)
+	}
+}
+
+func walkExpressions(v Visitor, list []Expression) {
+	for _, e := range list {
+		walkExpression(v, e)
+	}
+}
+
+func walkExpression(v Visitor, e Expression) {
+	if e != nil {
+		Walk(v, e)
+	}
+}
+
+func walkBlock(v Visitor, b *BlockStatement) {
+	if b != nil {
+		Walk(v, b)
+	}
+}
+
+func walkTypeHint(v Visitor, t *TypeHint) {
+	if t != nil {
+		Walk(v, t)
+	}
+}
+
+func walkAttributes(v Visitor, list []*AttributeGroup) {
+	for _, g := range list {
+		Walk(v, g)
+	}
+}
+
+func walkParameters(v Visitor, list []*Parameter) {
+	for _, p := range list {
+		Walk(v, p)
+	}
+}
+
+func walkArguments(v Visitor, list []*Argument) {
+	for _, a := range list {
+		Walk(v, a)
+	}
+}
+
+func walkConstants(v Visitor, list []*ConstantItem) {
+	for _, c := range list {
+		Walk(v, c)
+	}
+}
+
+func walkArrayItems(v Visitor, list []*ArrayItem) {
+	for _, item := range list {
+		// nil for the skipped entries of list(, $a)
+		if item != nil {
+			Walk(v, item)
+		}
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// traverser calls enter and leave around the children of each node
+type traverser struct {
+	enter func(Node) bool
+	leave func(Node)
+	stack []Node
+}
+
+func (t *traverser) Visit(node Node) Visitor {
+	if node == nil {
+		n := len(t.stack) - 1
+		if t.leave != nil {
+			t.leave(t.stack[n])
+		}
+		t.stack = t.stack[:n]
+		return nil
+	}
+	if t.enter != nil && !t.enter(node) {
+		if t.leave != nil {
+			t.leave(node)
+		}
+		return nil
+	}
+	t.stack = append(t.stack, node)
+	return t
+}
+
+// Traverse traverses an AST in depth-first order, calling enter before
+// the children of each node and leave after them. When enter returns
+// false the children of the node are skipped, leave is still called for
+// it. Either callback may be nil.
+func Traverse(node Node, enter func(Node) bool, leave func(Node)) {
+	Walk(&traverser{enter: enter, leave: leave}, node)
+}
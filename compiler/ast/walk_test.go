@@ -0,0 +1,269 @@
+package ast_test
+
+import (
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/parser"
+)
+
+// allNodes uses every kind of node
+const allNodes = `<?php
+declare(strict_types=1);
+namespace App;
+use Foo\Bar as Baz;
+use function Foo\strlen;
+const X = 1, Y = 2;
+#[Attr(1, name: 2)]
+function &f(int|string $a = 1, ?Foo &...$rest): ?int {
+	static $s = 1, $t;
+	global $g;
+	if ($a) { echo 1; } elseif ($b) { echo 2; } else { echo 3; }
+	while ($a) { break; }
+	do { continue 1; } while ($a);
+	for ($i = 0; $i < 10; $i++) {}
+	foreach ($arr as $k => &$v) {}
+	switch ($a) { case 1: break; default: }
+	try { throw new Exception('x'); } catch (A | B $e) {} finally {}
+	label:
+	goto label;
+	unset($a[0], $b->c); yield $k => $v; yield from g();
+	return $a;
+}
+abstract class A extends B implements C {
+	use T1, T2 { T1::foo insteadof T2; T2::foo as protected bar; }
+	public const Z = 1;
+	public static ?int $p = 1, $q;
+	abstract public function m();
+	public function __construct(private readonly int $x) {}
+}
+interface I extends J {}
+trait T {}
+enum E: string implements I { case A = 'a'; }
+?>
+<html>
+<?php
+$x = [1, 'k' => &$y, ...$z];
+[$a, , [$b]] = $c;
+$$name = ${'a' . 'b'};
+$q = $o?->p->q + A::$sp;
+A::C; A::m(...); $o->m(1); strlen(...);
+$fn = static function ($a) use ($b, &$c): int { return 1; };
+$af = fn($x) => $x * 2;
+new class(1) extends B {};
+$cl = clone $o;
+$r = -$a + !$b;
+$a++;
+$t = $a ? $b : ($c ?: $d);
+$i = (int) $f;
+$a =& $b; $a .= 'x';
+$s = "a $b {$c->d} ${e} $f->g";
+$sh = ` + "`ls $dir`" + `;
+include 'a.php';
+print 1;
+isset($a, $b); empty($a); exit(1);
+$m = match ($a) { 1, 2 => 'a', default => throw new E() };
+$f = 1.5; $b = true; $n = null; FOO;
+$bad = ;
+`
+
+// nodeTypes returns the names of the types embedding *BaseNode declared
+// in the ast package, and Program.
+func nodeTypes(t *testing.T) []string {
+	pkgs, err := goparser.ParseDir(gotoken.NewFileSet(), ".", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"Program"}
+	for _, f := range pkgs["ast"].Files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*goast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts, ok := spec.(*goast.TypeSpec)
+				if !ok {
+					continue
+				}
+				st, ok := ts.Type.(*goast.StructType)
+				if !ok || len(st.Fields.List) == 0 {
+					continue
+				}
+				if star, ok := st.Fields.List[0].Type.(*goast.StarExpr); ok {
+					if id, ok := star.X.(*goast.Ident); ok && id.Name == "BaseNode" {
+						names = append(names, ts.Name.Name)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fieldChildren returns the non-nil nodes held by the fields of node
+func fieldChildren(node ast.Node) []ast.Node {
+	nodeType := reflect.TypeOf((*ast.Node)(nil)).Elem()
+	var children []ast.Node
+	add := func(v reflect.Value) {
+		if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return
+			}
+		}
+		if v.Type().Implements(nodeType) {
+			children = append(children, v.Interface().(ast.Node))
+		}
+	}
+	v := reflect.ValueOf(node).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Anonymous {
+			continue
+		}
+		f := v.Field(i)
+		if f.Kind() == reflect.Slice {
+			for j := 0; j < f.Len(); j++ {
+				add(f.Index(j))
+			}
+			continue
+		}
+		add(f)
+	}
+	return children
+}
+
+func Test_WalkAllNodes(t *testing.T) {
+	program, err := parser.ParseFile(nil, "a.php", allNodes, parser.AllErrors)
+	if program == nil {
+		t.Fatalf("parser error: %s", err)
+	}
+	if list := err.(parser.ErrorList); len(list) != 1 || list[0].Pos.Line != 57 {
+		t.Fatalf("expected only the error of the bad statement, got %v", err)
+	}
+
+	seen := map[string]bool{}
+	children := map[ast.Node][]ast.Node{}
+	var stack []ast.Node
+	ast.Traverse(program, func(n ast.Node) bool {
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			children[parent] = append(children[parent], n)
+		}
+		children[n] = nil // checked even without children
+		stack = append(stack, n)
+		seen[reflect.TypeOf(n).Elem().Name()] = true
+		return true
+	}, func(n ast.Node) {
+		if stack[len(stack)-1] != n {
+			t.Fatalf("leave called for %T, expected %T", n, stack[len(stack)-1])
+		}
+		stack = stack[:len(stack)-1]
+	})
+	if len(stack) != 0 {
+		t.Fatalf("expected enter and leave to be balanced, %d left", len(stack))
+	}
+
+	var missing []string
+	for _, name := range nodeTypes(t) {
+		if !seen[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		t.Errorf("node types not reached: %s", strings.Join(missing, ", "))
+	}
+
+	// every node in a field of a visited node is visited as its child
+	for parent, got := range children {
+		counts := map[ast.Node][2]int{} // visits and occurrences in the fields
+		for _, n := range got {
+			c := counts[n]
+			c[0]++
+			counts[n] = c
+		}
+		for _, n := range fieldChildren(parent) {
+			c := counts[n]
+			c[1]++
+			counts[n] = c
+		}
+		for n, c := range counts {
+			if c[0] != c[1] {
+				t.Errorf("%T: child %T %q visited %d times, expected %d", parent, n, n.String(), c[0], c[1])
+			}
+		}
+	}
+}
+
+func Test_WalkOrder(t *testing.T) {
+	program, err := parser.ParseFile(nil, "a.php", `<?php do { $a = foo($b, 1); } while ($c > 2);`, 0)
+	if err != nil {
+		t.Fatalf("parser error: %s", err)
+	}
+	var out []string
+	ast.Traverse(program, func(n ast.Node) bool {
+		out = append(out, ">"+strings.TrimPrefix(reflect.TypeOf(n).String(), "*ast."))
+		return true
+	}, func(n ast.Node) {
+		out = append(out, "<")
+	})
+	expected := ">Program >DoStatement >BlockStatement >ExpressionStatement >AssignExpression " +
+		">Variable < >CallExpression >Constant < >Argument >Variable < < >Argument >IntegerLiteral < < < < < < " +
+		">InfixExpression >Variable < >IntegerLiteral < < < <"
+	if s := strings.Join(out, " "); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+}
+
+func Test_InspectSkip(t *testing.T) {
+	program, err := parser.ParseFile(nil, "a.php", `<?php function f() { $a = 1; } $b = function () { $c = 2; }; $d = 3;`, 0)
+	if err != nil {
+		t.Fatalf("parser error: %s", err)
+	}
+	var names []string
+	leaves := 0
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case nil:
+			leaves++
+		case *ast.FunctionStatement, *ast.ClosureExpression:
+			return false
+		case *ast.Variable:
+			names = append(names, n.Name)
+		}
+		return true
+	})
+	if s := strings.Join(names, ","); s != "b,d" {
+		t.Errorf("expected the function bodies to be skipped, got %q", s)
+	}
+	if leaves != 8 {
+		t.Errorf("expected f(nil) for the 8 nodes whose children were visited, got %d", leaves)
+	}
+}
+
+type countVisitor map[string]int
+
+func (v countVisitor) Visit(n ast.Node) ast.Visitor {
+	if n != nil {
+		v[reflect.TypeOf(n).Elem().Name()]++
+	}
+	return v
+}
+
+func Test_WalkVisitor(t *testing.T) {
+	program, err := parser.ParseFile(nil, "a.php", `<?php if ($a) { echo $b; } elseif ($c) {} else { echo $d ? 1 : 2; }`, 0)
+	if err != nil {
+		t.Fatalf("parser error: %s", err)
+	}
+	v := countVisitor{}
+	ast.Walk(v, program)
+	if v["Variable"] != 4 || v["ConditionalExpression"] != 2 || v["BlockStatement"] != 3 || v["TernaryExpression"] != 1 {
+		t.Errorf("unexpected counts %v", v)
+	}
+}
@@ -2,21 +2,28 @@ package main
 
 import (
 	"flag"
-	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"runtime/pprof"
 
-	"github.com/eaglewu/luban/compiler/lexer"
+	"github.com/eaglewu/luban/compiler/diag"
+	"github.com/eaglewu/luban/compiler/parser"
 	"github.com/eaglewu/luban/compiler/token"
 )
 
 var cpuprofile = flag.String("cpuprof", "", "write cpu profile to file")
 var compilefile = flag.String("file", "", "write cpu profile to file")
+var jsonOutput = flag.Bool("json", false, "print the diagnostics as JSON")
+var color = flag.Bool("color", false, "print the diagnostics in color")
 
 func main() {
 	flag.Parse()
+	os.Exit(run())
+}
+
+// run compiles the file and returns the exit code, 1 when the file has
+// errors. It returns rather than exits so that the profile is written.
+func run() int {
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
@@ -26,20 +33,16 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	buf, err := ioutil.ReadFile(*compilefile)
-	if err != nil {
-		log.Fatal(err)
+	fset := token.NewFileSet()
+	_, err := parser.ParseFile(fset, *compilefile, nil, parser.AllErrors)
+	if err == nil {
+		return 0
 	}
-	l := lexer.New(string(buf))
-	go l.Run()
-
-	//tokens := []token.Token{}
-	for tok := l.NextToken(); tok.Type != token.Error && tok.Type != token.End; tok = l.NextToken() {
-		//tokens = append(tokens, tok)
-		// fmt.Printf("%+v\n", tok)
-		// if tok.Line == 102 {
-		// 	break
-		// }
+	if *jsonOutput {
+		diag.WriteJSON(os.Stdout, diag.FromError(err))
+	} else {
+		p := &diag.Printer{Fset: fset, Color: *color}
+		p.Fprint(os.Stderr, diag.FromError(err)...)
 	}
-	fmt.Println("done")
+	return 1
 }
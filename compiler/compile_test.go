@@ -1 +1,26 @@
 package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_RunWritesProfileOnErrors(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.php")
+	if err := ioutil.WriteFile(src, []byte("<?php $a = ;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prof := filepath.Join(dir, "cpu.prof")
+	*compilefile, *cpuprofile, *jsonOutput = src, prof, true
+	defer func() { *compilefile, *cpuprofile, *jsonOutput = "", "", false }()
+
+	if code := run(); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if info, err := os.Stat(prof); err != nil || info.Size() == 0 {
+		t.Errorf("expected the profile to be written, got %v", err)
+	}
+}
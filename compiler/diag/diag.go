@@ -0,0 +1,109 @@
+// Package diag describes the problems found in PHP source, by the lexer,
+// the parser or the later stages, and renders them with the offending
+// source lines for people or as JSON for tools.
+package diag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// Severity tells how serious a diagnostic is
+type Severity int
+
+const (
+	// Error is a problem preventing the source from being compiled
+	Error Severity = iota
+	// Warning is a suspicious construct the source still compiles with
+	Warning
+	// Note gives additional information
+	Note
+)
+
+var severityNames = map[Severity]string{
+	Error:   "error",
+	Warning: "warning",
+	Note:    "note",
+}
+
+func (s Severity) String() string {
+	if n, ok := severityNames[s]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+// Label attaches a message to a span of source, e.g. the brace left open
+// by the code a syntax error is reported on.
+type Label struct {
+	Pos     token.Position
+	End     token.Position
+	Message string
+}
+
+// Diagnostic is a message about the span of source from Pos to End
+type Diagnostic struct {
+	Severity Severity
+	// Code identifies the kind of problem, e.g. "UnexpectedTokenError"
+	Code    string
+	Message string
+	Pos     token.Position
+	End     token.Position
+	// Labels point at the other spans related to the problem
+	Labels []Label
+}
+
+// Errorf returns an error diagnostic about the span from pos to end
+func Errorf(pos, end token.Position, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{Severity: Error, Message: fmt.Sprintf(format, args...), Pos: pos, End: end}
+}
+
+// Label adds a label about the span from pos to end, it returns d so that
+// calls can be chained.
+func (d *Diagnostic) Label(pos, end token.Position, format string, args ...interface{}) *Diagnostic {
+	d.Labels = append(d.Labels, Label{Pos: pos, End: end, Message: fmt.Sprintf(format, args...)})
+	return d
+}
+
+// Error returns the message prefixed by the position
+func (d *Diagnostic) Error() string {
+	if !d.Pos.IsValid() && d.Pos.Filename == "" {
+		return d.Message
+	}
+	return d.Pos.String() + ": " + d.Message
+}
+
+// Diagnostic implements Diagnoser
+func (d *Diagnostic) Diagnostic() *Diagnostic {
+	return d
+}
+
+// Diagnoser is implemented by the errors of the compile stages that can
+// describe themselves as a diagnostic.
+type Diagnoser interface {
+	Diagnostic() *Diagnostic
+}
+
+// lister is implemented by the error lists
+type lister interface {
+	Diagnostics() []*Diagnostic
+}
+
+// FromError returns the diagnostics described by err. Errors that are
+// neither a list of diagnostics nor wrap a Diagnoser become a diagnostic
+// without position.
+func FromError(err error) []*Diagnostic {
+	if err == nil {
+		return nil
+	}
+	if l, ok := err.(lister); ok {
+		return l.Diagnostics()
+	}
+	var d Diagnoser
+	if errors.As(err, &d) {
+		return []*Diagnostic{d.Diagnostic()}
+	}
+	return []*Diagnostic{{Severity: Error, Message: err.Error()}}
+}
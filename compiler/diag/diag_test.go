@@ -0,0 +1,157 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+func pos(line, column int) token.Position {
+	return token.Position{Filename: "a.php", Line: line, Column: column}
+}
+
+func Test_Print(t *testing.T) {
+	fset := token.NewFileSet()
+	fset.AddFile("a.php", "<?php function f() {\n    echo 1;\n\n\n  if ($a) {\n\t$b = 'héllo' $c;\n")
+	tests := []struct {
+		diagnostic *Diagnostic
+		expected   string
+	}{
+		{
+			Errorf(pos(7, 1), pos(7, 1), "syntax error, unexpected end of file").
+				Label(pos(1, 20), pos(1, 21), "unclosed `{` opened here"),
+			`error: syntax error, unexpected end of file
+ --> a.php:7:1
+  |
+1 | <?php function f() {
+  |                    - unclosed ` + "`{`" + ` opened here
+...
+7 |
+  | ^
+`,
+		},
+		{
+			&Diagnostic{Severity: Warning, Code: "W1", Message: "unused", Pos: pos(2, 10), End: pos(2, 10)},
+			`warning[W1]: unused
+ --> a.php:2:10
+  |
+2 |     echo 1;
+  |          ^
+`,
+		},
+		{
+			Errorf(pos(6, 16), pos(6, 18), "unexpected variable").
+				Label(pos(5, 9), pos(5, 10), "in this block").
+				Label(pos(6, 7), pos(6, 15), "after this string"),
+			"error: unexpected variable\n --> a.php:6:16\n  |\n" +
+				"5 |   if ($a) {\n" +
+				"  |         - in this block\n" +
+				"6 | \t$b = 'héllo' $c;\n" +
+				"  | \t     ------- after this string\n" +
+				"  | \t             ^^\n",
+		},
+		{
+			Errorf(pos(2, 5), pos(4, 1), "multi-line span").Label(pos(5, 3), pos(5, 5), "line after the gap"),
+			`error: multi-line span
+ --> a.php:2:5
+  |
+2 |     echo 1;
+  |     ^^^^^^^
+...
+5 |   if ($a) {
+  |   -- line after the gap
+`,
+		},
+		{
+			Errorf(token.Position{Filename: "b.php", Line: 3, Column: 1}, token.Position{}, "no source").
+				Label(pos(1, 1), pos(1, 2), "see here"),
+			"error: no source\n--> b.php:3:1\n  = a.php:1:1: see here\n",
+		},
+		{
+			&Diagnostic{Message: "no position"},
+			"error: no position\n",
+		},
+	}
+	for i, tt := range tests {
+		var out bytes.Buffer
+		p := &Printer{Fset: fset}
+		if err := p.Fprint(&out, tt.diagnostic); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("tests[%d] - expected:\n%s\ngot:\n%s", i, tt.expected, out.String())
+		}
+	}
+}
+
+func Test_PrintColor(t *testing.T) {
+	fset := token.NewFileSet()
+	fset.AddFile("a.php", "<?php $a")
+	var out bytes.Buffer
+	p := &Printer{Fset: fset, Color: true}
+	p.Fprint(&out, Errorf(pos(1, 7), pos(1, 9), "oops").Label(pos(1, 1), pos(1, 6), "here"))
+	for _, s := range []string{red + "error" + reset, bold + ": oops" + reset, red + "^^" + reset, blue + "----- here" + reset} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected %q in %q", s, out.String())
+		}
+	}
+}
+
+type listError []*Diagnostic
+
+func (l listError) Error() string              { return "list" }
+func (l listError) Diagnostics() []*Diagnostic { return l }
+
+func Test_FromError(t *testing.T) {
+	d := Errorf(pos(1, 1), pos(1, 2), "oops")
+	if ds := FromError(fmt.Errorf("wrapped: %w", d)); len(ds) != 1 || ds[0] != d {
+		t.Errorf("expected the wrapped diagnostic, got %v", ds)
+	}
+	if ds := FromError(listError{d, d}); len(ds) != 2 {
+		t.Errorf("expected 2 diagnostics, got %d", len(ds))
+	}
+	if ds := FromError(errors.New("plain")); len(ds) != 1 || ds[0].Message != "plain" || ds[0].Pos.IsValid() {
+		t.Errorf("expected a diagnostic without position, got %v", ds)
+	}
+	if FromError(nil) != nil {
+		t.Errorf("expected no diagnostics for nil")
+	}
+	if d.Error() != "a.php:1:1: oops" {
+		t.Errorf("unexpected error string %q", d.Error())
+	}
+}
+
+func Test_WriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	d := Errorf(pos(3, 1), pos(3, 1), "unexpected end of file").Label(pos(1, 20), pos(1, 21), "unclosed `{` opened here")
+	d.Code = "UnexpectedTokenError"
+	if err := WriteJSON(&out, []*Diagnostic{d, {Severity: Note, Message: "<?php"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"message": "<?php"`) {
+		t.Errorf("expected unescaped HTML characters in %s", out.String())
+	}
+
+	var got []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[map[code:UnexpectedTokenError end:map[column:1 line:3 offset:0] file:a.php ` +
+		`labels:[map[end:map[column:21 line:1 offset:0] file:a.php message:unclosed ` + "`{`" + ` opened here ` +
+		`start:map[column:20 line:1 offset:0]]] message:unexpected end of file severity:error ` +
+		`start:map[column:1 line:3 offset:0]] map[message:<?php severity:note]]`
+	if s := fmt.Sprint(got); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+
+	out.Reset()
+	WriteJSON(&out, nil)
+	if out.String() != "[]\n" {
+		t.Errorf("expected an empty array, got %q", out.String())
+	}
+}
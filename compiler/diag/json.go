@@ -0,0 +1,86 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// The JSON form of a diagnostic:
+//
+//	{
+//	  "severity": "error",
+//	  "code": "UnexpectedTokenError",
+//	  "message": "syntax error, unexpected end of file",
+//	  "file": "a.php",
+//	  "start": {"line": 3, "column": 1, "offset": 31},
+//	  "end": {"line": 3, "column": 1, "offset": 31},
+//	  "labels": [{"file": "a.php", "start": ..., "end": ..., "message": "unclosed `{` opened here"}]
+//	}
+type jsonDiagnostic struct {
+	Severity string      `json:"severity"`
+	Code     string      `json:"code,omitempty"`
+	Message  string      `json:"message"`
+	File     string      `json:"file,omitempty"`
+	Start    *jsonPos    `json:"start,omitempty"`
+	End      *jsonPos    `json:"end,omitempty"`
+	Labels   []jsonLabel `json:"labels,omitempty"`
+}
+
+type jsonLabel struct {
+	File    string   `json:"file,omitempty"`
+	Start   *jsonPos `json:"start,omitempty"`
+	End     *jsonPos `json:"end,omitempty"`
+	Message string   `json:"message"`
+}
+
+type jsonPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+func newJSONPos(pos token.Position) *jsonPos {
+	if !pos.IsValid() {
+		return nil
+	}
+	return &jsonPos{Line: pos.Line, Column: pos.Column, Offset: pos.Offset}
+}
+
+// MarshalJSON implements json.Marshaler
+func (d *Diagnostic) MarshalJSON() ([]byte, error) {
+	v := jsonDiagnostic{
+		Severity: d.Severity.String(),
+		Code:     d.Code,
+		Message:  d.Message,
+		File:     d.Pos.Filename,
+		Start:    newJSONPos(d.Pos),
+		End:      newJSONPos(d.End),
+	}
+	for _, l := range d.Labels {
+		v.Labels = append(v.Labels, jsonLabel{
+			File:    l.Pos.Filename,
+			Start:   newJSONPos(l.Pos),
+			End:     newJSONPos(l.End),
+			Message: l.Message,
+		})
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // keep "<?php" readable
+	err := enc.Encode(v)
+	return bytes.TrimRight(buf.Bytes(), "\n"), err
+}
+
+// WriteJSON writes the diagnostics to w as a JSON array
+func WriteJSON(w io.Writer, diagnostics []*Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []*Diagnostic{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(diagnostics)
+}
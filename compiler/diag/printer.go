@@ -0,0 +1,199 @@
+package diag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// ANSI escape sequences used in color mode
+const (
+	reset  = "\x1b[0m"
+	bold   = "\x1b[1m"
+	red    = "\x1b[1;31m"
+	yellow = "\x1b[1;33m"
+	blue   = "\x1b[1;34m"
+	cyan   = "\x1b[1;36m"
+)
+
+var severityColors = map[Severity]string{
+	Error:   red,
+	Warning: yellow,
+	Note:    cyan,
+}
+
+// Printer renders diagnostics as text. The source lines are taken from
+// Fset, the diagnostics about files missing from it are printed without
+// them:
+//
+//	error[UnexpectedTokenError]: syntax error, unexpected end of file
+//	 --> a.php:3:1
+//	  |
+//	1 | <?php function f() {
+//	  |                    - unclosed `{` opened here
+//	2 |     echo 1;
+//	3 |
+//	  | ^
+type Printer struct {
+	Fset *token.FileSet
+	// Color enables ANSI colors, for terminals
+	Color bool
+}
+
+// mark is an underlined span of a line
+type mark struct {
+	line    int
+	column  int
+	end     int // column after the span, 0 for the end of the line
+	primary bool
+	message string
+}
+
+// Fprint renders the diagnostics to w
+func (p *Printer) Fprint(w io.Writer, diagnostics ...*Diagnostic) error {
+	b := bufio.NewWriter(w)
+	for _, d := range diagnostics {
+		p.print(b, d)
+	}
+	return b.Flush()
+}
+
+// Fprint renders the diagnostics described by err to w without colors
+func Fprint(w io.Writer, fset *token.FileSet, err error) error {
+	p := &Printer{Fset: fset}
+	return p.Fprint(w, FromError(err)...)
+}
+
+func (p *Printer) print(w *bufio.Writer, d *Diagnostic) {
+	header := d.Severity.String()
+	if d.Code != "" {
+		header += "[" + d.Code + "]"
+	}
+	w.WriteString(p.color(severityColors[d.Severity], header))
+	w.WriteString(p.color(bold, ": "+d.Message) + "\n")
+
+	var file *token.SourceFile
+	if p.Fset != nil && d.Pos.IsValid() {
+		file = p.Fset.File(d.Pos.Filename)
+	}
+	if file == nil {
+		if d.Pos.IsValid() || d.Pos.Filename != "" {
+			fmt.Fprintf(w, "%s %s\n", p.color(blue, "-->"), d.Pos)
+		}
+		for _, l := range d.Labels {
+			fmt.Fprintf(w, "%s %s: %s\n", p.color(blue, "  ="), l.Pos, l.Message)
+		}
+		return
+	}
+
+	marks := []mark{newMark(d.Pos, d.End, true, "")}
+	var others []Label // labels about other files
+	for _, l := range d.Labels {
+		if l.Pos.Filename != d.Pos.Filename || !l.Pos.IsValid() {
+			others = append(others, l)
+			continue
+		}
+		marks = append(marks, newMark(l.Pos, l.End, false, l.Message))
+	}
+	sort.SliceStable(marks, func(i, j int) bool {
+		if marks[i].line != marks[j].line {
+			return marks[i].line < marks[j].line
+		}
+		return marks[i].column < marks[j].column
+	})
+
+	width := len(strconv.Itoa(marks[len(marks)-1].line))
+	gutter := func(s string) string {
+		return p.color(blue, fmt.Sprintf("%*s |", width, s))
+	}
+	fmt.Fprintf(w, "%*s%s %s\n", width, "", p.color(blue, "-->"), d.Pos)
+	w.WriteString(gutter("") + "\n")
+
+	last := 0
+	for _, m := range marks {
+		if m.line != last {
+			if last > 0 && m.line == last+2 {
+				p.printLine(w, gutter(strconv.Itoa(last+1)), file.Line(last+1))
+			} else if last > 0 && m.line > last+2 {
+				w.WriteString(p.color(blue, "...") + "\n")
+			}
+			p.printLine(w, gutter(strconv.Itoa(m.line)), file.Line(m.line))
+			last = m.line
+		}
+		p.printMark(w, gutter(""), file.Line(m.line), m, severityColors[d.Severity])
+	}
+	for _, l := range others {
+		fmt.Fprintf(w, "%s %s: %s\n", p.color(blue, strings.Repeat(" ", width)+" ="), l.Pos, l.Message)
+	}
+}
+
+func newMark(pos, end token.Position, primary bool, message string) mark {
+	m := mark{line: pos.Line, column: pos.Column, primary: primary, message: message}
+	if m.column < 1 {
+		m.column = 1
+	}
+	if end.Line == pos.Line && end.Column > m.column {
+		m.end = end.Column
+	} else if end.Line > pos.Line {
+		m.end = 0 // underline up to the end of the first line
+	} else {
+		m.end = m.column + 1
+	}
+	return m
+}
+
+func (p *Printer) printLine(w *bufio.Writer, gutter, text string) {
+	w.WriteString(gutter)
+	if text != "" {
+		w.WriteString(" " + text)
+	}
+	w.WriteString("\n")
+}
+
+// printMark underlines the span of m in text, the padding keeps the tabs
+// of the line and counts one column per character so that the underline
+// stays aligned.
+func (p *Printer) printMark(w *bufio.Writer, gutter, text string, m mark, color string) {
+	start := m.column - 1
+	if start > len(text) {
+		start = len(text)
+	}
+	end := len(text)
+	if m.end > 0 && m.end-1 < end {
+		end = m.end - 1
+	}
+	var pad strings.Builder
+	for _, r := range text[:start] {
+		if r == '\t' {
+			pad.WriteByte('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+	n := utf8.RuneCountInString(text[start:end])
+	if n < 1 {
+		n = 1
+	}
+	underline, c := "-", blue
+	if m.primary {
+		underline, c = "^", color
+	}
+	line := strings.Repeat(underline, n)
+	if m.message != "" {
+		line += " " + m.message
+	}
+	w.WriteString(gutter + " " + pad.String() + p.color(c, line) + "\n")
+}
+
+func (p *Printer) color(color, s string) string {
+	if !p.Color {
+		return s
+	}
+	return color + s + reset
+}
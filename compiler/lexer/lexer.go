@@ -51,11 +51,16 @@ type Lexer struct {
 	start     int              // start position of this item
 	width     int              // width of last rune read from input
 	line      int              // 1+number of newlines seen
+	lineStart int              // position of the first byte of the current line
 	tokens    chan token.Token // channel of scanned tokens
 	mode      mode
 	modeStack []mode
 	abort     bool
 	docLabel  string
+
+	// HashBracketComments makes `#[` start a comment like before PHP 8.0
+	// rather than an attribute, it is to be set before Run.
+	HashBracketComments bool
 }
 
 // New initializes a new lexer with input string
@@ -70,6 +75,13 @@ func New(input string) *Lexer {
 	return l
 }
 
+// NewScript initializes a lexer for PHP code without the opening tag
+func NewScript(input string) *Lexer {
+	l := New(input)
+	l.mode = modeInScript
+	return l
+}
+
 // Run runs the state machine for the lexer.
 func (l *Lexer) Run() {
 	for {
@@ -85,11 +97,7 @@ func (l *Lexer) Run() {
 
 // NextToken makes lexer tokenize next character(s)
 func (l *Lexer) NextToken() token.Token {
-	tok := <-l.tokens
-	if tok.Type == token.End || tok.Type == token.Error {
-		l.abort = true
-	}
-	return tok
+	return <-l.tokens
 }
 
 func (l *Lexer) next() rune {
@@ -135,8 +143,15 @@ func (l *Lexer) peekN(n int) rune {
 }
 
 func (l *Lexer) emit(t token.Type) *Lexer {
-	l.tokens <- token.Token{Line: l.line, Type: t, Literal: l.input[l.start:l.pos]}
-	l.line += strings.Count(l.input[l.start:l.pos], "\n")
+	lit := l.input[l.start:l.pos]
+	if t == token.End {
+		l.abort = true
+	}
+	l.tokens <- token.Token{Line: l.line, Column: l.start - l.lineStart + 1, Offset: l.start, Type: t, Literal: lit}
+	if n := strings.Count(lit, "\n"); n > 0 {
+		l.line += n
+		l.lineStart = l.start + strings.LastIndexByte(lit, '\n') + 1
+	}
 	l.start = l.pos
 	return l
 }
@@ -180,12 +195,44 @@ func (l *Lexer) skipWhitespace() {
 	for ch := l.next(); isSpace(ch); ch = l.next() {
 		if ch == '\n' {
 			l.line++
+			l.lineStart = l.pos
 		}
 	}
 	l.backup()
 	l.start = l.pos
 }
 
+// nextNonSpace returns the first rune after the whitespace following the
+// current position, without consuming anything.
+func (l *Lexer) nextNonSpace() rune {
+	rest := strings.TrimLeft(l.input[l.pos:], whiteSpace)
+	if rest == "" {
+		return eof
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return r
+}
+
+// atEnumName reports whether the `enum` just read starts an enum
+// declaration, that is it's followed by whitespace and a name other than
+// extends or implements, like in PHP's scanner.
+func (l *Lexer) atEnumName() bool {
+	rest := strings.TrimLeft(l.input[l.pos:], whiteSpace)
+	if len(rest) == len(l.input[l.pos:]) || rest == "" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	if !isLabelStart(r) {
+		return false
+	}
+	end := strings.IndexFunc(rest, func(r rune) bool { return !isLabel(r) })
+	if end < 0 {
+		end = len(rest)
+	}
+	name := strings.ToLower(rest[:end])
+	return name != "extends" && name != "implements"
+}
+
 func (l *Lexer) hasPrefix(prefix string) bool {
 	return strings.HasPrefix(l.input[l.pos:], prefix)
 }
@@ -193,13 +240,20 @@ func (l *Lexer) hasPrefix(prefix string) bool {
 // errorf returns an error token and terminates the scan by passing
 // back a nil pointer that will be the next state, terminating l.nextItem.
 func (l *Lexer) errorf(format string, args ...interface{}) stateFn {
-	l.tokens <- token.Token{Line: l.line, Type: token.Error, Literal: fmt.Sprintf(format, args...)}
+	l.abort = true
+	l.tokens <- token.Token{
+		Line:    l.line,
+		Column:  l.start - l.lineStart + 1,
+		Offset:  l.start,
+		Type:    token.Error,
+		Literal: fmt.Sprintf(format, args...),
+	}
 	return nil
 }
 
-// drain drains the output so the lexing goroutine will exit.
+// Drain drains the output so the lexing goroutine will exit.
 // Called by the parser, not in the lexing goroutine.
-func (l *Lexer) drain() {
+func (l *Lexer) Drain() {
 	for range l.tokens {
 	}
 }
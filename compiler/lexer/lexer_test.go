@@ -227,6 +227,118 @@ func Test_Backquote(t *testing.T) {
 	}
 }
 
+func Test_ClosingDocLabel(t *testing.T) {
+	tests := []struct {
+		script string
+		toks   []testToken
+	}{
+		{"<?php $a=<<<EOT\nx\nEOT;", []testToken{
+			{token.EncapsedAndWhitespace, "x\n", 2},
+			{token.EndHeredoc, "EOT", 3},
+			{token.Semicolon, ";", 3},
+			{token.End, "", 3},
+		}},
+		{"<?php $a=<<<EOT\nx\nEOT", []testToken{
+			{token.EncapsedAndWhitespace, "x\n", 2},
+			{token.EndHeredoc, "EOT", 3},
+			{token.End, "", 3},
+		}},
+		{"<?php $a=<<<EOT\nx\nEOT);", []testToken{
+			{token.EncapsedAndWhitespace, "x\n", 2},
+			{token.EndHeredoc, "EOT", 3},
+			{token.RParen, ")", 3},
+		}},
+		{"<?php $a=<<<EOT\nx\nEOT, 2];", []testToken{
+			{token.EncapsedAndWhitespace, "x\n", 2},
+			{token.EndHeredoc, "EOT", 3},
+			{token.Comma, ",", 3},
+		}},
+		{"<?php $a=<<<EOT\n  x\n   $b\n  EOT;\n", []testToken{
+			{token.EncapsedAndWhitespace, "  x\n   ", 2},
+			{token.Variable, "$b", 3},
+			{token.EncapsedAndWhitespace, "\n", 3},
+			{token.EndHeredoc, "  EOT", 4},
+			{token.Semicolon, ";", 4},
+		}},
+		{"<?php $a=<<<'EOT'\n\tx\n\tEOT;", []testToken{
+			{token.EncapsedAndWhitespace, "\tx\n", 2},
+			{token.EndHeredoc, "\tEOT", 3},
+			{token.Semicolon, ";", 3},
+		}},
+		{"<?php $a=<<<EOT\n  EOT;", []testToken{
+			{token.EndHeredoc, "  EOT", 2},
+			{token.Semicolon, ";", 2},
+		}},
+		{"<?php $a=<<<EOT\nEOTX\n EOT_\nEOT;", []testToken{
+			{token.EncapsedAndWhitespace, "EOTX\n EOT_\n", 2},
+			{token.EndHeredoc, "EOT", 4},
+			{token.Semicolon, ";", 4},
+		}},
+	}
+	for i, tt := range tests {
+		l := lex(tt.script)
+		for tok := l.NextToken(); tok.Type != token.StartHeredoc; tok = l.NextToken() {
+			if tok.Type == token.End || tok.Type == token.Error {
+				t.Fatalf("tests[%d] - expected a heredoc in %q", i, tt.script)
+			}
+		}
+		for j, tt := range tt.toks {
+			if err := compareToken(tt, l.NextToken()); err != nil {
+				t.Errorf("tests[%d] - tokens[%d] %s", i, j, err)
+				break
+			}
+		}
+	}
+}
+
+func Test_HashBracketComments(t *testing.T) {
+	l := New("<?php #[A] f();\n$a;")
+	l.HashBracketComments = true
+	go l.Run()
+	defer l.Drain()
+	toks := []testToken{
+		{token.OpenTag, "<?php ", 1},
+		{token.Comment, "#[A] f();\n", 1},
+		{token.Variable, "$a", 2},
+	}
+	for i, tt := range toks {
+		if err := compareToken(tt, l.NextToken()); err != nil {
+			t.Fatalf("tests[%d] - %s", i, err)
+		}
+	}
+}
+
+func Test_UnterminatedString(t *testing.T) {
+	for _, script := range []string{`<?php "abc $a`, "<?php `abc"} {
+		l := lex(script)
+		var tok token.Token
+		for i := 0; i < 10 && tok.Type != token.End; i++ {
+			tok = l.NextToken()
+		}
+		if tok.Type != token.End {
+			t.Errorf("expected End for %q, got %q", script, tok.Type)
+		}
+	}
+}
+
+func Test_NewScript(t *testing.T) {
+	l := NewScript("$a = 1;")
+	go l.Run()
+	defer l.Drain()
+	toks := []testToken{
+		{token.Variable, "$a", 1},
+		{token.Assign, "=", 1},
+		{token.Lnumber, "1", 1},
+		{token.Semicolon, ";", 1},
+		{token.End, "", 1},
+	}
+	for i, tt := range toks {
+		if err := compareToken(tt, l.NextToken()); err != nil {
+			t.Fatalf("tests[%d] - %s", i, err)
+		}
+	}
+}
+
 func Test_Unicode(t *testing.T) {
 	script := "<?php $🙂=`🚗🚴🚣🌺 $🎨$中国`; class 中国{ public $flag='🇨🇳' }"
 	toks := []testToken{
@@ -332,6 +444,71 @@ func Test_Number(t *testing.T) {
 	}
 }
 
+func Test_ContextualKeywords(t *testing.T) {
+	script := "<?php enum Suit {} enum extends; readonly(1); readonly int $a; match($a) {}"
+	toks := []testToken{
+		{token.OpenTag, "<?php ", 1},
+		{token.Enum, "enum", 1},
+		{token.String, "Suit", 1},
+		{token.LBrace, "{", 1},
+		{token.RBrace, "}", 1},
+		{token.String, "enum", 1},
+		{token.Extends, "extends", 1},
+		{token.Semicolon, ";", 1},
+		{token.String, "readonly", 1},
+		{token.LParen, "(", 1},
+		{token.Lnumber, "1", 1},
+		{token.RParen, ")", 1},
+		{token.Semicolon, ";", 1},
+		{token.Readonly, "readonly", 1},
+		{token.String, "int", 1},
+		{token.Variable, "$a", 1},
+		{token.Semicolon, ";", 1},
+		{token.Match, "match", 1},
+		{token.LParen, "(", 1},
+	}
+	l := lex(script)
+	for i, tt := range toks {
+		tok := l.NextToken()
+		if err := compareToken(tt, tok); err != nil {
+			fmt.Printf("%s\n", l)
+			t.Fatalf("tests[%d] - %s", i, err.Error())
+		}
+	}
+}
+
+func Test_TokenColumns(t *testing.T) {
+	script := "<?php $a = 1;\n\t$b = \"x\ny\";\n  foo();"
+	expected := []struct {
+		literal string
+		line    int
+		column  int
+		offset  int
+	}{
+		{"<?php ", 1, 1, 0},
+		{"$a", 1, 7, 6},
+		{"=", 1, 10, 9},
+		{"1", 1, 12, 11},
+		{";", 1, 13, 12},
+		{"$b", 2, 2, 15},
+		{"=", 2, 5, 18},
+		{"\"x\ny\"", 2, 7, 20},
+		{";", 3, 3, 25},
+		{"foo", 4, 3, 29},
+	}
+	l := lex(script)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		for tok.Type == token.Whitespace {
+			tok = l.NextToken()
+		}
+		if tok.Literal != tt.literal || tok.Line != tt.line || tok.Column != tt.column || tok.Offset != tt.offset {
+			t.Fatalf("tests[%d] - expected %q at %d:%d offset %d, got %q at %d:%d offset %d", i,
+				tt.literal, tt.line, tt.column, tt.offset, tok.Literal, tok.Line, tok.Column, tok.Offset)
+		}
+	}
+}
+
 func Benchmark_Test_Scripts(b *testing.B) {
 	buf, err := ioutil.ReadFile("../test-scripts/run-tests.php")
 	if err != nil {
@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"strings"
+
 	"github.com/eaglewu/luban/compiler/token"
 )
 
@@ -70,6 +72,19 @@ func lexInScript(l *Lexer) stateFn {
 		return nil
 	}
 
+	if l.hasPrefix("?->") {
+		l.pos += len("?->")
+		l.push(modeLookingForProperty)
+		l.emit(token.NullsafeObjectOperator)
+		return nil
+	}
+
+	if l.hasPrefix("#[") && !l.HashBracketComments {
+		l.pos += len("#[")
+		l.emit(token.Attribute)
+		return nil
+	}
+
 	if l.hasPrefix("#") || l.hasPrefix("//") {
 		return lexComment
 	}
@@ -302,7 +317,7 @@ func lexInScript(l *Lexer) stateFn {
 				typ = token.ArrayCast
 			} else if l.hasPrefix("object") {
 				l.pos += len("object")
-				typ = token.ObjectOperator
+				typ = token.ObjectCast
 			} else if l.hasPrefix("boolean") {
 				l.pos += len("boolean")
 				typ = token.BoolCast
@@ -311,7 +326,7 @@ func lexInScript(l *Lexer) stateFn {
 				typ = token.BoolCast
 			} else if l.hasPrefix("unset") {
 				l.pos += len("unset")
-				typ = token.Unset
+				typ = token.UnsetCast
 			} else {
 				goto LParen
 			}
@@ -397,9 +412,9 @@ func lexInScript(l *Lexer) stateFn {
 			l.pos++
 			if l.peek() == '=' {
 				l.pos++
-				l.emit(token.MulEqual)
-			} else {
 				l.emit(token.PowEqual)
+			} else {
+				l.emit(token.Pow)
 			}
 		} else {
 			l.emit(token.Asterisk)
@@ -504,7 +519,12 @@ func lexInScript(l *Lexer) stateFn {
 		l.pos++
 		if c := l.peek(); c == '?' {
 			l.pos++
-			l.emit(token.Coalesce)
+			if l.peek() == '=' {
+				l.pos++
+				l.emit(token.CoalesceEqual)
+			} else {
+				l.emit(token.Coalesce)
+			}
 		} else if c == '>' { // ?>
 			l.pos++
 			if c := l.peek(); isNewline(c) {
@@ -553,7 +573,14 @@ func lexInScript(l *Lexer) stateFn {
 				return lexInScript
 			}
 
-			l.emit(token.LookupIdent(ident))
+			typ := token.LookupIdent(ident)
+			switch {
+			case typ == token.Readonly && l.nextNonSpace() == '(': // readonly() is a function call
+				typ = token.String
+			case typ == token.String && strings.EqualFold(ident, "enum") && l.atEnumName():
+				typ = token.Enum
+			}
+			l.emit(typ)
 			return lexInScript
 		} else if isDigit(cur) {
 			l.readNumber()
@@ -581,7 +608,10 @@ func lexInScript(l *Lexer) stateFn {
 }
 
 func lexDoubleQuotes(l *Lexer) stateFn {
-
+	if l.pos >= len(l.input) { // unterminated string
+		l.emit(token.End).pop()
+		return nil
+	}
 	if l.peek() == '"' {
 		l.pos++
 		l.emit(token.DoubleQuotes).begin(modeInScript)
@@ -672,6 +702,13 @@ func lexLookingForProperty(l *Lexer) stateFn {
 			return lexLookingForProperty
 		}
 		fallthrough
+	case '?':
+		if l.hasPrefix("?->") {
+			l.pos += len("?->")
+			l.emit(token.NullsafeObjectOperator)
+			return lexLookingForProperty
+		}
+		fallthrough
 	default:
 		if isLabelStart(cur) {
 			l.acceptRunLabel()
@@ -797,6 +834,10 @@ func embeddedVariables(l *Lexer) bool {
 }
 
 func lexBackquote(l *Lexer) stateFn {
+	if l.pos >= len(l.input) { // unterminated string
+		l.emit(token.End).pop()
+		return nil
+	}
 	if l.peek() == '`' {
 		l.pos++
 		l.emit(token.Backquote).begin(modeInScript)
@@ -837,6 +878,9 @@ func lexHeredoc(l *Lexer) stateFn {
 		l.begin(modeInScript)
 		return nil
 	}
+	if emptyDoc(l) {
+		return nil
+	}
 	if embeddedVariables(l) {
 		return nil
 	}
@@ -849,18 +893,12 @@ func lexHeredoc(l *Lexer) stateFn {
 			}
 			fallthrough
 		case '\n':
-			if l.hasPrefix(l.docLabel) {
-				n, m := len(l.docLabel), 0
-				if c := l.peekN(n); c == ';' {
-					m++
-				}
-				if c := l.peekN(n + m); c == '\n' || c == '\r' {
-					l.emit(token.EncapsedAndWhitespace)
-					l.pos += n
-					l.emit(token.EndHeredoc)
-					l.begin(modeInScript)
-					return nil
-				}
+			if n := closingDocLabel(l); n > 0 {
+				l.emit(token.EncapsedAndWhitespace)
+				l.pos += n
+				l.emit(token.EndHeredoc)
+				l.begin(modeInScript)
+				return nil
 			}
 			continue
 		case '$':
@@ -887,6 +925,9 @@ func lexHeredoc(l *Lexer) stateFn {
 }
 
 func lexNowdoc(l *Lexer) stateFn {
+	if emptyDoc(l) {
+		return nil
+	}
 	r := l.next()
 	for ; r != eof; r = l.next() {
 		switch r {
@@ -896,17 +937,11 @@ func lexNowdoc(l *Lexer) stateFn {
 			}
 			fallthrough
 		case '\n':
-			if l.hasPrefix(l.docLabel) {
-				n, m := len(l.docLabel), 0
-				if c := l.peekN(n); c == ';' {
-					m++
-				}
-				if c := l.peekN(n + m); c == '\n' || c == '\r' {
-					l.emit(token.EncapsedAndWhitespace)
-					l.pos += n
-					l.emit(token.EndHeredoc)
-					break
-				}
+			if n := closingDocLabel(l); n > 0 {
+				l.emit(token.EncapsedAndWhitespace)
+				l.pos += n
+				l.emit(token.EndHeredoc)
+				break
 			}
 			fallthrough
 		default:
@@ -921,6 +956,40 @@ func lexNowdoc(l *Lexer) stateFn {
 	return nil
 }
 
+// closingDocLabel returns the length of the closing label of the current
+// heredoc or nowdoc starting at l.pos, 0 if there is none. The label may
+// be indented (PHP 7.3) and ends at any character that can't be part of
+// a label, the indentation is part of the EndHeredoc token.
+func closingDocLabel(l *Lexer) int {
+	n := 0
+	for c := l.peekN(n); c == ' ' || c == '\t'; c = l.peekN(n) {
+		n++
+	}
+	if !strings.HasPrefix(l.input[l.pos+n:], l.docLabel) {
+		return 0
+	}
+	n += len(l.docLabel)
+	if isLabel(l.peekN(n)) {
+		return 0
+	}
+	return n
+}
+
+// emptyDoc emits the closing label of a heredoc or nowdoc without content
+func emptyDoc(l *Lexer) bool {
+	if l.pos != l.start || l.pos == 0 || !isNewline(rune(l.input[l.pos-1])) {
+		return false
+	}
+	n := closingDocLabel(l)
+	if n == 0 {
+		return false
+	}
+	l.pos += n
+	l.emit(token.EndHeredoc)
+	l.begin(modeInScript)
+	return true
+}
+
 func isExpNumSufix(l *Lexer) (int, bool) {
 	if c1 := l.peek(); c1 == 'e' || c1 == 'E' {
 		p := 1
@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// parseArrayExpression parses `[...]` and `array(...)`. A short array
+// followed by `=` is the left side of a destructuring and is returned as
+// an *ast.ListExpression.
+func (p *Parser) parseArrayExpression() ast.Expression {
+	arr := &ast.ArrayExpression{BaseNode: p.newBaseNode(), IsShort: p.curTokenIs(token.LBracket)}
+	end := token.RBracket
+	if !arr.IsShort {
+		if !p.expectPeek(token.LParen) {
+			return nil
+		}
+		end = token.RParen
+	}
+	if arr.Items = p.parseArrayItems(end); arr.Items == nil {
+		return nil
+	}
+	if p.peekTokenIs(token.Assign) {
+		return p.convertToList(arr)
+	}
+	if p.arrayDepth == 0 && !p.checkArrayItems(arr) {
+		return nil
+	}
+	return arr
+}
+
+// parseListExpression parses a destructuring `list(...)` or `[...]`,
+// as used in foreach or nested in another list.
+func (p *Parser) parseListExpression() ast.Expression {
+	list := &ast.ListExpression{BaseNode: p.newBaseNode(), IsShort: p.curTokenIs(token.LBracket)}
+	end := token.RBracket
+	if !list.IsShort {
+		if !p.expectPeek(token.LParen) {
+			return nil
+		}
+		end = token.RParen
+	}
+	if list.Items = p.parseArrayItems(end); list.Items == nil || !p.checkListItems(list) {
+		return nil
+	}
+	return list
+}
+
+// parseListAssignment parses `list(...) = expr`, a list can't be used as
+// a standalone expression.
+func (p *Parser) parseListAssignment() ast.Expression {
+	list := p.parseListExpression()
+	if list == nil {
+		return nil
+	}
+	if !p.peekTokenIs(token.Assign) {
+		p.peekError(token.Assign)
+		return nil
+	}
+	return list
+}
+
+// parseArrayItems parses the items of an array until end, curToken is
+// the opening token and it's end on return. Empty slots are nil items.
+func (p *Parser) parseArrayItems(end token.Type) []*ast.ArrayItem {
+	p.arrayDepth++
+	defer func() { p.arrayDepth-- }()
+
+	items := []*ast.ArrayItem{}
+	for !p.peekTokenIs(end) {
+		p.nextToken()
+		if p.curTokenIs(token.Comma) {
+			items = append(items, nil)
+			continue
+		}
+		item := p.parseArrayItem()
+		if item == nil {
+			return nil
+		}
+		p.finish(item, item.Token)
+		items = append(items, item)
+		if !p.peekTokenIs(end) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	p.nextToken()
+	return items
+}
+
+// parseArrayItem parses `[key =>] [&]value`, `...value` and the nested
+// `[key =>] list(...)` of a destructuring.
+func (p *Parser) parseArrayItem() *ast.ArrayItem {
+	item := &ast.ArrayItem{BaseNode: p.newBaseNode()}
+	if p.curTokenIs(token.Ellipsis) {
+		item.Unpack = true
+		p.nextToken()
+		if item.Value = p.parseExpression(precLowest); item.Value == nil {
+			return nil
+		}
+		return item
+	}
+
+	if !p.curTokenIs(token.Ampersand) && !p.curTokenIs(token.List) {
+		value := p.parseExpression(precLowest)
+		if value == nil {
+			return nil
+		}
+		if !p.peekTokenIs(token.DoubleArrow) {
+			item.Value = value
+			return item
+		}
+		item.Key = value
+		p.nextToken()
+		p.nextToken()
+	}
+
+	switch p.curToken.Type {
+	case token.Ampersand:
+		item.ByRef = true
+		p.nextToken()
+		item.Value = p.parseExpression(precLowest)
+	case token.List:
+		item.Value = p.parseListExpression()
+	default:
+		item.Value = p.parseExpression(precLowest)
+	}
+	if item.Value == nil {
+		return nil
+	}
+	return item
+}
+
+// convertToList turns an array on the left side of a destructuring into a
+// list, nested arrays are converted as well.
+func (p *Parser) convertToList(arr *ast.ArrayExpression) ast.Expression {
+	if !arr.IsShort {
+		p.errorf(SyntaxError, "Cannot assign to array(), use [] instead")
+		return nil
+	}
+	list := &ast.ListExpression{BaseNode: arr.BaseNode, Items: arr.Items, IsShort: true}
+	if !p.checkListItems(list) {
+		return nil
+	}
+	return list
+}
+
+func (p *Parser) checkListItems(list *ast.ListExpression) bool {
+	empty, keyed, unkeyed := true, false, false
+	for _, item := range list.Items {
+		if item == nil {
+			continue
+		}
+		empty = false
+		if item.Unpack {
+			p.errorf(SyntaxError, "Spread operator is not supported in assignments")
+			return false
+		}
+		if item.Key != nil {
+			keyed = true
+		} else {
+			unkeyed = true
+		}
+		if arr, ok := item.Value.(*ast.ArrayExpression); ok {
+			if item.Value = p.convertToList(arr); item.Value == nil {
+				return false
+			}
+		}
+	}
+	if empty {
+		p.errorf(SyntaxError, "Cannot use empty list")
+		return false
+	}
+	if keyed && unkeyed {
+		p.errorf(SyntaxError, "Cannot mix keyed and unkeyed array entries in assignments")
+		return false
+	}
+	return true
+}
+
+// checkArrayItems reports the empty slots and nested lists that are only
+// allowed in a destructuring.
+func (p *Parser) checkArrayItems(arr *ast.ArrayExpression) bool {
+	for _, item := range arr.Items {
+		if item == nil {
+			p.errorf(SyntaxError, "Cannot use empty array elements in arrays")
+			return false
+		}
+		switch value := item.Value.(type) {
+		case *ast.ListExpression:
+			p.errorf(SyntaxError, "Cannot use list() as standalone expression")
+			return false
+		case *ast.ArrayExpression:
+			if !p.checkArrayItems(value) {
+				return false
+			}
+		}
+	}
+	return true
+}
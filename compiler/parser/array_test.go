@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_ArrayExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`<?php [];`, "[]"},
+		{`<?php array();`, "array()"},
+		{`<?php [1, 2,];`, "[1, 2]"},
+		{`<?php array($k => $v, &$r, ...$rest);`, "array($k => $v, &$r, ...$rest)"},
+		{`<?php [$k => &$r, [1, [2]]];`, "[$k => &$r, [1, [2]]]"},
+		{`<?php [$a, [$b] = $c];`, "[$a, ([$b] = $c)]"},
+	}
+	for i, tt := range tests {
+		program := parse(t, tt.input)
+		exp, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ArrayExpression)
+		if !ok {
+			t.Fatalf("tests[%d] - expected *ast.ArrayExpression, got %s", i, program.Statements[0])
+		}
+		if exp.String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, exp.String())
+		}
+	}
+
+	program := parse(t, `<?php array($k => &$v, ...$rest);`)
+	items := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ArrayExpression).Items
+	if items[0].Key == nil || !items[0].ByRef || items[0].Unpack {
+		t.Errorf("expected keyed by-ref item, got %s", items[0])
+	}
+	if items[1].Key != nil || !items[1].Unpack {
+		t.Errorf("expected unpacked item, got %s", items[1])
+	}
+}
+
+func Test_ListAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`<?php list($a, , $b) = $c;`, "(list($a, , $b) = $c)"},
+		{`<?php [, $a, [$b, list($c)]] = $d;`, "([, $a, [$b, list($c)]] = $d)"},
+		{`<?php ['x' => $a, $k => [&$b]] = $c;`, `(['x' => $a, $k => [&$b]] = $c)`},
+		{`<?php [$a, $b] = [$b, $a];`, "([$a, $b] = [$b, $a])"},
+		{`<?php $a = [$b] = $c;`, "($a = ([$b] = $c))"},
+	}
+	for i, tt := range tests {
+		program := parse(t, tt.input)
+		exp, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("tests[%d] - expected *ast.AssignExpression, got %s", i, program.Statements[0])
+		}
+		if exp.String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, exp.String())
+		}
+	}
+
+	program := parse(t, `<?php [$a, [$b]] = $c;`)
+	list, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression).Left.(*ast.ListExpression)
+	if !ok || !list.IsShort {
+		t.Fatalf("expected short list on the left side, got %s", program.Statements[0])
+	}
+	if _, ok := list.Items[1].Value.(*ast.ListExpression); !ok {
+		t.Errorf("expected nested list, got %T", list.Items[1].Value)
+	}
+}
+
+func Test_ArrayErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`<?php [1, , 2];`, "Cannot use empty array elements in arrays"},
+		{`<?php [[, 1]];`, "Cannot use empty array elements in arrays"},
+		{`<?php [list($a)];`, "Cannot use list() as standalone expression"},
+		{`<?php list($a);`, "unexpected token \";\", expecting \"=\""},
+		{`<?php [] = $a;`, "Cannot use empty list"},
+		{`<?php list(,) = $a;`, "Cannot use empty list"},
+		{`<?php [...$a] = $b;`, "Spread operator is not supported in assignments"},
+		{`<?php ['a' => $a, $b] = $c;`, "Cannot mix keyed and unkeyed array entries in assignments"},
+		{`<?php array($a) = $b;`, "Cannot assign to array(), use [] instead"},
+		{`<?php [$a, array($b)] = $c;`, "Cannot assign to array(), use [] instead"},
+		{`<?php [1 2];`, "unexpected integer \"2\""},
+	}
+	for i, tt := range tests {
+		err := parseError(t, tt.input)
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %q", i, tt.message, err.Message)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// compoundOperators maps the compound assignment tokens to their operator
+var compoundOperators = map[token.Type]string{
+	token.PlusEqual:     "+",
+	token.MinusEqual:    "-",
+	token.MulEqual:      "*",
+	token.DivEqual:      "/",
+	token.ConcatEqual:   ".",
+	token.ModEqual:      "%",
+	token.AndEqual:      "&",
+	token.OrEqual:       "|",
+	token.XorEqual:      "^",
+	token.SlEqual:       "<<",
+	token.SrEqual:       ">>",
+	token.PowEqual:      "**",
+	token.CoalesceEqual: "??",
+}
+
+// peekAssignsTo reports whether peekToken is an assignment to left. Like
+// in PHP's grammar, an assignment binds to the variable on its left
+// whatever the operator before it, so `!$a = f()` is `!($a = f())`.
+func (p *Parser) peekAssignsTo(left ast.Expression) bool {
+	if _, ok := compoundOperators[p.peekToken.Type]; !ok && !p.peekTokenIs(token.Assign) {
+		return false
+	}
+	if _, ok := left.(*ast.ListExpression); ok {
+		return true
+	}
+	return isVariable(left)
+}
+
+// isVariable reports whether exp is a variable in the sense of PHP's
+// grammar, calls included.
+func isVariable(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.Variable, *ast.DynamicVariable, *ast.IndexExpression, *ast.PropertyFetchExpression,
+		*ast.StaticPropertyFetchExpression,
+		*ast.CallExpression, *ast.MethodCallExpression, *ast.StaticCallExpression:
+		return true
+	}
+	return false
+}
+
+// parseAssignExpression parses `left = right` and `left = &right`,
+// assignment is right associative.
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	base := p.newBaseNode()
+	if !p.checkWritable(left) {
+		return nil
+	}
+	if p.peekTokenIs(token.Ampersand) {
+		p.nextToken()
+		exp := &ast.AssignRefExpression{BaseNode: base, Left: left}
+		p.nextToken()
+		if exp.Right = p.parseExpression(precAssign - 1); exp.Right == nil || !p.checkReferencable(exp.Right) {
+			return nil
+		}
+		return exp
+	}
+	exp := &ast.AssignExpression{BaseNode: base, Left: left}
+	p.nextToken()
+	if exp.Right = p.parseExpression(precAssign - 1); exp.Right == nil {
+		return nil
+	}
+	return exp
+}
+
+// parseCompoundAssignExpression parses `left op= right`
+func (p *Parser) parseCompoundAssignExpression(left ast.Expression) ast.Expression {
+	exp := &ast.CompoundAssignExpression{BaseNode: p.newBaseNode(), Left: left, Operator: compoundOperators[p.curToken.Type]}
+	if _, ok := left.(*ast.ListExpression); ok || !p.checkWritable(left) {
+		if p.error == nil {
+			p.errorf(InvalidAssignmentError, "Cannot use list() with compound assignment")
+		}
+		return nil
+	}
+	p.nextToken()
+	if exp.Right = p.parseExpression(precAssign - 1); exp.Right == nil {
+		return nil
+	}
+	return exp
+}
+
+// checkWritable reports an error unless exp can be assigned to, the
+// messages are the ones of PHP's compiler.
+func (p *Parser) checkWritable(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.Variable:
+		if exp.Name == "this" {
+			p.errorf(InvalidAssignmentError, "Cannot re-assign $this")
+			return false
+		}
+		return true
+	case *ast.DynamicVariable, *ast.StaticPropertyFetchExpression:
+		return true
+	case *ast.IndexExpression:
+		return p.checkWriteContainer(exp.Left)
+	case *ast.PropertyFetchExpression:
+		if exp.Nullsafe {
+			p.errorf(InvalidAssignmentError, "Can't use nullsafe operator in write context")
+			return false
+		}
+		return p.checkNullsafeChain(exp.Object)
+	case *ast.ListExpression:
+		for _, item := range exp.Items {
+			if item != nil && !p.checkWritable(item.Value) {
+				return false
+			}
+		}
+		return true
+	case *ast.CallExpression:
+		p.errorf(InvalidAssignmentError, "Can't use function return value in write context")
+		return false
+	case *ast.MethodCallExpression, *ast.StaticCallExpression:
+		p.errorf(InvalidAssignmentError, "Can't use method return value in write context")
+		return false
+	}
+	p.errorf(InvalidAssignmentError, "Assignments can only happen to writable values")
+	return false
+}
+
+// checkWriteContainer checks the left side of a dimension being written,
+// like `$a` in `$a[0] = 1`.
+func (p *Parser) checkWriteContainer(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.Variable, *ast.DynamicVariable, *ast.StaticPropertyFetchExpression:
+		return true
+	case *ast.IndexExpression:
+		return p.checkWriteContainer(exp.Left)
+	case *ast.PropertyFetchExpression:
+		return p.checkWritable(exp)
+	case *ast.CallExpression:
+		p.errorf(InvalidAssignmentError, "Can't use function return value in write context")
+		return false
+	case *ast.MethodCallExpression, *ast.StaticCallExpression:
+		p.errorf(InvalidAssignmentError, "Can't use method return value in write context")
+		return false
+	}
+	p.errorf(InvalidAssignmentError, "Cannot use temporary expression in write context")
+	return false
+}
+
+// checkNullsafeChain reports a nullsafe operator in the object chain of
+// a property being written.
+func (p *Parser) checkNullsafeChain(exp ast.Expression) bool {
+	for {
+		switch e := exp.(type) {
+		case *ast.PropertyFetchExpression:
+			if e.Nullsafe {
+				p.errorf(InvalidAssignmentError, "Can't use nullsafe operator in write context")
+				return false
+			}
+			exp = e.Object
+		case *ast.MethodCallExpression:
+			if e.Nullsafe {
+				p.errorf(InvalidAssignmentError, "Can't use nullsafe operator in write context")
+				return false
+			}
+			exp = e.Object
+		case *ast.IndexExpression:
+			exp = e.Left
+		default:
+			return true
+		}
+	}
+}
+
+// checkReferencable checks the right side of `$a = &expr`
+func (p *Parser) checkReferencable(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.Variable, *ast.DynamicVariable, *ast.StaticPropertyFetchExpression,
+		*ast.CallExpression, *ast.StaticCallExpression:
+		return true
+	case *ast.IndexExpression:
+		return p.checkReferencable(exp.Left)
+	case *ast.PropertyFetchExpression:
+		if exp.Nullsafe {
+			p.errorf(InvalidAssignmentError, "Cannot take reference of a nullsafe chain")
+			return false
+		}
+		return p.checkReferencable(exp.Object)
+	case *ast.MethodCallExpression:
+		if exp.Nullsafe {
+			p.errorf(InvalidAssignmentError, "Cannot take reference of a nullsafe chain")
+			return false
+		}
+		return true
+	}
+	p.errorf(InvalidAssignmentError, "Cannot assign reference to non referencable value")
+	return false
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_AssignExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`$a = $b = 1;`, `($a = ($b = 1))`},
+		{`$a = &$b;`, `($a = &$b)`},
+		{`$a = &$b->c[0];`, `($a = &$b->c[0])`},
+		{`$a = &foo();`, `($a = &foo())`},
+		{`$a += 1;`, `($a += 1)`},
+		{`$a .= $b . $c;`, `($a .= ($b . $c))`},
+		{`$a ??= [];`, `($a ??= [])`},
+		{`$a **= 2;`, `($a **= 2)`},
+		{`$a = $b += 1;`, `($a = ($b += 1))`},
+		{`!$a = 1;`, `(!($a = 1))`},
+		{`@$a = $b;`, `(@($a = $b))`},
+		{`$a + $b = 1;`, `($a + ($b = 1))`},
+		{`$a->b[0] = 1;`, `($a->b[0] = 1)`},
+		{`A::$b = 1;`, `(A::$b = 1)`},
+		{`[$a, $b] = $c;`, `([$a, $b] = $c)`},
+	}
+	for _, tt := range tests {
+		program := parse(t, "<?php "+tt.input)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func Test_InvalidAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 = $a;", "Assignments can only happen to writable values"},
+		{"$a + 1 += 2;", "Assignments can only happen to writable values"},
+		{"\n\nfoo() = 1;", "Can't use function return value in write context in php shell code on line 3"},
+		{"$a->b() = 1;", "Can't use method return value in write context"},
+		{"A::b() .= 1;", "Can't use method return value in write context"},
+		{"foo()[0] = 1;", "Can't use function return value in write context"},
+		{"[1][0] = 1;", "Cannot use temporary expression in write context"},
+		{"$this = 1;", "Cannot re-assign $this"},
+		{"$this .= 1;", "Cannot re-assign $this"},
+		{"[$this] = $a;", "Cannot re-assign $this"},
+		{"$this++;", "Cannot re-assign $this"},
+		{"--$this;", "Cannot re-assign $this"},
+		{"foo()--;", "Can't use function return value in write context"},
+		{"++$a->b();", "Can't use method return value in write context"},
+		{"$a?->b++;", "Can't use nullsafe operator in write context"},
+		{"foreach ($a as $this) {}", "Cannot re-assign $this"},
+		{"foreach ($a as $this => $v) {}", "Cannot re-assign $this"},
+		{"foreach ($a as [$b, $this]) {}", "Cannot re-assign $this"},
+		{"foreach ($a as foo()) {}", "Can't use function return value in write context"},
+		{"try {} catch (E $this) {}", "Cannot re-assign $this"},
+		{"$a?->b = 1;", "Can't use nullsafe operator in write context"},
+		{"$a?->b->c = 1;", "Can't use nullsafe operator in write context"},
+		{"$a = &1;", "Cannot assign reference to non referencable value"},
+		{"$a = &$b?->c;", "Cannot take reference of a nullsafe chain"},
+	}
+	for _, tt := range tests {
+		err := parseError(t, "<?php "+tt.input)
+		if err.Kind != InvalidAssignmentError {
+			t.Errorf("%q: expected an invalid assignment error, got %q", tt.input, err.Message)
+		}
+		if !strings.Contains(err.Message, tt.expected) {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+
+}
@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// parseAttributes parses the attribute groups `#[A, B(args)] #[C]`
+// starting at curToken, on return curToken is the first token following
+// them.
+func (p *Parser) parseAttributes() []*ast.AttributeGroup {
+	groups := []*ast.AttributeGroup{}
+	for p.curTokenIs(token.Attribute) {
+		if !p.requireVersion(800, "attributes") {
+			return nil
+		}
+		group := &ast.AttributeGroup{BaseNode: p.newBaseNode()}
+		for len(group.Attributes) == 0 || !p.peekTokenIs(token.RBracket) {
+			p.nextToken()
+			from := p.curToken
+			attr := p.parseAttribute()
+			if attr == nil {
+				return nil
+			}
+			p.finish(attr, from)
+			group.Attributes = append(group.Attributes, attr)
+			if !p.peekTokenIs(token.RBracket) && !p.expectPeek(token.Comma) {
+				return nil
+			}
+		}
+		p.nextToken()
+		p.finish(group, group.Token)
+		groups = append(groups, group)
+		p.nextToken()
+	}
+	return groups
+}
+
+// parseAttribute parses `Name` or `Name(args)` in an attribute group
+func (p *Parser) parseAttribute() *ast.Attribute {
+	attr := &ast.Attribute{BaseNode: p.newBaseNode()}
+	switch p.curToken.Type {
+	case token.String, token.NsSeparator, token.Namespace:
+	default:
+		p.unexpectedError()
+		return nil
+	}
+	if attr.Name = p.parseName(); attr.Name == "" {
+		return nil
+	}
+	if !p.peekTokenIs(token.LParen) {
+		return attr
+	}
+	p.nextToken()
+	var callable bool
+	if attr.Arguments, callable = p.parseArguments(); attr.Arguments == nil {
+		return nil
+	}
+	if callable {
+		p.errorf(SyntaxError, "Cannot create Closure as attribute argument")
+		return nil
+	}
+	for _, arg := range attr.Arguments {
+		if arg.Unpack {
+			p.errorf(SyntaxError, "Cannot use unpacking in attribute argument list")
+			return nil
+		}
+	}
+	return attr
+}
+
+// parseAttributedStatement parses a function, class, interface, trait or
+// enum declaration preceded by attributes, or an expression statement starting
+// with an attributed closure.
+func (p *Parser) parseAttributedStatement() ast.Statement {
+	attrs := p.parseAttributes()
+	if attrs == nil {
+		return nil
+	}
+	switch p.curToken.Type {
+	case token.Function:
+		if p.peekTokenIs(token.String) || p.peekTokenIs(token.Ampersand) {
+			return p.parseFunctionStatement(attrs)
+		}
+	case token.Abstract, token.Final, token.Class:
+		if stmt, ok := p.parseClassStatement().(*ast.ClassStatement); ok {
+			stmt.Attributes = attrs
+			return stmt
+		}
+		return nil
+	case token.Interface:
+		if stmt, ok := p.parseInterfaceStatement().(*ast.InterfaceStatement); ok {
+			stmt.Attributes = attrs
+			return stmt
+		}
+		return nil
+	case token.Trait:
+		if stmt, ok := p.parseTraitStatement().(*ast.TraitStatement); ok {
+			stmt.Attributes = attrs
+			return stmt
+		}
+		return nil
+	case token.Enum:
+		if stmt, ok := p.parseEnumStatement().(*ast.EnumStatement); ok {
+			stmt.Attributes = attrs
+			return stmt
+		}
+		return nil
+	}
+
+	stmt := &ast.ExpressionStatement{BaseNode: p.newBaseNode()}
+	closure := p.parseAttributedClosure(attrs)
+	if closure == nil {
+		return nil
+	}
+	p.finish(closure, attrs[0].Token)
+	if stmt.Expression = p.parseInfixExpressions(closure, attrs[0].Token, precLowest); stmt.Expression == nil {
+		return nil
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	stmt.Expression.MarkAsStmt()
+	return stmt
+}
+
+// parseAttributedExpression parses a closure or an arrow function preceded
+// by attributes, curToken is `#[`.
+func (p *Parser) parseAttributedExpression() ast.Expression {
+	attrs := p.parseAttributes()
+	if attrs == nil {
+		return nil
+	}
+	return p.parseAttributedClosure(attrs)
+}
+
+// parseAttributedClosure parses the closure or arrow function following
+// attrs, the only expressions attributes can be applied to.
+func (p *Parser) parseAttributedClosure(attrs []*ast.AttributeGroup) ast.Expression {
+	var exp ast.Expression
+	switch p.curToken.Type {
+	case token.Function:
+		exp = p.parseClosureExpression()
+	case token.Fn:
+		exp = p.parseArrowFunctionExpression()
+	case token.Static:
+		if !p.peekTokenIs(token.Function) && !p.peekTokenIs(token.Fn) {
+			p.nextToken()
+			p.unexpectedError()
+			return nil
+		}
+		exp = p.parseStaticExpression()
+	default:
+		p.unexpectedError()
+		return nil
+	}
+	switch e := exp.(type) {
+	case *ast.ClosureExpression:
+		e.Attributes = attrs
+	case *ast.ArrowFunctionExpression:
+		e.Attributes = attrs
+	}
+	return exp
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_Attributes(t *testing.T) {
+	program := parse(t, `<?php
+#[Route('/users', methods: ['GET']), \Foo\Bar]
+#[Deprecated]
+function users(#[SensitiveParameter] $password) { }
+
+#[ORM\Entity(repositoryClass: UserRepository::class)]
+final class User {
+	#[ORM\Id, ORM\Column(type: 'integer')]
+	private $id;
+	#[Override]
+	public function __toString(): string { }
+	#[Flag]
+	const A = 1;
+}
+
+#[A] interface I { }
+#[A] trait T { }
+$f = #[Pure] fn($x) => $x;
+#[Pure] static function () { };
+$o = new #[A] class { };
+`)
+	fn := program.Statements[0].(*ast.FunctionStatement)
+	if len(fn.Attributes) != 2 || len(fn.Attributes[0].Attributes) != 2 {
+		t.Fatalf("expected 2 attribute groups on function, got %v", fn.Attributes)
+	}
+	route := fn.Attributes[0].Attributes[0]
+	if route.Name != "Route" || len(route.Arguments) != 2 || route.Arguments[1].Name != "methods" {
+		t.Errorf("unexpected Route attribute %s", route)
+	}
+	if name := fn.Attributes[0].Attributes[1].Name; name != "\\Foo\\Bar" {
+		t.Errorf("expected \\Foo\\Bar, got %s", name)
+	}
+	if fn.Attributes[1].String() != "#[Deprecated]" {
+		t.Errorf("expected #[Deprecated], got %s", fn.Attributes[1])
+	}
+	if param := fn.Parameters[0]; param.String() != "#[SensitiveParameter] $password" {
+		t.Errorf("unexpected parameter %s", param)
+	}
+
+	class := program.Statements[1].(*ast.ClassStatement)
+	if len(class.Attributes) != 1 || class.Attributes[0].String() != "#[ORM\\Entity(repositoryClass: UserRepository::class)]" {
+		t.Errorf("unexpected class attributes %v", class.Attributes)
+	}
+	members := class.Body.Statements
+	if prop := members[0].(*ast.PropertyStatement); len(prop.Attributes[0].Attributes) != 2 {
+		t.Errorf("expected 2 attributes on property, got %s", prop)
+	}
+	if method := members[1].(*ast.MethodStatement); method.Attributes[0].Attributes[0].Name != "Override" {
+		t.Errorf("expected Override attribute, got %s", method)
+	}
+	if c := members[2].(*ast.ClassConstStatement); c.String() != "#[Flag] const A = 1;" {
+		t.Errorf("unexpected constant %s", c)
+	}
+
+	if i := program.Statements[2].(*ast.InterfaceStatement); len(i.Attributes) != 1 {
+		t.Errorf("expected attribute on interface")
+	}
+	if tr := program.Statements[3].(*ast.TraitStatement); len(tr.Attributes) != 1 {
+		t.Errorf("expected attribute on trait")
+	}
+	if s := program.Statements[4].String(); s != "($f = #[Pure] fn($x) => $x)" {
+		t.Errorf("unexpected arrow function %s", s)
+	}
+	closure := program.Statements[5].(*ast.ExpressionStatement).Expression.(*ast.ClosureExpression)
+	if !closure.Static || closure.Attributes[0].String() != "#[Pure]" {
+		t.Errorf("unexpected closure %s", closure)
+	}
+	assign := program.Statements[6].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression)
+	if n := assign.Right.(*ast.NewExpression); len(n.AnonymousClass.Attributes) != 1 {
+		t.Errorf("expected attribute on anonymous class, got %s", n)
+	}
+}
+
+func Test_AttributeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"#[A] $a = 1;", "unexpected variable"},
+		{"#[A(...$b)] function f() {}", "Cannot use unpacking in attribute argument list"},
+		{"#[] function f() {}", "unexpected token \"]\""},
+		{"#[A function f() {}", "unexpected token \"function\", expecting \",\""},
+		{"class C { #[A] use T; }", "unexpected token \"use\""},
+		{"$a = #[A] static::f();", "unexpected token \"::\""},
+	}
+	for _, tt := range tests {
+		err := parseError(t, "<?php "+tt.input)
+		if !strings.Contains(err.Message, tt.expected) {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
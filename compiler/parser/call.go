@@ -0,0 +1,408 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// parseArguments parses the arguments of a call, curToken is `(` and it's
+// `)` on return. It reports true for the first-class callable syntax
+// `(...)`, which has no arguments.
+func (p *Parser) parseArguments() ([]*ast.Argument, bool) {
+	args := []*ast.Argument{}
+	named, unpacked := false, false
+	seen := map[string]bool{}
+	for !p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		arg := &ast.Argument{BaseNode: p.newBaseNode()}
+		switch {
+		case p.curTokenIs(token.Ellipsis) && len(args) == 0 && p.peekTokenIs(token.RParen):
+			if !p.requireVersion(801, "first-class callable syntax") {
+				return nil, false
+			}
+			p.nextToken()
+			return args, true
+		case p.curTokenIs(token.Ellipsis):
+			if named {
+				p.errorf(SyntaxError, "Cannot use argument unpacking after named arguments")
+				return nil, false
+			}
+			arg.Unpack, unpacked = true, true
+			p.nextToken()
+		case p.curTokenIsIdentifier() && p.peekTokenIs(token.Colon):
+			if !p.requireVersion(800, "named arguments") {
+				return nil, false
+			}
+			arg.Name, named = p.curToken.Literal, true
+			if seen[arg.Name] {
+				p.errorf(SyntaxError, "Duplicate named parameter $%s", arg.Name)
+				return nil, false
+			}
+			seen[arg.Name] = true
+			p.nextToken()
+			p.nextToken()
+		case named:
+			p.errorf(SyntaxError, "Cannot use positional argument after named argument")
+			return nil, false
+		case unpacked:
+			p.errorf(SyntaxError, "Cannot use positional argument after argument unpacking")
+			return nil, false
+		}
+		if arg.Value = p.parseExpression(precLowest); arg.Value == nil {
+			return nil, false
+		}
+		p.finish(arg, arg.Token)
+		args = append(args, arg)
+		if !p.peekTokenIs(token.RParen) && !p.expectPeek(token.Comma) {
+			return nil, false
+		}
+	}
+	p.nextToken()
+	return args, false
+}
+
+// parseNewArguments parses the constructor arguments of a new expression
+func (p *Parser) parseNewArguments() []*ast.Argument {
+	args, callable := p.parseArguments()
+	if callable {
+		p.errorf(SyntaxError, "Cannot create Closure for new expression")
+		return nil
+	}
+	return args
+}
+
+// hasNullsafe reports whether the object chain of exp contains a nullsafe
+// operator.
+func hasNullsafe(exp ast.Expression) bool {
+	for {
+		switch e := exp.(type) {
+		case *ast.PropertyFetchExpression:
+			if e.Nullsafe {
+				return true
+			}
+			exp = e.Object
+		case *ast.MethodCallExpression:
+			if e.Nullsafe {
+				return true
+			}
+			exp = e.Object
+		case *ast.IndexExpression:
+			exp = e.Left
+		case *ast.CallExpression:
+			exp = e.Function
+		case *ast.StaticPropertyFetchExpression:
+			exp = e.Class
+		case *ast.StaticCallExpression:
+			exp = e.Class
+		default:
+			return false
+		}
+	}
+}
+
+// parseCallExpression parses `left(args)`
+func (p *Parser) parseCallExpression(left ast.Expression) ast.Expression {
+	exp := &ast.CallExpression{BaseNode: p.newBaseNode(), Function: left}
+	if exp.Arguments, exp.FirstClassCallable = p.parseArguments(); exp.Arguments == nil {
+		return nil
+	}
+	if exp.FirstClassCallable && hasNullsafe(left) {
+		p.errorf(SyntaxError, "Cannot combine nullsafe operator with Closure creation")
+		return nil
+	}
+	return exp
+}
+
+// parseIndexExpression parses `left[index]` and `left[]`
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{BaseNode: p.newBaseNode(), Left: left}
+	if p.peekTokenIs(token.RBracket) {
+		p.nextToken()
+		return exp
+	}
+	p.nextToken()
+	if exp.Index = p.parseExpression(precLowest); exp.Index == nil || !p.expectPeek(token.RBracket) {
+		return nil
+	}
+	return exp
+}
+
+// peekBraceIndex reports whether peekToken starts the `$s{0}` offset
+// removed in PHP 8.0, after a variable, a string, an array or a
+// parenthesized expression.
+func (p *Parser) peekBraceIndex(left ast.Expression) bool {
+	if !p.peekTokenIs(token.LBrace) || p.Mode.version() >= 800 {
+		return false
+	}
+	switch left.(type) {
+	case *ast.StringLiteral, *ast.InterpolatedStringExpression, *ast.ArrayExpression:
+		return true
+	}
+	return isVariable(left) || p.curTokenIs(token.RParen)
+}
+
+// parseBraceIndexExpression parses `left{index}`
+func (p *Parser) parseBraceIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{BaseNode: p.newBaseNode(), Left: left}
+	p.nextToken()
+	if exp.Index = p.parseExpression(precLowest); exp.Index == nil || !p.expectPeek(token.RBrace) {
+		return nil
+	}
+	return exp
+}
+
+// parseMemberName parses the name following `->`, `?->` or `::`, which
+// is an identifier, a variable or `{expr}`.
+func (p *Parser) parseMemberName() ast.Expression {
+	p.nextToken()
+	switch {
+	case p.curTokenIs(token.Variable):
+		return p.parseVariable()
+	case p.curTokenIs(token.LBrace):
+		p.nextToken()
+		name := p.parseExpression(precLowest)
+		if name == nil || !p.expectPeek(token.RBrace) {
+			return nil
+		}
+		return name
+	case p.curTokenIsIdentifier():
+		name := &ast.Identifier{BaseNode: p.newBaseNode(), Value: p.curToken.Literal}
+		p.finish(name, name.Token)
+		return name
+	}
+	p.unexpectedError()
+	return nil
+}
+
+// parseObjectMemberExpression parses `left->name`, `left->name(args)` and
+// their nullsafe `?->` forms.
+func (p *Parser) parseObjectMemberExpression(left ast.Expression) ast.Expression {
+	base, nullsafe := p.newBaseNode(), p.curTokenIs(token.NullsafeObjectOperator)
+	if nullsafe && !p.requireVersion(800, "the nullsafe operator") {
+		return nil
+	}
+	name := p.parseMemberName()
+	if name == nil {
+		return nil
+	}
+	if !p.peekTokenIs(token.LParen) {
+		return &ast.PropertyFetchExpression{BaseNode: base, Object: left, Property: name, Nullsafe: nullsafe}
+	}
+	p.nextToken()
+	exp := &ast.MethodCallExpression{BaseNode: base, Object: left, Method: name, Nullsafe: nullsafe}
+	if exp.Arguments, exp.FirstClassCallable = p.parseArguments(); exp.Arguments == nil {
+		return nil
+	}
+	if exp.FirstClassCallable && (nullsafe || hasNullsafe(left)) {
+		p.errorf(SyntaxError, "Cannot combine nullsafe operator with Closure creation")
+		return nil
+	}
+	return exp
+}
+
+// parseStaticMemberExpression parses what follows `left::`, a class
+// constant, `class`, a static property or a static method call.
+func (p *Parser) parseStaticMemberExpression(left ast.Expression) ast.Expression {
+	base := p.newBaseNode()
+	if p.peekTokenIs(token.Dollar) {
+		p.nextToken()
+		prop := p.parseDynamicVariable()
+		if prop == nil {
+			return nil
+		}
+		return &ast.StaticPropertyFetchExpression{BaseNode: base, Class: left, Property: prop}
+	}
+
+	braced := p.peekTokenIs(token.LBrace)
+	name := p.parseMemberName()
+	if name == nil {
+		return nil
+	}
+	if p.peekTokenIs(token.LParen) {
+		p.nextToken()
+		exp := &ast.StaticCallExpression{BaseNode: base, Class: left, Method: name}
+		if exp.Arguments, exp.FirstClassCallable = p.parseArguments(); exp.Arguments == nil {
+			return nil
+		}
+		if exp.FirstClassCallable && hasNullsafe(left) {
+			p.errorf(SyntaxError, "Cannot combine nullsafe operator with Closure creation")
+			return nil
+		}
+		return exp
+	}
+	if braced { // A::{expr} must be a call
+		p.peekError(token.LParen)
+		return nil
+	}
+	if v, ok := name.(*ast.Variable); ok {
+		return &ast.StaticPropertyFetchExpression{BaseNode: base, Class: left, Property: v}
+	}
+	constName := name.(*ast.Identifier).Value
+	if _, ok := left.(*ast.Constant); !ok && strings.EqualFold(constName, "class") && !p.requireVersion(800, "::class on objects") {
+		return nil
+	}
+	return &ast.ClassConstFetchExpression{BaseNode: base, Class: left, Name: constName}
+}
+
+// parseDynamicVariable parses `$$a` and `${expr}`, curToken is `$`
+func (p *Parser) parseDynamicVariable() ast.Expression {
+	exp := &ast.DynamicVariable{BaseNode: p.newBaseNode()}
+	p.nextToken()
+	switch p.curToken.Type {
+	case token.Variable:
+		exp.Name = p.parseVariable()
+	case token.Dollar:
+		exp.Name = p.parseDynamicVariable()
+	case token.LBrace:
+		p.nextToken()
+		if exp.Name = p.parseExpression(precLowest); exp.Name != nil && !p.expectPeek(token.RBrace) {
+			return nil
+		}
+	default:
+		p.unexpectedError()
+	}
+	if exp.Name == nil {
+		return nil
+	}
+	p.finish(exp, exp.Token)
+	return exp
+}
+
+// parseCloneExpression parses `clone expr`
+func (p *Parser) parseCloneExpression() ast.Expression {
+	exp := &ast.CloneExpression{BaseNode: p.newBaseNode()}
+	p.nextToken()
+	if exp.Expression = p.parseExpression(precClone); exp.Expression == nil {
+		return nil
+	}
+	return exp
+}
+
+// parseNewExpression parses `new Class[(args)]`, `new (expr)[(args)]` and
+// `new class[(args)] [extends A] [implements B] { members }`.
+func (p *Parser) parseNewExpression() ast.Expression {
+	exp := &ast.NewExpression{BaseNode: p.newBaseNode()}
+	p.nextToken()
+	switch p.curToken.Type {
+	case token.Class:
+		return p.parseAnonymousClass(exp)
+	case token.Attribute:
+		attrs := p.parseAttributes()
+		if attrs == nil {
+			return nil
+		}
+		if !p.curTokenIs(token.Class) {
+			p.unexpectedError()
+			return nil
+		}
+		if p.parseAnonymousClass(exp) == nil {
+			return nil
+		}
+		exp.AnonymousClass.Attributes = attrs
+		return exp
+	case token.Static:
+		exp.Class = &ast.Constant{BaseNode: p.newBaseNode(), Value: p.curToken.Literal}
+		p.finish(exp.Class, p.curToken)
+	case token.String, token.NsSeparator, token.Namespace:
+		tok := p.curToken
+		name := p.parseName()
+		if name == "" {
+			return nil
+		}
+		exp.Class = &ast.Constant{BaseNode: &ast.BaseNode{Token: tok}, Value: name, IsNamespace: strings.Contains(name, "\\")}
+		p.finish(exp.Class, tok)
+	case token.LParen:
+		exp.Class = p.parseGroupedExpression()
+	case token.Variable, token.Dollar:
+		exp.Class = p.parseNewClassReference()
+	default:
+		p.unexpectedError()
+		return nil
+	}
+	if exp.Class == nil {
+		return nil
+	}
+	if p.peekTokenIs(token.LParen) {
+		p.nextToken()
+		if exp.Arguments = p.parseNewArguments(); exp.Arguments == nil {
+			return nil
+		}
+	}
+	return exp
+}
+
+// parseNewClassReference parses the variable class name of `new $a`, which
+// can be followed by dimension and property fetches but not by calls.
+func (p *Parser) parseNewClassReference() ast.Expression {
+	from := p.curToken
+	var exp ast.Expression
+	if p.curTokenIs(token.Dollar) {
+		exp = p.parseDynamicVariable()
+	} else {
+		exp = p.parseVariable()
+	}
+	for exp != nil {
+		switch p.peekToken.Type {
+		case token.LBracket:
+			p.nextToken()
+			exp = p.parseIndexExpression(exp)
+			p.finish(exp, from)
+		case token.ObjectOperator, token.NullsafeObjectOperator:
+			p.nextToken()
+			prop := &ast.PropertyFetchExpression{BaseNode: p.newBaseNode(), Object: exp, Nullsafe: p.curTokenIs(token.NullsafeObjectOperator)}
+			if prop.Nullsafe && !p.requireVersion(800, "the nullsafe operator") {
+				return nil
+			}
+			if prop.Property = p.parseMemberName(); prop.Property == nil {
+				return nil
+			}
+			p.finish(prop, from)
+			exp = prop
+		case token.PaamayimNekudotayim:
+			p.nextToken()
+			prop := &ast.StaticPropertyFetchExpression{BaseNode: p.newBaseNode(), Class: exp}
+			p.nextToken()
+			switch p.curToken.Type {
+			case token.Variable:
+				prop.Property = p.parseVariable()
+			case token.Dollar:
+				prop.Property = p.parseDynamicVariable()
+			default:
+				p.unexpectedError()
+			}
+			if prop.Property == nil {
+				return nil
+			}
+			p.finish(prop, from)
+			exp = prop
+		default:
+			return exp
+		}
+	}
+	return nil
+}
+
+// parseAnonymousClass parses an anonymous class, curToken is `class`
+func (p *Parser) parseAnonymousClass(exp *ast.NewExpression) ast.Expression {
+	class := &ast.ClassStatement{BaseNode: p.newBaseNode()}
+	if p.peekTokenIs(token.LParen) {
+		p.nextToken()
+		if exp.Arguments = p.parseNewArguments(); exp.Arguments == nil {
+			return nil
+		}
+	}
+	if !p.parseClassHeader(class) || !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	if class.Body = p.parseClassBody("class@anonymous", classKindClass); class.Body == nil {
+		return nil
+	}
+	if !p.verifyAbstractClass("class@anonymous", class.Body) {
+		return nil
+	}
+	p.finish(class, class.Token)
+	exp.AnonymousClass = class
+	return exp
+}
@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_CallExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`<?php foo();`, "foo()"},
+		{`<?php \Foo\bar($a, ...$b);`, `\Foo\bar($a, ...$b)`},
+		{`<?php foo($a, b: $c, list: 1);`, "foo($a, b: $c, list: 1)"},
+		{`<?php $f($a)(1);`, "$f($a)(1)"},
+		{`<?php $a->b()->c;`, "$a->b()->c"},
+		{`<?php $a?->b?->c();`, "$a?->b?->c()"},
+		{`<?php $a->$b->{$c . $d}();`, "$a->$b->{($c . $d)}()"},
+		{`<?php $a->list->class;`, "$a->list->class"},
+		{`<?php A::b(1)::$c;`, "A::b(1)::$c"},
+		{`<?php static::$a::$$b;`, "static::$a::${$b}"},
+		{`<?php A::{$m . $n}();`, "A::{($m . $n)}()"},
+		{`<?php $c::$m();`, "$c::$m()"},
+		{`<?php \A\B::C;`, `\A\B::C`},
+		{`<?php self::list;`, "self::list"},
+		{`<?php $a[0][][$b];`, "$a[0][][$b]"},
+		{`<?php $$a[0];`, "${$a}[0]"},
+		{`<?php ${$a . $b};`, "${($a . $b)}"},
+		{`<?php -$a->b ** 2;`, "(-($a->b ** 2))"},
+		{`<?php !$a::b();`, "(!$a::b())"},
+		{`<?php clone $a->b;`, "clone $a->b"},
+		{`<?php strlen(...);`, "strlen(...)"},
+		{`<?php $a->b(...);`, "$a->b(...)"},
+		{`<?php A::b(...);`, "A::b(...)"},
+	}
+	for i, tt := range tests {
+		program := parse(t, tt.input)
+		if s := program.Statements[0].String(); s != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, s)
+		}
+	}
+
+	program := parse(t, `<?php $c::$m(); $a::class; foo(...$a, b: 1);`)
+	exps := []ast.Expression{}
+	for _, stmt := range program.Statements {
+		exps = append(exps, stmt.(*ast.ExpressionStatement).Expression)
+	}
+	if call, ok := exps[0].(*ast.StaticCallExpression); !ok {
+		t.Errorf("expected *ast.StaticCallExpression, got %T", exps[0])
+	} else if _, ok := call.Method.(*ast.Variable); !ok {
+		t.Errorf("expected variable method name, got %T", call.Method)
+	}
+	if c, ok := exps[1].(*ast.ClassConstFetchExpression); !ok || c.Name != "class" {
+		t.Errorf("expected ::class fetch, got %s", exps[1])
+	}
+	args := exps[2].(*ast.CallExpression).Arguments
+	if !args[0].Unpack || args[1].Name != "b" {
+		t.Errorf("unexpected arguments: %s", exps[2])
+	}
+}
+
+func Test_NewExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`<?php new Foo;`, "new Foo()"},
+		{`<?php new \Foo\Bar(1, ...$a);`, `new \Foo\Bar(1, ...$a)`},
+		{`<?php new static();`, "new static()"},
+		{`<?php new static;`, "new static()"},
+		{`<?php new self;`, "new self()"},
+		{`<?php new parent(1);`, "new parent(1)"},
+		{`<?php new $class($a);`, "new $class($a)"},
+		{`<?php new $a->b[$c]::$d();`, "new $a->b[$c]::$d()"},
+		{`<?php new ($a . $b);`, "new ($a . $b)()"},
+		{`<?php new class($a) extends B implements C { public $x; };`, "new class($a) extends B implements C {\npublic $x;\n}"},
+		{`<?php (new Foo)->bar();`, "new Foo()->bar()"},
+	}
+	for i, tt := range tests {
+		program := parse(t, tt.input)
+		if s := program.Statements[0].String(); s != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, s)
+		}
+	}
+
+	program := parse(t, `<?php new $a->b();`)
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.NewExpression)
+	if _, ok := exp.Class.(*ast.PropertyFetchExpression); !ok {
+		t.Errorf("expected property fetch as class name, got %T", exp.Class)
+	}
+}
+
+func Test_CallErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`<?php foo(a: 1, $b);`, "Cannot use positional argument after named argument"},
+		{`<?php foo(...$a, $b);`, "Cannot use positional argument after argument unpacking"},
+		{`<?php foo(a: 1, ...$b);`, "Cannot use argument unpacking after named arguments"},
+		{`<?php foo($a $b);`, "unexpected variable \"$b\""},
+		{`<?php A::{$m};`, "unexpected token \";\", expecting \"(\""},
+		{`<?php new 1;`, "unexpected integer"},
+		{`<?php new class { abstract function f(); };`, "Class class@anonymous contains 1 abstract method"},
+		{`<?php foo(a: 1, a: 2);`, "Duplicate named parameter $a"},
+		{`<?php new A(...);`, "Cannot create Closure for new expression"},
+		{`<?php $a?->b(...);`, "Cannot combine nullsafe operator with Closure creation"},
+		{`<?php foo(..., $a);`, "unexpected"},
+	}
+	for i, tt := range tests {
+		err := parseError(t, tt.input)
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %q", i, tt.message, err.Message)
+		}
+	}
+}
@@ -0,0 +1,691 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// classKind tells which class-like declaration a body belongs to, the
+// rules for members differ between them.
+type classKind int
+
+const (
+	classKindClass classKind = iota
+	classKindInterface
+	classKindTrait
+	classKindEnum
+)
+
+// reservedClassNames can't be used as the name of a class, interface or trait
+var reservedClassNames = map[string]bool{
+	"bool": true, "false": true, "float": true, "int": true, "null": true,
+	"parent": true, "self": true, "static": true, "string": true, "true": true,
+	"void": true, "iterable": true, "object": true, "mixed": true, "never": true,
+}
+
+var modifierTypes = map[token.Type]ast.Modifier{
+	token.Public:    ast.ModifierPublic,
+	token.Protected: ast.ModifierProtected,
+	token.Private:   ast.ModifierPrivate,
+	token.Static:    ast.ModifierStatic,
+	token.Abstract:  ast.ModifierAbstract,
+	token.Final:     ast.ModifierFinal,
+	token.Readonly:  ast.ModifierReadonly,
+}
+
+// curTokenIsIdentifier reports whether curToken can be used as a member
+// name, which includes the semi-reserved keywords like `list` or `print`.
+func (p *Parser) curTokenIsIdentifier() bool {
+	return p.curTokenIs(token.String) || token.LookupIdent(p.curToken.Literal) == p.curToken.Type
+}
+
+// expectPeekIdentifier is expectPeek for member names
+func (p *Parser) expectPeekIdentifier() bool {
+	p.nextToken()
+	if !p.curTokenIsIdentifier() {
+		p.unexpectedError()
+		return false
+	}
+	return true
+}
+
+// parseClassName parses the name of a class-like declaration
+func (p *Parser) parseClassName() (string, bool) {
+	if !p.expectPeek(token.String) {
+		return "", false
+	}
+	name := p.curToken.Literal
+	if reservedClassNames[strings.ToLower(name)] {
+		p.errorf(SyntaxError, "Cannot use '%s' as class name as it is reserved", name)
+		return "", false
+	}
+	return name, true
+}
+
+// checkClassReference reports the use of self or parent as the name of
+// the class extended, or of the interfaces or traits used, kind tells
+// which of them.
+func (p *Parser) checkClassReference(name, kind string) bool {
+	switch strings.ToLower(name) {
+	case "self", "parent":
+		p.errorf(SyntaxError, "Cannot use '%s' as %s name as it is reserved", name, kind)
+		return false
+	}
+	return true
+}
+
+// parseNameList parses `A, B\C, ...`, curToken is the token before the
+// first name. kind is the kind of class named for checkClassReference, an
+// empty kind skips the check.
+func (p *Parser) parseNameList(kind string) []string {
+	names := []string{}
+	for {
+		p.nextToken()
+		name := p.parseName()
+		if name == "" || kind != "" && !p.checkClassReference(name, kind) {
+			return nil
+		}
+		names = append(names, name)
+		if !p.peekTokenIs(token.Comma) {
+			return names
+		}
+		p.nextToken()
+	}
+}
+
+// parseClassStatement parses
+//
+//	[abstract|final] class Name [extends A] [implements B, C] { members }
+func (p *Parser) parseClassStatement() ast.Statement {
+	stmt := &ast.ClassStatement{BaseNode: p.newBaseNode()}
+	for p.curTokenIs(token.Abstract) || p.curTokenIs(token.Final) {
+		m := modifierTypes[p.curToken.Type]
+		if stmt.Modifiers.Has(m) {
+			p.errorf(SyntaxError, "Multiple %s modifiers are not allowed", strings.ToLower(p.curToken.Literal))
+			return nil
+		}
+		stmt.Modifiers |= m
+		p.nextToken()
+	}
+	if stmt.Modifiers.Has(ast.ModifierAbstract | ast.ModifierFinal) {
+		p.errorf(SyntaxError, "Cannot use the final modifier on an abstract class")
+		return nil
+	}
+	if !p.curTokenIs(token.Class) {
+		p.unexpectedError()
+		return nil
+	}
+	var ok bool
+	if stmt.Name, ok = p.parseClassName(); !ok {
+		return nil
+	}
+	if !p.parseClassHeader(stmt) || !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	if stmt.Body = p.parseClassBody(stmt.Name, classKindClass); stmt.Body == nil {
+		return nil
+	}
+	if !stmt.Modifiers.Has(ast.ModifierAbstract) && !p.verifyAbstractClass(stmt.Name, stmt.Body) {
+		return nil
+	}
+	return stmt
+}
+
+// parseClassHeader parses the optional `extends A implements B, C` of a class
+func (p *Parser) parseClassHeader(stmt *ast.ClassStatement) bool {
+	if p.peekTokenIs(token.Extends) {
+		p.nextToken()
+		p.nextToken()
+		tok := p.curToken
+		if stmt.SuperClassName = p.parseName(); stmt.SuperClassName == "" || !p.checkClassReference(stmt.SuperClassName, "class") {
+			return false
+		}
+		stmt.SuperClass = &ast.Constant{BaseNode: &ast.BaseNode{Token: tok}, Value: stmt.SuperClassName}
+		p.finish(stmt.SuperClass, tok)
+	}
+	if p.peekTokenIs(token.Implements) {
+		p.nextToken()
+		if stmt.Interfaces = p.parseNameList("interface"); stmt.Interfaces == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyAbstractClass reports an error if a non abstract class declares
+// abstract methods.
+func (p *Parser) verifyAbstractClass(name string, body *ast.BlockStatement) bool {
+	methods := []string{}
+	for _, member := range body.Statements {
+		if m, ok := member.(*ast.MethodStatement); ok && m.Modifiers.Has(ast.ModifierAbstract) {
+			methods = append(methods, name+"::"+m.Name)
+		}
+	}
+	if len(methods) == 0 {
+		return true
+	}
+	plural := ""
+	if len(methods) > 1 {
+		plural = "s"
+	}
+	list := methods
+	if len(list) > 3 {
+		list = append(list[:3:3], "...")
+	}
+	p.errorf(SyntaxError,
+		"Class %s contains %d abstract method%s and must therefore be declared abstract or implement the remaining methods (%s)",
+		name, len(methods), plural, strings.Join(list, ", "),
+	)
+	return false
+}
+
+// parseInterfaceStatement parses `interface Name [extends A, B] { members }`
+func (p *Parser) parseInterfaceStatement() ast.Statement {
+	stmt := &ast.InterfaceStatement{BaseNode: p.newBaseNode()}
+	var ok bool
+	if stmt.Name, ok = p.parseClassName(); !ok {
+		return nil
+	}
+	if p.peekTokenIs(token.Extends) {
+		p.nextToken()
+		if stmt.Extends = p.parseNameList("interface"); stmt.Extends == nil {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	if stmt.Body = p.parseClassBody(stmt.Name, classKindInterface); stmt.Body == nil {
+		return nil
+	}
+	return stmt
+}
+
+// parseTraitStatement parses `trait Name { members }`
+func (p *Parser) parseTraitStatement() ast.Statement {
+	stmt := &ast.TraitStatement{BaseNode: p.newBaseNode()}
+	var ok bool
+	if stmt.Name, ok = p.parseClassName(); !ok {
+		return nil
+	}
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	if stmt.Body = p.parseClassBody(stmt.Name, classKindTrait); stmt.Body == nil {
+		return nil
+	}
+	return stmt
+}
+
+// parseClassBody parses the members between `{` and `}`, on return
+// curToken is the closing brace.
+func (p *Parser) parseClassBody(className string, kind classKind) *ast.BlockStatement {
+	body := &ast.BlockStatement{BaseNode: p.newBaseNode(), Statements: []ast.Statement{}}
+	declared := map[string]bool{}
+	depth := p.depth
+	p.nextToken()
+	for !p.curTokenIs(token.RBrace) {
+		if p.curTokenIs(token.End) {
+			p.unexpectedError()
+			return nil
+		}
+		from := p.curToken
+		member := p.parseClassMember(className, kind)
+		if p.error != nil || !p.checkRedeclaredMember(className, member, declared) {
+			bad, closed := p.recoverStatement(from, depth, token.RBrace)
+			if bad == nil {
+				return nil
+			}
+			body.Statements = append(body.Statements, bad)
+			if closed {
+				continue
+			}
+		} else {
+			p.finish(member, from)
+			body.Statements = append(body.Statements, member)
+		}
+		p.nextToken()
+	}
+	p.finish(body, body.Token)
+	return body
+}
+
+// checkRedeclaredMember reports the members declared twice in a class,
+// method names are case insensitive.
+func (p *Parser) checkRedeclaredMember(className string, member ast.Statement, declared map[string]bool) bool {
+	var keys, messages []string
+	switch m := member.(type) {
+	case *ast.MethodStatement:
+		keys = append(keys, "function "+strings.ToLower(m.Name))
+		messages = append(messages, fmt.Sprintf("Cannot redeclare %s::%s()", className, m.Name))
+	case *ast.PropertyStatement:
+		for _, prop := range m.Properties {
+			keys = append(keys, "$"+prop.Name)
+			messages = append(messages, fmt.Sprintf("Cannot redeclare %s::$%s", className, prop.Name))
+		}
+	case *ast.ClassConstStatement:
+		for _, c := range m.Constants {
+			keys = append(keys, "const "+c.Name)
+			messages = append(messages, fmt.Sprintf("Cannot redefine class constant %s::%s", className, c.Name))
+		}
+	case *ast.EnumCaseStatement:
+		keys = append(keys, "const "+m.Name)
+		messages = append(messages, fmt.Sprintf("Cannot redefine class constant %s::%s", className, m.Name))
+	}
+	for i, key := range keys {
+		if declared[key] {
+			p.errorf(SyntaxError, "%s", messages[i])
+			return false
+		}
+		declared[key] = true
+	}
+	return true
+}
+
+// parseClassMember parses a property, constant, method or trait use, and
+// the attributes preceding it.
+func (p *Parser) parseClassMember(className string, kind classKind) ast.Statement {
+	if !p.curTokenIs(token.Attribute) {
+		return p.parseClassMemberRest(className, kind)
+	}
+	attrs := p.parseAttributes()
+	if attrs == nil {
+		return nil
+	}
+	if p.curTokenIs(token.Use) {
+		p.unexpectedError()
+		return nil
+	}
+	member := p.parseClassMemberRest(className, kind)
+	switch m := member.(type) {
+	case *ast.MethodStatement:
+		m.Attributes = attrs
+	case *ast.PropertyStatement:
+		m.Attributes = attrs
+	case *ast.ClassConstStatement:
+		m.Attributes = attrs
+	case *ast.EnumCaseStatement:
+		m.Attributes = attrs
+	}
+	return member
+}
+
+func (p *Parser) parseClassMemberRest(className string, kind classKind) ast.Statement {
+	if p.curTokenIs(token.Case) {
+		return p.parseEnumCaseStatement(kind)
+	}
+	if p.curTokenIs(token.Use) {
+		if kind == classKindInterface {
+			p.nextToken()
+			p.errorf(SyntaxError, "Cannot use traits inside of interfaces. %s is used in %s", p.parseName(), className)
+			return nil
+		}
+		return p.parseTraitUseStatement()
+	}
+
+	tok := p.curToken
+	isVar := p.curTokenIs(token.Var)
+	var modifiers ast.Modifier
+	if isVar {
+		modifiers = ast.ModifierPublic
+		p.nextToken()
+	} else {
+		var ok bool
+		if modifiers, ok = p.parseMemberModifiers(); !ok {
+			return nil
+		}
+	}
+
+	switch {
+	case p.curTokenIs(token.Const) && !isVar:
+		return p.parseClassConstStatement(tok, modifiers, className, kind)
+	case p.curTokenIs(token.Function) && !isVar:
+		return p.parseMethodStatement(tok, modifiers, className, kind)
+	case modifiers != 0:
+		return p.parsePropertyStatement(tok, modifiers, className, kind)
+	}
+	p.unexpectedError()
+	return nil
+}
+
+// parseMemberModifiers collects the modifiers of a class member, curToken
+// is the first token after them on return.
+func (p *Parser) parseMemberModifiers() (ast.Modifier, bool) {
+	var modifiers ast.Modifier
+	for {
+		m, ok := modifierTypes[p.curToken.Type]
+		if !ok {
+			return modifiers, true
+		}
+		if m&ast.ModifierVisibility != 0 && modifiers&ast.ModifierVisibility != 0 {
+			p.errorf(SyntaxError, "Multiple access type modifiers are not allowed")
+			return 0, false
+		}
+		if modifiers.Has(m) {
+			p.errorf(SyntaxError, "Multiple %s modifiers are not allowed", m)
+			return 0, false
+		}
+		modifiers |= m
+		if modifiers.Has(ast.ModifierAbstract | ast.ModifierFinal) {
+			p.errorf(SyntaxError, "Cannot use the final modifier on an abstract class member")
+			return 0, false
+		}
+		p.nextToken()
+	}
+}
+
+// parsePropertyStatement parses `modifiers [type] $a [= expr], ...;`
+func (p *Parser) parsePropertyStatement(tok token.Token, modifiers ast.Modifier, className string, kind classKind) ast.Statement {
+	stmt := &ast.PropertyStatement{BaseNode: &ast.BaseNode{Token: tok}, Modifiers: modifiers}
+	if kind == classKindInterface {
+		p.errorf(SyntaxError, "Interfaces may not include properties")
+		return nil
+	}
+	if kind == classKindEnum {
+		p.errorf(SyntaxError, "Enum %s cannot include properties", className)
+		return nil
+	}
+	if modifiers.Has(ast.ModifierAbstract) {
+		p.errorf(SyntaxError, "Properties cannot be declared abstract")
+		return nil
+	}
+	if !p.curTokenIs(token.Variable) {
+		if stmt.Type = p.parseTypeHint(typeProperty); stmt.Type == nil {
+			return nil
+		}
+		p.nextToken()
+	}
+	for {
+		if !p.curTokenIs(token.Variable) {
+			p.unexpectedError()
+			return nil
+		}
+		prop := &ast.PropertyItem{BaseNode: p.newBaseNode(), Name: p.curToken.Literal[1:]}
+		if modifiers.Has(ast.ModifierFinal) {
+			p.errorf(SyntaxError,
+				"Cannot declare property %s::$%s final, the final modifier is allowed only for methods, classes, and class constants",
+				className, prop.Name,
+			)
+			return nil
+		}
+		if stmt.Type != nil {
+			for _, t := range stmt.Type.Types {
+				if t = strings.ToLower(t); t == "callable" || t == "void" || t == "never" {
+					p.errorf(SyntaxError, "Property %s::$%s cannot have type %s", className, prop.Name, stmt.Type)
+					return nil
+				}
+			}
+		}
+		if modifiers.Has(ast.ModifierReadonly) && !p.checkReadonlyProperty(className, prop.Name, stmt.Type, modifiers) {
+			return nil
+		}
+		if p.peekTokenIs(token.Assign) {
+			if modifiers.Has(ast.ModifierReadonly) {
+				p.errorf(SyntaxError, "Readonly property %s::$%s cannot have default value", className, prop.Name)
+				return nil
+			}
+			p.nextToken()
+			p.nextToken()
+			if prop.Default = p.parseExpression(precLowest); prop.Default == nil {
+				return nil
+			}
+		}
+		p.finish(prop, prop.Token)
+		stmt.Properties = append(stmt.Properties, prop)
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken()
+		p.nextToken()
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+// checkReadonlyProperty reports the misuses of a readonly property or
+// promoted parameter.
+func (p *Parser) checkReadonlyProperty(className, name string, typ *ast.TypeHint, modifiers ast.Modifier) bool {
+	if modifiers.Has(ast.ModifierStatic) {
+		p.errorf(SyntaxError, "Static property %s::$%s cannot be readonly", className, name)
+		return false
+	}
+	if typ == nil {
+		p.errorf(SyntaxError, "Readonly property %s::$%s must have type", className, name)
+		return false
+	}
+	return true
+}
+
+// parseClassConstStatement parses `[modifiers] const A = expr, ...;`
+func (p *Parser) parseClassConstStatement(tok token.Token, modifiers ast.Modifier, className string, kind classKind) ast.Statement {
+	stmt := &ast.ClassConstStatement{BaseNode: &ast.BaseNode{Token: tok}, Modifiers: modifiers}
+	for _, m := range []ast.Modifier{ast.ModifierStatic, ast.ModifierAbstract, ast.ModifierReadonly} {
+		if modifiers.Has(m) {
+			p.errorf(SyntaxError, "Cannot use '%s' as constant modifier", m)
+			return nil
+		}
+	}
+	for {
+		if !p.expectPeekIdentifier() {
+			return nil
+		}
+		c := &ast.ConstantItem{BaseNode: p.newBaseNode(), Name: p.curToken.Literal}
+		if strings.ToLower(c.Name) == "class" {
+			p.errorf(SyntaxError, "A class constant must not be called 'class'; it is reserved for class name fetching")
+			return nil
+		}
+		if kind == classKindInterface && modifiers&(ast.ModifierProtected|ast.ModifierPrivate) != 0 {
+			p.errorf(SyntaxError, "Access type for interface constant %s::%s must be public", className, c.Name)
+			return nil
+		}
+		if !p.expectPeek(token.Assign) {
+			return nil
+		}
+		p.nextToken()
+		if c.Value = p.parseExpression(precLowest); c.Value == nil {
+			return nil
+		}
+		p.finish(c, c.Token)
+		stmt.Constants = append(stmt.Constants, c)
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+// parseMethodStatement parses `modifiers function [&] name(params) [: type] { body }`,
+// the body is replaced by `;` for abstract and interface methods.
+func (p *Parser) parseMethodStatement(tok token.Token, modifiers ast.Modifier, className string, kind classKind) ast.Statement {
+	stmt := &ast.MethodStatement{BaseNode: &ast.BaseNode{Token: tok}, Modifiers: modifiers}
+	if modifiers.Has(ast.ModifierReadonly) {
+		p.errorf(SyntaxError, "Cannot use 'readonly' as method modifier")
+		return nil
+	}
+	if p.peekTokenIs(token.Ampersand) {
+		p.nextToken()
+		stmt.ByRef = true
+	}
+	if !p.expectPeekIdentifier() {
+		return nil
+	}
+	stmt.Name = p.curToken.Literal
+	if kind == classKindEnum && enumMagicMethods[strings.ToLower(stmt.Name)] {
+		p.errorf(SyntaxError, "Enum %s cannot include magic method %s", className, stmt.Name)
+		return nil
+	}
+	switch strings.ToLower(stmt.Name) {
+	case "__construct", "__destruct", "__clone":
+		if modifiers.Has(ast.ModifierStatic) {
+			p.errorf(SyntaxError, "Method %s::%s() cannot be static", className, stmt.Name)
+			return nil
+		}
+	}
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	if stmt.Parameters = p.parseParameterList(); stmt.Parameters == nil {
+		return nil
+	}
+	var ok bool
+	if stmt.ReturnType, ok = p.parseReturnType(); !ok {
+		return nil
+	}
+
+	if kind == classKindInterface {
+		if modifiers&(ast.ModifierProtected|ast.ModifierPrivate) != 0 {
+			p.errorf(SyntaxError, "Access type for interface method %s::%s() must be public", className, stmt.Name)
+			return nil
+		}
+	}
+	abstract := kind == classKindInterface || modifiers.Has(ast.ModifierAbstract)
+	if abstract && modifiers.Has(ast.ModifierPrivate) && kind != classKindTrait {
+		p.errorf(SyntaxError, "%s function %s::%s() cannot be declared private", abstractKind(kind), className, stmt.Name)
+		return nil
+	}
+
+	p.nextToken()
+	hasBody := p.curTokenIs(token.LBrace)
+	switch {
+	case !hasBody && !p.curTokenIs(token.Semicolon):
+		p.unexpectedError()
+		return nil
+	case abstract && hasBody:
+		p.errorf(SyntaxError, "%s function %s::%s() cannot contain body", abstractKind(kind), className, stmt.Name)
+		return nil
+	case !abstract && !hasBody:
+		p.errorf(SyntaxError, "Non-abstract method %s::%s() must contain body", className, stmt.Name)
+		return nil
+	}
+
+	for _, param := range stmt.Parameters {
+		if !param.IsPromoted() {
+			continue
+		}
+		if strings.ToLower(stmt.Name) != "__construct" {
+			p.errorf(SyntaxError, "Cannot declare promoted property outside a constructor")
+			return nil
+		}
+		if abstract {
+			p.errorf(SyntaxError, "Cannot declare promoted property in an abstract constructor")
+			return nil
+		}
+		if param.Modifiers.Has(ast.ModifierReadonly) && !p.checkReadonlyProperty(className, param.Name, param.Type, param.Modifiers) {
+			return nil
+		}
+	}
+
+	if hasBody {
+		outer := p.enterFunction()
+		stmt.Body = p.parseBlockStatement()
+		if !p.leaveFunction(outer) {
+			return nil
+		}
+	}
+	return stmt
+}
+
+func abstractKind(kind classKind) string {
+	if kind == classKindInterface {
+		return "Interface"
+	}
+	return "Abstract"
+}
+
+// parseTraitUseStatement parses `use A, B;` or `use A, B { adaptations }`
+func (p *Parser) parseTraitUseStatement() ast.Statement {
+	stmt := &ast.TraitUseStatement{BaseNode: p.newBaseNode()}
+	if stmt.Traits = p.parseNameList("trait"); stmt.Traits == nil {
+		return nil
+	}
+	p.nextToken()
+	if p.curTokenIs(token.Semicolon) {
+		return stmt
+	}
+	if !p.curTokenIs(token.LBrace) {
+		p.unexpectedError()
+		return nil
+	}
+	for !p.peekTokenIs(token.RBrace) {
+		p.nextToken()
+		from := p.curToken
+		adaptation := p.parseTraitAdaptation()
+		if adaptation == nil {
+			return nil
+		}
+		p.finish(adaptation, from)
+		stmt.Adaptations = append(stmt.Adaptations, adaptation)
+	}
+	p.nextToken()
+	return stmt
+}
+
+// parseTraitAdaptation parses `[A::]foo insteadof B;` or `[A::]foo as [modifier] [alias];`
+func (p *Parser) parseTraitAdaptation() ast.Statement {
+	tok := p.curToken
+	var trait, method string
+	if p.curTokenIs(token.String) || p.curTokenIs(token.NsSeparator) {
+		if method = p.parseName(); method == "" {
+			return nil
+		}
+	} else if p.curTokenIsIdentifier() {
+		method = p.curToken.Literal
+	} else {
+		p.unexpectedError()
+		return nil
+	}
+	if p.peekTokenIs(token.PaamayimNekudotayim) {
+		trait = method
+		p.nextToken()
+		if !p.expectPeekIdentifier() {
+			return nil
+		}
+		method = p.curToken.Literal
+	}
+
+	if trait != "" && p.peekTokenIs(token.Insteadof) {
+		stmt := &ast.TraitPrecedenceStatement{BaseNode: &ast.BaseNode{Token: tok}, Trait: trait, Method: method}
+		p.nextToken()
+		if stmt.InsteadOf = p.parseNameList(""); stmt.InsteadOf == nil || !p.expectSemicolon() {
+			return nil
+		}
+		return stmt
+	}
+
+	stmt := &ast.TraitAliasStatement{BaseNode: &ast.BaseNode{Token: tok}, Trait: trait, Method: method}
+	if !p.expectPeek(token.As) {
+		return nil
+	}
+	p.nextToken()
+	if m, ok := modifierTypes[p.curToken.Type]; ok {
+		if m&ast.ModifierVisibility == 0 {
+			p.errorf(SyntaxError, "Cannot use '%s' as method modifier", m)
+			return nil
+		}
+		stmt.Modifiers = m
+		p.nextToken()
+	}
+	if !p.curTokenIs(token.Semicolon) {
+		if !p.curTokenIsIdentifier() {
+			p.unexpectedError()
+			return nil
+		}
+		stmt.Alias = p.curToken.Literal
+		if !p.expectSemicolon() {
+			return nil
+		}
+	} else if stmt.Modifiers == 0 {
+		p.unexpectedError()
+		return nil
+	}
+	return stmt
+}
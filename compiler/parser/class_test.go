@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_ClassStatement(t *testing.T) {
+	program := parse(t, `<?php
+abstract class Foo extends \Base\Model implements Countable, Arrayable {
+	use A, B {
+		A::list insteadof B;
+		B::list as protected bList;
+		print as public;
+	}
+	use C;
+
+	const X = 1, Y = 2;
+	final public const Z = 3;
+
+	public static ?int $count = 0, $total;
+	var $legacy;
+	protected array $items = null;
+
+	public function __construct(private int $id, protected ?string $name = null) {}
+	abstract protected function &list(): static;
+	final public static function count(): int { return 1; }
+}
+final class Bar {}
+`)
+	if len(program.Statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(program.Statements))
+	}
+	class, ok := program.Statements[0].(*ast.ClassStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ClassStatement, got %T", program.Statements[0])
+	}
+	if class.Name != "Foo" || !class.Modifiers.Has(ast.ModifierAbstract) {
+		t.Errorf("expected abstract class Foo, got %s %s", class.Modifiers, class.Name)
+	}
+	if class.SuperClassName != `\Base\Model` || class.SuperClass.String() != `\Base\Model` {
+		t.Errorf("expected superclass \\Base\\Model, got %s", class.SuperClassName)
+	}
+	if strings.Join(class.Interfaces, ",") != "Countable,Arrayable" {
+		t.Errorf("unexpected interfaces: %v", class.Interfaces)
+	}
+
+	expected := []string{
+		"use A, B {\nA::list insteadof B;\nB::list as protected bList;\nprint as public;\n}",
+		"use C;",
+		"const X = 1, Y = 2;",
+		"final public const Z = 3;",
+		"public static ?int $count = 0, $total;",
+		"public $legacy;",
+		"protected array $items = null;",
+		"public function __construct(private int $id, protected ?string $name = null) {\n\n}",
+		"abstract protected function &list(): static;",
+		"final public static function count(): int {\nreturn 1;\n}",
+	}
+	members := class.Body.Statements
+	if len(members) != len(expected) {
+		t.Fatalf("expected %d members, got %d", len(expected), len(members))
+	}
+	for i, member := range members {
+		if member.String() != expected[i] {
+			t.Errorf("members[%d] - expected=%q, got=%q", i, expected[i], member.String())
+		}
+	}
+	if m := members[8].(*ast.MethodStatement); !m.IsAbstract() || !m.ByRef {
+		t.Errorf("expected abstract by-ref method, got %s", m)
+	}
+	if bar := program.Statements[1].(*ast.ClassStatement); !bar.Modifiers.Has(ast.ModifierFinal) {
+		t.Errorf("expected final class Bar, got %s", bar)
+	}
+}
+
+func Test_InterfaceAndTraitStatement(t *testing.T) {
+	program := parse(t, `<?php
+interface Foo extends A, \B\C {
+	const X = 1;
+	public function foo(int $a): void;
+	public static function create();
+}
+trait Bar {
+	private $x = 1;
+	abstract private function helper();
+	public function bar() { return $this; }
+}
+`)
+	iface, ok := program.Statements[0].(*ast.InterfaceStatement)
+	if !ok {
+		t.Fatalf("expected *ast.InterfaceStatement, got %T", program.Statements[0])
+	}
+	if strings.Join(iface.Extends, ",") != `A,\B\C` || len(iface.Body.Statements) != 3 {
+		t.Errorf("unexpected interface: %s", iface)
+	}
+	trait, ok := program.Statements[1].(*ast.TraitStatement)
+	if !ok {
+		t.Fatalf("expected *ast.TraitStatement, got %T", program.Statements[1])
+	}
+	if trait.Name != "Bar" || len(trait.Body.Statements) != 3 {
+		t.Errorf("unexpected trait: %s", trait)
+	}
+}
+
+func Test_ClassErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`<?php abstract final class A {}`, "Cannot use the final modifier on an abstract class"},
+		{`<?php class self {}`, "Cannot use 'self' as class name as it is reserved"},
+		{`<?php class A extends self {}`, "Cannot use 'self' as class name as it is reserved"},
+		{`<?php class A extends Parent {}`, "Cannot use 'Parent' as class name as it is reserved"},
+		{`<?php class A implements I, self {}`, "Cannot use 'self' as interface name as it is reserved"},
+		{`<?php interface I extends parent {}`, "Cannot use 'parent' as interface name as it is reserved"},
+		{`<?php class A { use self; }`, "Cannot use 'self' as trait name as it is reserved"},
+		{`<?php class A { static function __construct() {} }`, "Method A::__construct() cannot be static"},
+		{`<?php class A { public static function __Destruct() {} }`, "Method A::__Destruct() cannot be static"},
+		{`<?php trait T { static function __clone() {} }`, "Method T::__clone() cannot be static"},
+		{`<?php class A { public private $a; }`, "Multiple access type modifiers are not allowed"},
+		{`<?php class A { static static $a; }`, "Multiple static modifiers are not allowed"},
+		{`<?php abstract class A { abstract final function f(); }`, "Cannot use the final modifier on an abstract class member"},
+		{`<?php class A { abstract function f(); }`, "Class A contains 1 abstract method and must therefore be declared abstract or implement the remaining methods (A::f)"},
+		{`<?php abstract class A { abstract function f() {} }`, "Abstract function A::f() cannot contain body"},
+		{`<?php class A { function f(); }`, "Non-abstract method A::f() must contain body"},
+		{`<?php interface I { function f() {} }`, "Interface function I::f() cannot contain body"},
+		{`<?php interface I { protected function f(); }`, "Access type for interface method I::f() must be public"},
+		{`<?php interface I { public $a; }`, "Interfaces may not include properties"},
+		{`<?php interface I { use T; }`, "Cannot use traits inside of interfaces. T is used in I"},
+		{`<?php class A { abstract $a; }`, "Properties cannot be declared abstract"},
+		{`<?php class A { final $a; }`, "Cannot declare property A::$a final"},
+		{`<?php class A { public callable $a; }`, "Property A::$a cannot have type callable"},
+		{`<?php class A { static const X = 1; }`, "Cannot use 'static' as constant modifier"},
+		{`<?php class A { const class = 1; }`, "A class constant must not be called 'class'"},
+		{`<?php class A { function f() {} function F() {} }`, "Cannot redeclare A::F()"},
+		{`<?php class A { public $a; public $b, $a; }`, "Cannot redeclare A::$a"},
+		{`<?php class A { const X = 1; const X = 2; }`, "Cannot redefine class constant A::X"},
+		{`<?php class A { function f(public $a) {} }`, "Cannot declare promoted property outside a constructor"},
+		{`<?php class A { use T { f as static; } }`, "Cannot use 'static' as method modifier"},
+		{`<?php class A { $a; }`, "unexpected variable"},
+		{`<?php class A { public void $a; }`, "Property A::$a cannot have type void"},
+		{`<?php class A { readonly static int $a; }`, "Static property A::$a cannot be readonly"},
+		{`<?php class A { readonly $a; }`, "Readonly property A::$a must have type"},
+		{`<?php class A { readonly int $a = 1; }`, "Readonly property A::$a cannot have default value"},
+		{`<?php class A { readonly const X = 1; }`, "Cannot use 'readonly' as constant modifier"},
+		{`<?php class A { readonly function f() {} }`, "Cannot use 'readonly' as method modifier"},
+		{`<?php class A { function __construct(public readonly $a) {} }`, "Readonly property A::$a must have type"},
+	}
+	for i, tt := range tests {
+		err := parseError(t, tt.input)
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %q", i, tt.message, err.Message)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// parsePrintExpression parses `print expr`, like in PHP its operand binds
+// tighter than `and`, `or` and `xor` only.
+func (p *Parser) parsePrintExpression() ast.Expression {
+	exp := &ast.PrintExpression{BaseNode: p.newBaseNode()}
+	p.nextToken()
+	if exp.Expression = p.parseExpression(precLogicalAnd); exp.Expression == nil {
+		return nil
+	}
+	return exp
+}
+
+// yieldEnd are the tokens following a yield without a value
+var yieldEnd = map[token.Type]bool{
+	token.Semicolon: true, token.RParen: true, token.Comma: true,
+	token.RBracket: true, token.RBrace: true, token.Colon: true,
+}
+
+// parseYieldExpression parses `yield`, `yield value` and `yield key =>
+// value`, the operands bind like the one of print.
+func (p *Parser) parseYieldExpression() ast.Expression {
+	exp := &ast.YieldExpression{BaseNode: p.newBaseNode()}
+	if !p.checkGenerator("yield") {
+		return nil
+	}
+	if yieldEnd[p.peekToken.Type] {
+		return exp
+	}
+	p.nextToken()
+	if exp.Value = p.parseExpression(precLogicalAnd); exp.Value == nil {
+		return nil
+	}
+	if p.peekTokenIs(token.DoubleArrow) {
+		p.nextToken()
+		p.nextToken()
+		exp.Key = exp.Value
+		if exp.Value = p.parseExpression(precLogicalAnd); exp.Value == nil {
+			return nil
+		}
+	}
+	return exp
+}
+
+func (p *Parser) parseYieldFromExpression() ast.Expression {
+	exp := &ast.YieldFromExpression{BaseNode: p.newBaseNode()}
+	if !p.checkGenerator("yield from") {
+		return nil
+	}
+	p.nextToken()
+	if exp.Expression = p.parseExpression(precLogicalAnd); exp.Expression == nil {
+		return nil
+	}
+	return exp
+}
+
+// checkGenerator reports an error for a yield outside of a function
+func (p *Parser) checkGenerator(keyword string) bool {
+	if !p.jumps.function {
+		p.errorf(SyntaxError, "The \"%s\" expression can only be used inside a function", keyword)
+		return false
+	}
+	return true
+}
+
+// parseIssetExpression parses `isset(var, ...)`, a trailing comma is
+// allowed.
+func (p *Parser) parseIssetExpression() ast.Expression {
+	exp := &ast.IssetExpression{BaseNode: p.newBaseNode()}
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	for len(exp.Variables) == 0 || !p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		v := p.parseExpression(precLowest)
+		if v == nil {
+			return nil
+		}
+		switch v.(type) {
+		case *ast.Variable, *ast.DynamicVariable, *ast.IndexExpression,
+			*ast.PropertyFetchExpression, *ast.StaticPropertyFetchExpression:
+		default:
+			p.errorf(SyntaxError, "Cannot use isset() on the result of an expression (you can use \"null !== expression\" instead)")
+			return nil
+		}
+		exp.Variables = append(exp.Variables, v)
+		if !p.peekTokenIs(token.RParen) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	p.nextToken()
+	return exp
+}
+
+// parseEmptyExpression parses `empty(expr)`
+func (p *Parser) parseEmptyExpression() ast.Expression {
+	exp := &ast.EmptyExpression{BaseNode: p.newBaseNode()}
+	if exp.Expression = p.parseParenExpression(); exp.Expression == nil {
+		return nil
+	}
+	return exp
+}
+
+// parseExitExpression parses `exit`, `exit()` and `exit(expr)`, and the
+// same forms of `die`.
+func (p *Parser) parseExitExpression() ast.Expression {
+	exp := &ast.ExitExpression{BaseNode: p.newBaseNode()}
+	if !p.peekTokenIs(token.LParen) {
+		return exp
+	}
+	p.nextToken()
+	if p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		return exp
+	}
+	p.nextToken()
+	if exp.Expression = p.parseExpression(precLowest); exp.Expression == nil || !p.expectPeek(token.RParen) {
+		return nil
+	}
+	return exp
+}
+
+// parseGlobalStatement parses `global $a, $$b, ${expr};`
+func (p *Parser) parseGlobalStatement() ast.Statement {
+	stmt := &ast.GlobalStatement{BaseNode: p.newBaseNode()}
+	for {
+		p.nextToken()
+		var name ast.Expression
+		switch p.curToken.Type {
+		case token.Variable:
+			name = p.parseVariable()
+			if name.(*ast.Variable).Name == "this" {
+				p.errorf(SyntaxError, "Cannot use $this as global variable")
+				return nil
+			}
+		case token.Dollar:
+			if name = p.parseDynamicVariable(); name == nil {
+				return nil
+			}
+		default:
+			p.unexpectedError()
+			return nil
+		}
+		stmt.Names = append(stmt.Names, name)
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+// parseStaticStatement parses `static $a = expr, $b;`, curToken is
+// `static` and peekToken a variable.
+func (p *Parser) parseStaticStatement() ast.Statement {
+	stmt := &ast.StaticStatement{BaseNode: p.newBaseNode()}
+	for {
+		if !p.expectPeek(token.Variable) {
+			return nil
+		}
+		v := &ast.StaticVar{BaseNode: p.newBaseNode(), Name: p.curToken.Literal[1:]}
+		if v.Name == "this" {
+			p.errorf(SyntaxError, "Cannot use $this as static variable")
+			return nil
+		}
+		if p.peekTokenIs(token.Assign) {
+			p.nextToken()
+			p.nextToken()
+			if v.Default = p.parseExpression(precLowest); v.Default == nil {
+				return nil
+			}
+		}
+		p.finish(v, v.Token)
+		stmt.Vars = append(stmt.Vars, v)
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+// parseUnsetStatement parses `unset(var, ...);`, a trailing comma is
+// allowed.
+func (p *Parser) parseUnsetStatement() ast.Statement {
+	stmt := &ast.UnsetStatement{BaseNode: p.newBaseNode()}
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	for len(stmt.Variables) == 0 || !p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		v := p.parseExpression(precLowest)
+		if v == nil {
+			return nil
+		}
+		if v, ok := v.(*ast.Variable); ok && v.Name == "this" {
+			p.errorf(SyntaxError, "Cannot unset $this")
+			return nil
+		}
+		if !isVariable(v) {
+			p.errorf(InvalidAssignmentError, "Cannot use temporary expression in write context")
+			return nil
+		}
+		if !p.checkWritable(v) {
+			return nil
+		}
+		stmt.Variables = append(stmt.Variables, v)
+		if !p.peekTokenIs(token.RParen) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	p.nextToken()
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_LanguageConstructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`echo $a, $b + 1;`, `echo $a, ($b + 1);`},
+		{`print $a . $b;`, `(print ($a . $b))`},
+		{`print $a and $b;`, `((print $a) and $b)`},
+		{`$a = print $b;`, `($a = (print $b))`},
+		{`isset($a, $b[0], $c->d, A::$e,);`, `isset($a, $b[0], $c->d, A::$e)`},
+		{`!isset($a) || empty($b);`, `((!isset($a)) || empty($b))`},
+		{`empty($a + 1);`, `empty(($a + 1))`},
+		{`empty(foo());`, `empty(foo())`},
+		{`exit;`, `exit`},
+		{`exit();`, `exit`},
+		{`die(1);`, `exit(1)`},
+		{`$a or die($b);`, `($a or exit($b))`},
+		{`global $a, $$b, ${$c . $d};`, `global $a, ${$b}, ${($c . $d)};`},
+		{`function f() { static $a = 1, $b; }`, "function f() {\nstatic $a = 1, $b;\n}"},
+		{`static fn() => 1;`, `static fn() => 1`},
+		{`unset($a, $b[0], $c->d,);`, `unset($a, $b[0], $c->d);`},
+		{`function g() { yield; }`, "function g() {\n(yield)\n}"},
+		{`function g() { $a = yield $b + 1; }`, "function g() {\n($a = (yield ($b + 1)))\n}"},
+		{`function g() { yield $a and $b; }`, "function g() {\n((yield $a) and $b)\n}"},
+		{`function g() { yield $k . 'x' => $v ?? 1; }`, "function g() {\n(yield ($k . 'x') => ($v ?? 1))\n}"},
+		{`function g() { $a = (yield) ?? 1; }`, "function g() {\n($a = ((yield) ?? 1))\n}"},
+		{`fn() => yield from h();`, `fn() => (yield from h())`},
+	}
+	for _, tt := range tests {
+		program := parse(t, "<?php "+tt.input)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func Test_LanguageConstructErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"isset(foo());", "Cannot use isset() on the result of an expression"},
+		{"isset($a + 1);", "Cannot use isset() on the result of an expression"},
+		{"isset();", "unexpected token \")\""},
+		{"empty($a, $b);", "unexpected token \",\""},
+		{"exit 1;", "unexpected integer"},
+		{"global $this;", "Cannot use $this as global variable"},
+		{"global $a->b;", "unexpected token \"->\""},
+		{"static $this;", "Cannot use $this as static variable"},
+		{"unset($this);", "Cannot unset $this"},
+		{"unset(foo());", "Can't use function return value in write context"},
+		{"unset($a?->b);", "Can't use nullsafe operator in write context"},
+		{"unset(1);", "Cannot use temporary expression in write context"},
+		{"$a = (unset) $b;", "The (unset) cast is no longer supported"},
+		{"yield 1;", `The "yield" expression can only be used inside a function`},
+		{"function f() {} $a = yield;", `The "yield" expression can only be used inside a function`},
+		{"class A { const B = yield; }", `The "yield" expression can only be used inside a function`},
+		{"yield from f();", `The "yield from" expression can only be used inside a function`},
+	}
+	for _, tt := range tests {
+		err := parseError(t, "<?php "+tt.input)
+		if !strings.Contains(err.Message, tt.expected) {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
@@ -0,0 +1,504 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// parseFunctionStatement parses `function [&] name(params) [: type] { body }`
+// with the attributes preceding it. A `function &(` at the beginning of a
+// statement is a closure, it is parsed as an expression statement.
+func (p *Parser) parseFunctionStatement(attrs []*ast.AttributeGroup) ast.Statement {
+	tok := p.curToken
+	byRef := false
+	if p.peekTokenIs(token.Ampersand) {
+		p.nextToken()
+		byRef = true
+	}
+	if byRef && p.peekTokenIs(token.LParen) {
+		stmt := &ast.ExpressionStatement{BaseNode: &ast.BaseNode{Token: tok}}
+		closure := p.parseClosureRest(&ast.ClosureExpression{BaseNode: &ast.BaseNode{Token: tok}, Attributes: attrs, ByRef: true})
+		if closure == nil {
+			return nil
+		}
+		from := tok
+		if len(attrs) > 0 {
+			from = attrs[0].Token
+		}
+		p.finish(closure, from)
+		if stmt.Expression = p.parseInfixExpressions(closure, from, precLowest); stmt.Expression == nil {
+			return nil
+		}
+		if !p.expectSemicolon() {
+			return nil
+		}
+		stmt.Expression.MarkAsStmt()
+		return stmt
+	}
+
+	stmt := &ast.FunctionStatement{BaseNode: &ast.BaseNode{Token: tok}, Attributes: attrs, ByRef: byRef}
+	if !p.expectPeek(token.String) {
+		return nil
+	}
+	stmt.Name = p.curToken.Literal
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	if stmt.Parameters = p.parseParameterList(); stmt.Parameters == nil {
+		return nil
+	}
+	for _, param := range stmt.Parameters {
+		if param.IsPromoted() {
+			p.errorf(SyntaxError, "Cannot declare promoted property outside a constructor")
+			return nil
+		}
+	}
+	var ok bool
+	if stmt.ReturnType, ok = p.parseReturnType(); !ok {
+		return nil
+	}
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	outer := p.enterFunction()
+	stmt.Body = p.parseBlockStatement()
+	if !p.leaveFunction(outer) {
+		return nil
+	}
+	return stmt
+}
+
+// parseClosureExpression parses `function [&](params) [use (vars)] [: type] { body }`
+func (p *Parser) parseClosureExpression() ast.Expression {
+	closure := &ast.ClosureExpression{BaseNode: p.newBaseNode()}
+	if p.peekTokenIs(token.Ampersand) {
+		p.nextToken()
+		closure.ByRef = true
+	}
+	return p.parseClosureRest(closure)
+}
+
+// parseClosureRest parses a closure from its parameter list on
+func (p *Parser) parseClosureRest(closure *ast.ClosureExpression) ast.Expression {
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	if closure.Parameters = p.parseParameterList(); closure.Parameters == nil {
+		return nil
+	}
+	if p.peekTokenIs(token.Use) {
+		p.nextToken()
+		if closure.Uses = p.parseClosureUses(closure.Parameters); closure.Uses == nil {
+			return nil
+		}
+	}
+	var ok bool
+	if closure.ReturnType, ok = p.parseReturnType(); !ok {
+		return nil
+	}
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	outer := p.enterFunction()
+	closure.Body = p.parseBlockStatement()
+	if !p.leaveFunction(outer) {
+		return nil
+	}
+	return closure
+}
+
+// parseClosureUses parses `use ([&]$a, ...)`, curToken is `use`
+func (p *Parser) parseClosureUses(params []*ast.Parameter) []*ast.ClosureUse {
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	uses := []*ast.ClosureUse{}
+	seen := map[string]bool{}
+	for !p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		use := &ast.ClosureUse{BaseNode: p.newBaseNode()}
+		if p.curTokenIs(token.Ampersand) {
+			use.ByRef = true
+			p.nextToken()
+		}
+		if !p.curTokenIs(token.Variable) {
+			p.unexpectedError()
+			return nil
+		}
+		use.Name = p.curToken.Literal[1:]
+		if use.Name == "this" {
+			p.errorf(SyntaxError, "Cannot use $this as lexical variable")
+			return nil
+		}
+		for _, param := range params {
+			if param.Name == use.Name {
+				p.errorf(SyntaxError, "Cannot use lexical variable $%s as a parameter name", use.Name)
+				return nil
+			}
+		}
+		if seen[use.Name] {
+			p.errorf(SyntaxError, "Cannot use variable $%s twice", use.Name)
+			return nil
+		}
+		seen[use.Name] = true
+		p.finish(use, use.Token)
+		uses = append(uses, use)
+		if !p.peekTokenIs(token.RParen) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+		if p.peekTokenIs(token.RParen) && p.curTokenIs(token.Comma) && !p.requireVersion(800, "a trailing comma in closure use lists") {
+			return nil
+		}
+	}
+	p.nextToken()
+	return uses
+}
+
+// parseArrowFunctionExpression parses `fn [&](params) [: type] => expr`
+func (p *Parser) parseArrowFunctionExpression() ast.Expression {
+	fn := &ast.ArrowFunctionExpression{BaseNode: p.newBaseNode()}
+	if p.peekTokenIs(token.Ampersand) {
+		p.nextToken()
+		fn.ByRef = true
+	}
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	if fn.Parameters = p.parseParameterList(); fn.Parameters == nil {
+		return nil
+	}
+	var ok bool
+	if fn.ReturnType, ok = p.parseReturnType(); !ok {
+		return nil
+	}
+	if !p.expectPeek(token.DoubleArrow) {
+		return nil
+	}
+	p.nextToken()
+	outer := p.enterFunction()
+	fn.Expression = p.parseExpression(precLowest)
+	if !p.leaveFunction(outer) {
+		return nil
+	}
+	return fn
+}
+
+// parseStaticExpression parses the expressions starting with `static`
+func (p *Parser) parseStaticExpression() ast.Expression {
+	tok := p.curToken
+	switch {
+	case p.peekTokenIs(token.Function):
+		p.nextToken()
+		exp := p.parseClosureExpression()
+		if exp == nil {
+			return nil
+		}
+		closure := exp.(*ast.ClosureExpression)
+		closure.Token, closure.Static = tok, true
+		return closure
+	case p.peekTokenIs(token.Fn):
+		p.nextToken()
+		exp := p.parseArrowFunctionExpression()
+		if exp == nil {
+			return nil
+		}
+		fn := exp.(*ast.ArrowFunctionExpression)
+		fn.Token, fn.Static = tok, true
+		return fn
+	case p.peekTokenIs(token.PaamayimNekudotayim): // static::
+		class := &ast.Constant{BaseNode: p.newBaseNode(), Value: tok.Literal}
+		p.finish(class, tok)
+		return class
+	}
+	p.nextToken()
+	p.unexpectedError()
+	return nil
+}
+
+// parseParameterList parses the parameters of a function, curToken is `(`
+// and it's `)` on return.
+func (p *Parser) parseParameterList() []*ast.Parameter {
+	params := []*ast.Parameter{}
+	for !p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		param := p.parseParameter()
+		if param == nil {
+			return nil
+		}
+		params = append(params, param)
+		if !p.peekTokenIs(token.RParen) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+		if p.peekTokenIs(token.RParen) && p.curTokenIs(token.Comma) && !p.requireVersion(800, "a trailing comma in parameter lists") {
+			return nil
+		}
+	}
+	p.nextToken()
+
+	for i, param := range params {
+		if param.Name == "this" {
+			p.errorf(SyntaxError, "Cannot use $this as parameter")
+			return nil
+		}
+		for _, prev := range params[:i] {
+			if prev.Name == param.Name {
+				p.errorf(SyntaxError, "Redefinition of parameter $%s", param.Name)
+				return nil
+			}
+		}
+		if param.Variadic {
+			if i != len(params)-1 {
+				p.errorf(SyntaxError, "Only the last parameter can be variadic")
+				return nil
+			}
+			if param.Default != nil {
+				p.errorf(SyntaxError, "Variadic parameter cannot have a default value")
+				return nil
+			}
+			if param.IsPromoted() {
+				p.errorf(SyntaxError, "Cannot declare variadic promoted property")
+				return nil
+			}
+		}
+	}
+	return params
+}
+
+// parseParameter parses `[visibility] [type] [&] [...] $name [= default]`
+func (p *Parser) parseParameter() *ast.Parameter {
+	param := &ast.Parameter{BaseNode: p.newBaseNode()}
+	if p.curTokenIs(token.Attribute) {
+		if param.Attributes = p.parseAttributes(); param.Attributes == nil {
+			return nil
+		}
+	}
+	for {
+		m := modifierTypes[p.curToken.Type]
+		if m&(ast.ModifierVisibility|ast.ModifierReadonly) == 0 {
+			break
+		}
+		if m&ast.ModifierVisibility != 0 && param.Modifiers&ast.ModifierVisibility != 0 {
+			p.errorf(SyntaxError, "Multiple access type modifiers are not allowed")
+			return nil
+		}
+		if param.Modifiers.Has(m) {
+			p.errorf(SyntaxError, "Multiple %s modifiers are not allowed", m)
+			return nil
+		}
+		if !p.requireVersion(800, "constructor property promotion") {
+			return nil
+		}
+		param.Modifiers |= m
+		p.nextToken()
+	}
+	if !p.curTokenIs(token.Ampersand) && !p.curTokenIs(token.Ellipsis) && !p.curTokenIs(token.Variable) {
+		if param.Type = p.parseTypeHint(typeParameter); param.Type == nil {
+			return nil
+		}
+		p.nextToken()
+	}
+	if p.curTokenIs(token.Ampersand) {
+		param.ByRef = true
+		p.nextToken()
+	}
+	if p.curTokenIs(token.Ellipsis) {
+		param.Variadic = true
+		p.nextToken()
+	}
+	if !p.curTokenIs(token.Variable) {
+		p.unexpectedError()
+		return nil
+	}
+	param.Name = p.curToken.Literal[1:]
+	if p.peekTokenIs(token.Assign) {
+		p.nextToken()
+		p.nextToken()
+		if param.Default = p.parseExpression(precLowest); param.Default == nil {
+			return nil
+		}
+	}
+	p.finish(param, param.Token)
+	return param
+}
+
+// parseReturnType parses the optional `: type` after a parameter list,
+// it reports false on error.
+func (p *Parser) parseReturnType() (*ast.TypeHint, bool) {
+	if !p.peekTokenIs(token.Colon) {
+		return nil, true
+	}
+	p.nextToken()
+	p.nextToken()
+	typ := p.parseTypeHint(typeReturn)
+	return typ, typ != nil
+}
+
+// positions of a type declaration, the types allowed differ between them
+const (
+	typeParameter = iota
+	typeReturn
+	typeProperty
+)
+
+// parseTypeHint parses `?type` or `type[|type...]`, on return curToken
+// is the last token of the type.
+func (p *Parser) parseTypeHint(pos int) *ast.TypeHint {
+	typ := &ast.TypeHint{BaseNode: p.newBaseNode()}
+	if p.curTokenIs(token.QuestionMark) {
+		typ.Nullable = true
+		p.nextToken()
+	}
+	name := p.parseTypeName(pos)
+	if name == "" {
+		return nil
+	}
+	typ.Types = append(typ.Types, name)
+	for !typ.Nullable && p.peekTokenIs(token.Bar) {
+		p.nextToken()
+		if !p.requireVersion(800, "union types") {
+			return nil
+		}
+		p.nextToken()
+		if name = p.parseTypeName(pos); name == "" {
+			return nil
+		}
+		typ.Types = append(typ.Types, name)
+	}
+	if !p.checkTypeHint(typ, pos) {
+		return nil
+	}
+	p.finish(typ, typ.Token)
+	return typ
+}
+
+// checkTypeHint reports the duplicate types, the misuses of the mixed,
+// void, never, null and false types and the types introduced after the
+// targeted PHP version
+func (p *Parser) checkTypeHint(typ *ast.TypeHint, pos int) bool {
+	standalone := !typ.Nullable && len(typ.Types) == 1
+	seen := map[string]bool{}
+	for _, name := range typ.Types {
+		lower := strings.ToLower(name)
+		if seen[lower] {
+			if reservedClassNames[lower] {
+				name = lower
+			}
+			p.errorf(SyntaxError, "Duplicate type %s is redundant", name)
+			return false
+		}
+		seen[lower] = true
+		switch name = lower; name {
+		case "null":
+			if typ.Nullable {
+				p.errorf(SyntaxError, "null cannot be marked as nullable")
+				return false
+			}
+			if standalone {
+				p.errorf(SyntaxError, "Null can not be used as a standalone type")
+				return false
+			}
+		case "false":
+			// ?false and false|null are false alone as well
+			if len(typ.Types) == 1 || len(typ.Types) == 2 && (seen["null"] || strings.EqualFold(typ.Types[1], "null")) {
+				p.errorf(SyntaxError, "False can not be used as a standalone type")
+				return false
+			}
+		case "static":
+			if !p.requireVersion(800, "the static return type") {
+				return false
+			}
+		case "mixed":
+			if !p.requireVersion(800, "the mixed type") {
+				return false
+			}
+			if typ.Nullable {
+				p.errorf(SyntaxError, "Type mixed cannot be marked as nullable since mixed already includes null")
+				return false
+			}
+			if !standalone {
+				p.errorf(SyntaxError, "Type mixed can only be used as a standalone type")
+				return false
+			}
+		case "void", "never":
+			if name == "never" && !p.requireVersion(801, "the never type") {
+				return false
+			}
+			if pos == typeParameter {
+				p.errorf(SyntaxError, "%s cannot be used as a parameter type", name)
+				return false
+			}
+			if !standalone && name == "void" {
+				p.errorf(SyntaxError, "Void can only be used as a standalone type")
+				return false
+			}
+			if !standalone {
+				p.errorf(SyntaxError, "never can only be used as a standalone type")
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (p *Parser) parseTypeName(pos int) string {
+	switch p.curToken.Type {
+	case token.Static:
+		if pos != typeReturn {
+			p.unexpectedError()
+			return ""
+		}
+		return "static"
+	case token.Array, token.Callable:
+		return strings.ToLower(p.curToken.Literal)
+	case token.String, token.NsSeparator:
+		return p.parseName()
+	case token.Namespace:
+		if p.peekTokenIs(token.NsSeparator) { // namespace\Foo
+			return p.parseName()
+		}
+	}
+	p.unexpectedError()
+	return ""
+}
+
+// parseName parses a possibly qualified name like `Foo\Bar`, `\Foo` or
+// `namespace\Foo`, on return curToken is the last segment of the name.
+func (p *Parser) parseName() string {
+	var out strings.Builder
+	if p.curTokenIs(token.Namespace) && p.peekTokenIs(token.NsSeparator) {
+		out.WriteString(p.curToken.Literal)
+		p.nextToken()
+	}
+	if p.curTokenIs(token.NsSeparator) {
+		out.WriteString("\\")
+		if !p.expectPeek(token.String) {
+			return ""
+		}
+	}
+	out.WriteString(p.curToken.Literal)
+	for p.peekTokenIs(token.NsSeparator) {
+		p.nextToken()
+		if !p.expectPeek(token.String) {
+			return ""
+		}
+		out.WriteString("\\")
+		out.WriteString(p.curToken.Literal)
+	}
+	return out.String()
+}
+
+func (p *Parser) parseReturnStatement() ast.Statement {
+	stmt := &ast.ReturnStatement{BaseNode: p.newBaseNode()}
+	if !p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+		if stmt.ReturnValue = p.parseExpression(precLowest); stmt.ReturnValue == nil {
+			return nil
+		}
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_FunctionStatement(t *testing.T) {
+	program := parse(t, `<?php
+function &foo(int $a, ?\Foo\Bar $b = null, int|string &$c = 1, array ...$rest): ?array {
+	return $a;
+}
+function bar() {}
+`)
+	if len(program.Statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(program.Statements))
+	}
+	fn, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("expected *ast.FunctionStatement, got %T", program.Statements[0])
+	}
+	if fn.Name != "foo" || !fn.ByRef {
+		t.Errorf("expected by-ref function foo, got %s", fn.Name)
+	}
+	expected := []string{"int $a", `?\Foo\Bar $b = null`, "int|string &$c = 1", "array ...$rest"}
+	if len(fn.Parameters) != len(expected) {
+		t.Fatalf("expected %d parameters, got %d", len(expected), len(fn.Parameters))
+	}
+	for i, param := range fn.Parameters {
+		if param.String() != expected[i] {
+			t.Errorf("parameters[%d] - expected=%q, got=%q", i, expected[i], param.String())
+		}
+	}
+	if fn.ReturnType == nil || fn.ReturnType.String() != "?array" {
+		t.Errorf("expected return type ?array, got %v", fn.ReturnType)
+	}
+	if _, ok := fn.Body.Statements[0].(*ast.ReturnStatement); !ok {
+		t.Errorf("expected return statement, got %T", fn.Body.Statements[0])
+	}
+	if bar := program.Statements[1].(*ast.FunctionStatement); len(bar.Parameters) != 0 || bar.ReturnType != nil {
+		t.Errorf("unexpected function bar: %s", bar)
+	}
+}
+
+func Test_RelativeTypeNames(t *testing.T) {
+	program := parse(t, `<?php
+function f(namespace\Foo $a): namespace\Bar|int {}
+class A { public ?namespace\Baz $p; }
+try {} catch (namespace\E $e) {}
+`)
+	fn := program.Statements[0].(*ast.FunctionStatement)
+	if s := fn.Parameters[0].String(); s != `namespace\Foo $a` {
+		t.Errorf("unexpected parameter %q", s)
+	}
+	if s := fn.ReturnType.String(); s != `namespace\Bar|int` {
+		t.Errorf("unexpected return type %q", s)
+	}
+	prop := program.Statements[1].(*ast.ClassStatement).Body.Statements[0].(*ast.PropertyStatement)
+	if s := prop.Type.String(); s != `?namespace\Baz` {
+		t.Errorf("unexpected property type %q", s)
+	}
+	catch := program.Statements[2].(*ast.TryStatement).Catches[0]
+	if len(catch.Types) != 1 || catch.Types[0] != `namespace\E` {
+		t.Errorf("unexpected catch types %v", catch.Types)
+	}
+	if err := parseError(t, `<?php function f(namespace $a) {}`); !strings.Contains(err.Message, `unexpected token "namespace"`) {
+		t.Errorf("unexpected error %q", err.Message)
+	}
+}
+
+func Test_ClosureExpression(t *testing.T) {
+	program := parse(t, `<?php
+function ($a) use (&$x, $y): int { return $a; };
+static function &() {};
+function &() use ($z) {};
+`)
+	closure := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ClosureExpression)
+	if len(closure.Parameters) != 1 || len(closure.Uses) != 2 {
+		t.Fatalf("unexpected closure: %s", closure)
+	}
+	if !closure.Uses[0].ByRef || closure.Uses[0].Name != "x" || closure.Uses[1].ByRef {
+		t.Errorf("unexpected closure uses: %s", closure)
+	}
+	if closure.ReturnType.String() != "int" {
+		t.Errorf("expected return type int, got %s", closure.ReturnType)
+	}
+	static := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.ClosureExpression)
+	if !static.Static || !static.ByRef {
+		t.Errorf("expected static by-ref closure, got %s", static)
+	}
+	byRef := program.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.ClosureExpression)
+	if !byRef.ByRef || len(byRef.Uses) != 1 {
+		t.Errorf("expected by-ref closure with use, got %s", byRef)
+	}
+}
+
+func Test_ArrowFunctionExpression(t *testing.T) {
+	program := parse(t, `<?php fn($x) => $x + 1; static fn&(array $a = null): ?int => $a; fn() => fn($y) => $y;`)
+	tests := []string{
+		"fn($x) => ($x + 1)",
+		"static fn&(array $a = null): ?int => $a",
+		"fn() => fn($y) => $y",
+	}
+	for i, expected := range tests {
+		exp := program.Statements[i].(*ast.ExpressionStatement).Expression
+		if _, ok := exp.(*ast.ArrowFunctionExpression); !ok {
+			t.Fatalf("statements[%d] - expected *ast.ArrowFunctionExpression, got %T", i, exp)
+		}
+		if exp.String() != expected {
+			t.Errorf("statements[%d] - expected=%q, got=%q", i, expected, exp.String())
+		}
+	}
+}
+
+func Test_FunctionErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`<?php function foo($a, $a) {}`, "Redefinition of parameter $a"},
+		{`<?php function foo(...$a, $b) {}`, "Only the last parameter can be variadic"},
+		{`<?php function foo(...$a = 1) {}`, "Variadic parameter cannot have a default value"},
+		{`<?php function foo($this) {}`, "Cannot use $this as parameter"},
+		{`<?php function foo(public $a) {}`, "Cannot declare promoted property outside a constructor"},
+		{`<?php function ($a) use ($a) {};`, "Cannot use lexical variable $a as a parameter name"},
+		{`<?php function () use ($b, $b) {};`, "Cannot use variable $b twice"},
+		{`<?php function () use ($this) {};`, "Cannot use $this as lexical variable"},
+		{`<?php function foo(?int|string $a) {}`, "unexpected token \"|\""},
+		{`<?php fn($a) { return $a; };`, "unexpected token \"{\", expecting \"=>\""},
+		{`<?php function foo(?mixed $a) {}`, "Type mixed cannot be marked as nullable since mixed already includes null"},
+		{`<?php function foo(): int|mixed {}`, "Type mixed can only be used as a standalone type"},
+		{`<?php function foo(void $a) {}`, "void cannot be used as a parameter type"},
+		{`<?php function foo(): void|int {}`, "Void can only be used as a standalone type"},
+		{`<?php function foo(never $a) {}`, "never cannot be used as a parameter type"},
+		{`<?php function foo(static $a) {}`, "unexpected token \"static\""},
+		{`<?php function foo(): null {}`, "Null can not be used as a standalone type"},
+		{`<?php function foo(?null $a) {}`, "null cannot be marked as nullable"},
+		{`<?php function foo(): false {}`, "False can not be used as a standalone type"},
+		{`<?php function foo(?false $a) {}`, "False can not be used as a standalone type"},
+		{`<?php function foo(): null|false {}`, "False can not be used as a standalone type"},
+		{`<?php function foo(int|int $a) {}`, "Duplicate type int is redundant"},
+		{`<?php function foo(): INT|string|Int {}`, "Duplicate type int is redundant"},
+		{`<?php class A { public Foo|Bar|foo $a; }`, "Duplicate type foo is redundant"},
+		{`<?php function foo(public public $a) {}`, "Multiple access type modifiers are not allowed"},
+	}
+	for i, tt := range tests {
+		err := parseError(t, tt.input)
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %q", i, tt.message, err.Message)
+		}
+	}
+	parse(t, `<?php function foo(int|null $a, false|int $b): string|false {}`)
+}
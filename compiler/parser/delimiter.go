@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/diag"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// closers maps the tokens opening a nested construct to the token
+// closing it
+var closers = map[token.Type]token.Type{
+	token.LBrace:                token.RBrace,
+	token.CurlyOpen:             token.RBrace,
+	token.DollarOpenCurlyBraces: token.RBrace,
+	token.LParen:                token.RParen,
+	token.LBracket:              token.RBracket,
+	token.Attribute:             token.RBracket,
+	token.StartHeredoc:          token.EndHeredoc,
+}
+
+// trackDelimiter pushes curToken on the delimiters when it opens a
+// construct, or pops up to the delimiter it closes. Closing tokens with
+// no opening one are left alone.
+func (p *Parser) trackDelimiter() {
+	tok := p.curToken
+	if _, ok := closers[tok.Type]; ok {
+		p.delimiters = append(p.delimiters, tok)
+		return
+	}
+	for i := len(p.delimiters) - 1; i >= 0; i-- {
+		if closers[p.delimiters[i].Type] == tok.Type {
+			p.delimiters = p.delimiters[:i]
+			p.closed = tok
+			return
+		}
+	}
+}
+
+// nestingError returns the error PHP's lexer reports when tok doesn't
+// match the delimiters open up to curToken: the end of file with a
+// delimiter left open, or a closing token opened by a different one or
+// not opened at all. Heredocs aren't nested like the other delimiters.
+func (p *Parser) nestingError(tok token.Token) string {
+	var close string
+	switch tok.Type {
+	case token.End:
+	case token.RBrace, token.RParen, token.RBracket:
+		close = tok.Literal
+	default:
+		return ""
+	}
+	for i := len(p.delimiters) - 1; i >= 0; i-- {
+		open := p.delimiters[i]
+		if open.Type == token.StartHeredoc {
+			continue
+		}
+		if closers[open.Type] == tok.Type {
+			return ""
+		}
+		msg := fmt.Sprintf("Unclosed '%c'", open.Literal[len(open.Literal)-1])
+		if open.Line != tok.Line {
+			msg += fmt.Sprintf(" on line %d", open.Line)
+		}
+		if close != "" {
+			msg += " does not match '" + close + "'"
+		}
+		return msg
+	}
+	if close != "" {
+		return "Unmatched '" + close + "'"
+	}
+	return ""
+}
+
+// nesting returns the nesting error of tok when it's curToken or peekToken
+func (p *Parser) nesting(tok token.Token) string {
+	switch tok {
+	case p.peekToken:
+		return p.peekNesting
+	case p.curToken:
+		return p.curNesting
+	}
+	return ""
+}
+
+// delimiterLabels returns the label pointing at the delimiter left open
+// when an error is reported on tok: the end of file, or a closing token
+// not matching the parenthesis or bracket open.
+func (p *Parser) delimiterLabels(tok token.Token) []diag.Label {
+	n := len(p.delimiters)
+	if n == 0 {
+		return nil
+	}
+	open := p.delimiters[n-1]
+	switch tok.Type {
+	case token.End:
+	case token.RBrace, token.RParen, token.RBracket:
+		if tok.Offset == p.closed.Offset || closers[open.Type] == tok.Type ||
+			closers[open.Type] == token.RBrace || open.Type == token.StartHeredoc {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	msg := "unclosed `" + open.Literal + "` opened here"
+	switch open.Type {
+	case token.StartHeredoc:
+		open.Literal = strings.TrimRight(open.Literal, "\r\n")
+		msg = "heredoc started here"
+	case token.CurlyOpen:
+		msg = "unclosed `{` opened here"
+	}
+	filename := p.filename()
+	return []diag.Label{{Pos: open.Pos(filename), End: open.End(filename), Message: msg}}
+}
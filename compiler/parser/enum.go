@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// enumMagicMethods are the magic methods an enum may not declare
+var enumMagicMethods = map[string]bool{
+	"__construct": true, "__destruct": true, "__clone": true, "__get": true,
+	"__set": true, "__unset": true, "__isset": true, "__tostring": true,
+	"__debuginfo": true, "__serialize": true, "__unserialize": true,
+	"__sleep": true, "__wakeup": true, "__set_state": true,
+}
+
+// parseEnumStatement parses `enum Name[: int|string] [implements A, B] { members }`
+func (p *Parser) parseEnumStatement() ast.Statement {
+	stmt := &ast.EnumStatement{BaseNode: p.newBaseNode()}
+	var ok bool
+	if stmt.Name, ok = p.parseClassName(); !ok {
+		return nil
+	}
+	if p.peekTokenIs(token.Colon) {
+		p.nextToken()
+		p.nextToken()
+		if stmt.BackingType = p.parseTypeHint(typeProperty); stmt.BackingType == nil {
+			return nil
+		}
+		if t := stmt.BackingType; t.Nullable || len(t.Types) != 1 || t.Types[0] != "int" && t.Types[0] != "string" {
+			p.errorf(SyntaxError, "Enum backing type must be int or string, %s given", t)
+			return nil
+		}
+	}
+	if p.peekTokenIs(token.Implements) {
+		p.nextToken()
+		if stmt.Interfaces = p.parseNameList("interface"); stmt.Interfaces == nil {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	if stmt.Body = p.parseClassBody(stmt.Name, classKindEnum); stmt.Body == nil {
+		return nil
+	}
+	for _, member := range stmt.Body.Statements {
+		c, ok := member.(*ast.EnumCaseStatement)
+		if !ok {
+			continue
+		}
+		if stmt.BackingType != nil && c.Value == nil {
+			p.errorAt(c.Token, SyntaxError, "Case %s of backed enum %s must have a value", c.Name, stmt.Name)
+			return nil
+		}
+		if stmt.BackingType == nil && c.Value != nil {
+			p.errorAt(c.Token, SyntaxError, "Case %s of non-backed enum %s must not have a value", c.Name, stmt.Name)
+			return nil
+		}
+	}
+	return stmt
+}
+
+// parseEnumCaseStatement parses `case NAME [= expr];`
+func (p *Parser) parseEnumCaseStatement(kind classKind) ast.Statement {
+	stmt := &ast.EnumCaseStatement{BaseNode: p.newBaseNode()}
+	if kind != classKindEnum {
+		p.errorf(SyntaxError, "Case can only be used in enums")
+		return nil
+	}
+	if !p.expectPeekIdentifier() {
+		return nil
+	}
+	stmt.Name = p.curToken.Literal
+	if strings.ToLower(stmt.Name) == "class" {
+		p.errorf(SyntaxError, "A class constant must not be called 'class'; it is reserved for class name fetching")
+		return nil
+	}
+	if p.peekTokenIs(token.Assign) {
+		p.nextToken()
+		p.nextToken()
+		if stmt.Value = p.parseExpression(precLowest); stmt.Value == nil {
+			return nil
+		}
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
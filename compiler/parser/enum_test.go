@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_EnumStatement(t *testing.T) {
+	program := parse(t, `<?php
+enum Suit: string implements HasColor, JsonSerializable {
+	use T;
+	case Hearts = 'H';
+	#[Deprecated]
+	case Spades = 'S';
+	const Wild = self::Spades;
+	public function color(): string { return 1; }
+}
+enum Status {
+	case Active;
+}
+$enum = 1;
+`)
+	if len(program.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(program.Statements))
+	}
+	enum, ok := program.Statements[0].(*ast.EnumStatement)
+	if !ok {
+		t.Fatalf("expected *ast.EnumStatement, got %T", program.Statements[0])
+	}
+	if enum.Name != "Suit" || enum.BackingType.String() != "string" {
+		t.Errorf("expected enum Suit: string, got %s: %s", enum.Name, enum.BackingType)
+	}
+	if strings.Join(enum.Interfaces, ",") != "HasColor,JsonSerializable" {
+		t.Errorf("unexpected interfaces: %v", enum.Interfaces)
+	}
+	members := enum.Body.Statements
+	if len(members) != 5 {
+		t.Fatalf("expected 5 members, got %d", len(members))
+	}
+	spades, ok := members[2].(*ast.EnumCaseStatement)
+	if !ok {
+		t.Fatalf("expected *ast.EnumCaseStatement, got %T", members[2])
+	}
+	if spades.Name != "Spades" || spades.Value == nil || len(spades.Attributes) != 1 {
+		t.Errorf("unexpected case: %s", spades)
+	}
+	status := program.Statements[1].(*ast.EnumStatement)
+	if status.BackingType != nil || status.Body.Statements[0].String() != "case Active;" {
+		t.Errorf("unexpected pure enum: %s", status)
+	}
+}
+
+func Test_EnumErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`<?php enum A: float { case B = 1.0; }`, "Enum backing type must be int or string, float given"},
+		{`<?php enum A: int { case B; }`, "Case B of backed enum A must have a value"},
+		{`<?php enum A { case B = 1; }`, "Case B of non-backed enum A must not have a value"},
+		{`<?php enum A { public $a; }`, "Enum A cannot include properties"},
+		{`<?php enum A { function __get($a) {} }`, "Enum A cannot include magic method __get"},
+		{`<?php enum A { case B; case B; }`, "Cannot redefine class constant A::B"},
+		{`<?php class A { case B; }`, "Case can only be used in enums"},
+		{`<?php enum A extends B {}`, "unexpected"},
+	}
+	for i, tt := range tests {
+		err := parseError(t, tt.input)
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %q", i, tt.message, err.Message)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/diag"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// ErrorKind classifies the parser errors, it implements error so that
+// errors.Is(err, SyntaxError) matches the errors of that kind.
+type ErrorKind int
+
+const (
+	_ ErrorKind = iota
+	// EndOfFileError represents normal EOF error
+	EndOfFileError
+	// WrongTokenError means that token is not what we expected
+	WrongTokenError
+	// UnexpectedTokenError means that token is not expected to appear in current condition
+	UnexpectedTokenError
+	// UnexpectedEndError means we get unexpected "end" keyword (this is mainly created for REPL)
+	UnexpectedEndError
+	// MethodDefinitionError means there's an error on method definition's method name
+	MethodDefinitionError
+	// InvalidAssignmentError means user assigns value to wrong type of expressions
+	InvalidAssignmentError
+	// SyntaxError means there's a grammatical in the source code
+	SyntaxError
+	// ArgumentError means there's a method parameter's definition error
+	ArgumentError
+)
+
+var errorKindNames = map[ErrorKind]string{
+	EndOfFileError:         "EndOfFileError",
+	WrongTokenError:        "WrongTokenError",
+	UnexpectedTokenError:   "UnexpectedTokenError",
+	UnexpectedEndError:     "UnexpectedEndError",
+	MethodDefinitionError:  "MethodDefinitionError",
+	InvalidAssignmentError: "InvalidAssignmentError",
+	SyntaxError:            "SyntaxError",
+	ArgumentError:          "ArgumentError",
+}
+
+func (k ErrorKind) String() string {
+	if n, ok := errorKindNames[k]; ok {
+		return n
+	}
+	return "Unknown"
+}
+
+func (k ErrorKind) Error() string {
+	return k.String()
+}
+
+// Error represents parser's parsing error
+type Error struct {
+	// Message contains the readable message of error
+	Message string
+	Kind    ErrorKind
+	// Pos and End delimit the source the error is about
+	Pos token.Position
+	End token.Position
+	// Labels point at the related source, e.g. the brace left open
+	Labels []diag.Label
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the kind of the error for errors.Is and errors.As
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Diagnostic describes the error for the diag package, the message is
+// given without the "in file on line N" suffix of PHP as the diagnostic
+// shows the position.
+func (e *Error) Diagnostic() *diag.Diagnostic {
+	suffix := fmt.Sprintf(" in %s on line %d", e.Pos.Filename, e.Pos.Line)
+	return &diag.Diagnostic{
+		Severity: diag.Error,
+		Code:     e.Kind.String(),
+		Message:  strings.TrimSuffix(e.Message, suffix),
+		Pos:      e.Pos,
+		End:      e.End,
+		Labels:   e.Labels,
+	}
+}
+
+// ErrorList is a list of parser errors, it sorts by position
+type ErrorList []*Error
+
+// Add appends an error to the list
+func (l *ErrorList) Add(e *Error) {
+	*l = append(*l, e)
+}
+
+func (l ErrorList) Len() int      { return len(l) }
+func (l ErrorList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l ErrorList) Less(i, j int) bool {
+	a, b := l[i].Pos, l[j].Pos
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	if a.Column != b.Column {
+		return a.Column < b.Column
+	}
+	return l[i].Message < l[j].Message
+}
+
+// Sort sorts the list by file, line, column and message
+func (l ErrorList) Sort() {
+	sort.Sort(l)
+}
+
+// RemoveMultiples sorts the list and keeps only the first error of each
+// line, the errors following it are usually caused by it.
+func (l *ErrorList) RemoveMultiples() {
+	sort.Sort(l)
+	var last token.Position
+	i := 0
+	for _, e := range *l {
+		if e.Pos.Filename != last.Filename || e.Pos.Line != last.Line {
+			last = e.Pos
+			(*l)[i] = e
+			i++
+		}
+	}
+	*l = (*l)[0:i]
+}
+
+// Error implements the error interface
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Unwrap returns the errors of the list for errors.Is and errors.As
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, e := range l {
+		errs[i] = e
+	}
+	return errs
+}
+
+// Diagnostics returns the diagnostics of the errors
+func (l ErrorList) Diagnostics() []*diag.Diagnostic {
+	diagnostics := make([]*diag.Diagnostic, len(l))
+	for i, e := range l {
+		diagnostics[i] = e.Diagnostic()
+	}
+	return diagnostics
+}
+
+// Err returns an error equivalent to this list, or nil for an empty list
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
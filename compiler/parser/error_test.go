@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/diag"
+	"github.com/eaglewu/luban/compiler/lexer"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+func Test_ErrorKind(t *testing.T) {
+	var err error = parseError(t, `<?php foo() = 1;`)
+	if !errors.Is(err, InvalidAssignmentError) || errors.Is(err, SyntaxError) {
+		t.Errorf("expected an InvalidAssignmentError, got %s", err.(*Error).Kind)
+	}
+	var perr *Error
+	if !errors.As(err, &perr) || perr.Message != err.Error() {
+		t.Errorf("expected errors.As to find *Error in %v", err)
+	}
+	var kind ErrorKind
+	if !errors.As(err, &kind) || kind != InvalidAssignmentError {
+		t.Errorf("expected errors.As to find the kind, got %s", kind)
+	}
+}
+
+func Test_ErrorListUnwrap(t *testing.T) {
+	_, err := ParseFile(nil, "a.php", "<?php 1 = $a;", 0)
+	if !errors.Is(err, InvalidAssignmentError) {
+		t.Errorf("expected errors.Is to find InvalidAssignmentError in %v", err)
+	}
+	var perr *Error
+	if !errors.As(err, &perr) || perr.Message != err.Error() {
+		t.Errorf("expected errors.As to find *Error in %v", err)
+	}
+	_, err = ParseExpr("$a +")
+	if !errors.As(err, &perr) {
+		t.Errorf("expected errors.As to find *Error in %v", err)
+	}
+	_, err = ParseFile(nil, "a.php", "<?php $a = ;\n$b = ;", AllErrors)
+	if !errors.As(err, &perr) || perr.Pos.Line != 1 {
+		t.Errorf("expected errors.As to find the first error, got %v", perr)
+	}
+}
+
+func Test_ErrorPosition(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   string
+		end   string
+	}{
+		{"<?php\n$a = 1\n  $b = 2;", "a.php:3:3", "a.php:3:5"},
+		{"<?php\nfoo(1,\n  'x\ny' 2);", "a.php:4:4", "a.php:4:5"},
+		{"<?php\nfunction f() {\n  $a;", "a.php:3:6", "a.php:3:6"},
+		{"<?php $this = 1;", "a.php:1:13", "a.php:1:14"},
+	}
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		go l.Run()
+		p := New(l)
+		p.Filename = "a.php"
+		_, err := p.ParseProgram()
+		if err == nil {
+			t.Fatalf("tests[%d] - expected parser error for %q", i, tt.input)
+		}
+		if err.Pos.String() != tt.pos || err.End.String() != tt.end {
+			t.Errorf("tests[%d] - expected %s-%s, got %s-%s", i, tt.pos, tt.end, err.Pos, err.End)
+		}
+	}
+}
+
+func Test_ErrorList(t *testing.T) {
+	at := func(line, column int, msg string) *Error {
+		pos := token.Position{Filename: "a.php", Line: line, Column: column}
+		return &Error{Message: msg, Kind: SyntaxError, Pos: pos, End: pos}
+	}
+	var list ErrorList
+	if list.Err() != nil {
+		t.Errorf("expected nil error for an empty list")
+	}
+	list.Add(at(3, 1, "c"))
+	list.Add(at(1, 5, "b"))
+	list.Add(at(1, 2, "a"))
+	list.Add(at(3, 1, "c"))
+	list.Sort()
+	if list[0].Message != "a" || list[1].Message != "b" || list[3].Message != "c" {
+		t.Errorf("unexpected order: %v", list)
+	}
+	if s := list.Err().Error(); s != "a (and 3 more errors)" {
+		t.Errorf("unexpected message %q", s)
+	}
+	list.RemoveMultiples()
+	if len(list) != 2 || list[0].Message != "a" || list[1].Message != "c" {
+		t.Errorf("expected one error per line, got %v", list)
+	}
+	if !errors.Is(list[0], SyntaxError) {
+		t.Errorf("expected list errors to keep their kind")
+	}
+}
+
+func Test_ErrorLabels(t *testing.T) {
+	tests := []struct {
+		input string
+		label string
+	}{
+		{"<?php\nfunction f() {\n  if ($a) {\n    foo();", "a.php:3:11: unclosed `{` opened here"},
+		{"<?php\n$a = <<<EOT\nabc\n", "a.php:2:6: heredoc started here"},
+		{"<?php\nfoo(1,\n  [2, 3);", "a.php:3:3: unclosed `[` opened here"},
+		{"<?php\n#[A(1]\nfunction f() {}", "a.php:2:4: unclosed `(` opened here"},
+		{`<?php "{$a`, "a.php:1:8: unclosed `{` opened here"},
+		{"<?php\nfunction f() {\n  foo(1);\n)", ""},
+		{"<?php\n[1 => ];", ""},
+		{"<?php\n$a = 1\n$b = 2;", ""},
+	}
+	for i, tt := range tests {
+		_, err := ParseFile(nil, "a.php", tt.input, 0)
+		if err == nil {
+			t.Fatalf("tests[%d] - expected parser error for %q", i, tt.input)
+		}
+		labels := []string{}
+		for _, l := range err.(ErrorList)[0].Labels {
+			labels = append(labels, l.Pos.String()+": "+l.Message)
+		}
+		if s := strings.Join(labels, "\n"); s != tt.label {
+			t.Errorf("tests[%d] - expected label %q, got %q", i, tt.label, s)
+		}
+	}
+}
+
+func Test_ErrorDiagnostic(t *testing.T) {
+	src := "<?php\nfunction f() {\n  echo 1;\n"
+	fset := token.NewFileSet()
+	_, err := ParseFile(fset, "a.php", src, AllErrors)
+	var out strings.Builder
+	diag.Fprint(&out, fset, err)
+	expected := "error[SyntaxError]: Unclosed '{' on line 2\n" +
+		" --> a.php:4:1\n" +
+		"  |\n" +
+		"2 | function f() {\n" +
+		"  |              - unclosed `{` opened here\n" +
+		"3 |   echo 1;\n" +
+		"4 |\n" +
+		"  | ^\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	// the errors of the lexer are reported as well
+	_, err = ParseFile(nil, "a.php", "<?php /* abc", AllErrors)
+	if ds := diag.FromError(err); len(ds) != 1 || ds[0].Message != "Unterminated comment starting line 1" || ds[0].Pos.Column != 7 {
+		t.Errorf("expected the lexer error, got %v", err)
+	}
+}
@@ -0,0 +1,483 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// Operator precedences, from lowest to highest. The order follows
+// the %left/%right/%precedence declarations of zend_language_parser.y.
+const (
+	_ int = iota
+	precLowest
+	precLogicalOr  // or
+	precLogicalXor // xor
+	precLogicalAnd // and
+	precAssign     // = += -= ...
+	precTernary    // ? :
+	precCoalesce   // ??
+	precBooleanOr  // ||
+	precBooleanAnd // &&
+	precBitwiseOr  // |
+	precBitwiseXor // ^
+	precBitwiseAnd // &
+	precEquality   // == != === !== <>  <=>
+	precCompare    // < <= > >=
+	precConcat     // .
+	precShift      // << >>
+	precSum        // + -
+	precProduct    // * / %
+	precNot        // !
+	precInstanceof // instanceof
+	precPrefix     // ~ ++ -- (int) @ -$a
+	precPow        // **
+	precClone      // clone new
+	precPostfix    // $a++ $a--
+	precCall       // () [] -> ?-> ::
+)
+
+var precedences = map[token.Type]int{
+	token.LogicalOr:              precLogicalOr,
+	token.LogicalXor:             precLogicalXor,
+	token.LogicalAnd:             precLogicalAnd,
+	token.Assign:                 precAssign,
+	token.PlusEqual:              precAssign,
+	token.MinusEqual:             precAssign,
+	token.MulEqual:               precAssign,
+	token.DivEqual:               precAssign,
+	token.ConcatEqual:            precAssign,
+	token.ModEqual:               precAssign,
+	token.AndEqual:               precAssign,
+	token.OrEqual:                precAssign,
+	token.XorEqual:               precAssign,
+	token.SlEqual:                precAssign,
+	token.SrEqual:                precAssign,
+	token.PowEqual:               precAssign,
+	token.CoalesceEqual:          precAssign,
+	token.QuestionMark:           precTernary,
+	token.Coalesce:               precCoalesce,
+	token.BooleanOr:              precBooleanOr,
+	token.BooleanAnd:             precBooleanAnd,
+	token.Bar:                    precBitwiseOr,
+	token.Caret:                  precBitwiseXor,
+	token.Ampersand:              precBitwiseAnd,
+	token.IsEqual:                precEquality,
+	token.IsNotEqual:             precEquality,
+	token.IsIdentical:            precEquality,
+	token.IsNotIdentical:         precEquality,
+	token.Spaceship:              precEquality,
+	token.Lt:                     precCompare,
+	token.IsSmallerOrEqual:       precCompare,
+	token.Gt:                     precCompare,
+	token.IsGreaterOrEqual:       precCompare,
+	token.Dot:                    precConcat,
+	token.Sl:                     precShift,
+	token.Sr:                     precShift,
+	token.Plus:                   precSum,
+	token.Minus:                  precSum,
+	token.Asterisk:               precProduct,
+	token.Slash:                  precProduct,
+	token.Modulo:                 precProduct,
+	token.Instanceof:             precInstanceof,
+	token.Pow:                    precPow,
+	token.Inc:                    precPostfix,
+	token.Dec:                    precPostfix,
+	token.LParen:                 precCall,
+	token.LBracket:               precCall,
+	token.ObjectOperator:         precCall,
+	token.NullsafeObjectOperator: precCall,
+	token.PaamayimNekudotayim:    precCall,
+}
+
+// rightAssociative operators bind their right operand with one level less
+// of precedence, so that `a ?? b ?? c` is read as `a ?? (b ?? c)`.
+var rightAssociative = map[token.Type]bool{
+	token.Coalesce: true,
+	token.Pow:      true,
+}
+
+type (
+	prefixParseFn func() ast.Expression
+	infixParseFn  func(ast.Expression) ast.Expression
+)
+
+func (p *Parser) registerPrefix(t token.Type, fn prefixParseFn) {
+	p.prefixParseFns[t] = fn
+}
+
+func (p *Parser) registerInfix(t token.Type, fn infixParseFn) {
+	p.infixParseFns[t] = fn
+}
+
+func (p *Parser) registerExpressionFns() {
+	p.prefixParseFns = make(map[token.Type]prefixParseFn)
+	p.infixParseFns = make(map[token.Type]infixParseFn)
+
+	p.registerPrefix(token.Variable, p.parseVariable)
+	p.registerPrefix(token.Lnumber, p.parseIntegerLiteral)
+	p.registerPrefix(token.Dnumber, p.parseFloatLiteral)
+	p.registerPrefix(token.ConstantEncapsedString, p.parseStringLiteral)
+	p.registerPrefix(token.DoubleQuotes, p.parseInterpolatedString)
+	p.registerPrefix(token.StartHeredoc, p.parseInterpolatedString)
+	p.registerPrefix(token.Backquote, p.parseInterpolatedString)
+	p.registerPrefix(token.String, p.parseConstant)
+	p.registerPrefix(token.NsSeparator, p.parseConstant)
+	p.registerPrefix(token.Namespace, p.parseConstant)
+	for _, t := range []token.Type{
+		token.Line, token.File, token.Dir, token.ClassC, token.TraitC,
+		token.MethodC, token.FuncC, token.NsC,
+	} {
+		p.registerPrefix(t, p.parseMagicConstant)
+	}
+	p.registerPrefix(token.LParen, p.parseGroupedExpression)
+	p.registerPrefix(token.Function, p.parseClosureExpression)
+	p.registerPrefix(token.Fn, p.parseArrowFunctionExpression)
+	p.registerPrefix(token.Static, p.parseStaticExpression)
+	p.registerPrefix(token.Throw, p.parseThrowExpression)
+	p.registerPrefix(token.LBracket, p.parseArrayExpression)
+	p.registerPrefix(token.Array, p.parseArrayExpression)
+	p.registerPrefix(token.List, p.parseListAssignment)
+	p.registerPrefix(token.Dollar, p.parseDynamicVariable)
+	p.registerPrefix(token.New, p.parseNewExpression)
+	p.registerPrefix(token.Clone, p.parseCloneExpression)
+	p.registerPrefix(token.Attribute, p.parseAttributedExpression)
+	p.registerPrefix(token.Match, p.parseMatchExpression)
+	p.registerPrefix(token.Print, p.parsePrintExpression)
+	p.registerPrefix(token.Yield, p.parseYieldExpression)
+	p.registerPrefix(token.YieldFrom, p.parseYieldFromExpression)
+	p.registerPrefix(token.Isset, p.parseIssetExpression)
+	p.registerPrefix(token.Empty, p.parseEmptyExpression)
+	p.registerPrefix(token.Exit, p.parseExitExpression)
+	for _, t := range []token.Type{
+		token.Include, token.IncludeOnce, token.Require, token.RequireOnce, token.Eval,
+	} {
+		p.registerPrefix(t, p.parseIncludeOrEvalExpression)
+	}
+
+	for _, t := range []token.Type{
+		token.Bang, token.Tilde, token.Minus, token.Plus, token.At,
+	} {
+		p.registerPrefix(t, p.parsePrefixExpression)
+	}
+	for _, t := range []token.Type{token.Inc, token.Dec} {
+		p.registerPrefix(t, p.parsePrefixExpression)
+		p.registerInfix(t, p.parsePostfixExpression)
+	}
+	for _, t := range []token.Type{
+		token.IntCast, token.DoubleCast, token.StringCast, token.ArrayCast,
+		token.ObjectCast, token.BoolCast, token.UnsetCast,
+	} {
+		p.registerPrefix(t, p.parseCastExpression)
+	}
+
+	for t := range precedences {
+		if _, ok := p.infixParseFns[t]; !ok {
+			p.registerInfix(t, p.parseInfixExpression)
+		}
+	}
+	p.registerInfix(token.Instanceof, p.parseInstanceofExpression)
+	p.registerInfix(token.QuestionMark, p.parseTernaryExpression)
+	p.registerInfix(token.Assign, p.parseAssignExpression)
+	for t := range compoundOperators {
+		p.registerInfix(t, p.parseCompoundAssignExpression)
+	}
+	p.registerInfix(token.LParen, p.parseCallExpression)
+	p.registerInfix(token.LBracket, p.parseIndexExpression)
+	p.registerInfix(token.LBrace, p.parseBraceIndexExpression)
+	p.registerInfix(token.ObjectOperator, p.parseObjectMemberExpression)
+	p.registerInfix(token.NullsafeObjectOperator, p.parseObjectMemberExpression)
+	p.registerInfix(token.PaamayimNekudotayim, p.parseStaticMemberExpression)
+}
+
+func (p *Parser) peekPrecedence() int {
+	if prec, ok := precedences[p.peekToken.Type]; ok {
+		return prec
+	}
+	return precLowest
+}
+
+func (p *Parser) curPrecedence() int {
+	if prec, ok := precedences[p.curToken.Type]; ok {
+		return prec
+	}
+	return precLowest
+}
+
+// parseExpression is the entry of the Pratt parser, on return
+// curToken is the last token of the expression.
+func (p *Parser) parseExpression(precedence int) ast.Expression {
+	prefix := p.prefixParseFns[p.curToken.Type]
+	if prefix == nil {
+		p.unexpectedError()
+		return nil
+	}
+	from := p.curToken
+	left := prefix()
+	if p.error != nil {
+		return nil
+	}
+	p.finish(left, from)
+	return p.parseInfixExpressions(left, from, precedence)
+}
+
+// parseInfixExpressions continues parsing the operators following an
+// already parsed left operand, from is the first token of the operand.
+func (p *Parser) parseInfixExpressions(left ast.Expression, from token.Token, precedence int) ast.Expression {
+	for !p.peekTokenIs(token.Semicolon) && (precedence < p.peekPrecedence() || p.peekAssignsTo(left) || p.peekBraceIndex(left)) {
+		infix := p.infixParseFns[p.peekToken.Type]
+		if infix == nil {
+			break
+		}
+		p.nextToken()
+		left = infix(left)
+		if p.error != nil {
+			return nil
+		}
+		p.finish(left, from)
+	}
+	p.exprEnd = true
+	return left
+}
+
+// parseExpressionList parses comma separated expressions until the end
+// token, the end token is consumed. An empty list is allowed.
+func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
+	list := []ast.Expression{}
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+	p.nextToken()
+	list = append(list, p.parseExpression(precLowest))
+	for p.error == nil && p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(precLowest))
+	}
+	if p.error != nil || !p.expectPeek(end) {
+		return nil
+	}
+	return list
+}
+
+func (p *Parser) newBaseNode() *ast.BaseNode {
+	return &ast.BaseNode{Token: p.curToken}
+}
+
+func (p *Parser) parseVariable() ast.Expression {
+	v := &ast.Variable{BaseNode: p.newBaseNode(), Name: p.curToken.Literal[1:]}
+	p.finish(v, v.Token)
+	return v
+}
+
+func (p *Parser) parseIntegerLiteral() ast.Expression {
+	lit := p.curToken.Literal
+	value, err := strconv.ParseInt(lit, 0, 64)
+	if err != nil {
+		if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+			// integer overflow turns into float like PHP does
+			return &ast.FloatLiteral{BaseNode: p.newBaseNode(), Value: parseOverflowInteger(lit)}
+		}
+		p.errorf(SyntaxError, "Invalid numeric literal")
+		return nil
+	}
+	return &ast.IntegerLiteral{BaseNode: p.newBaseNode(), Value: int(value)}
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	// Out of range literals still parse: PHP reads 1e400 as INF, like the
+	// ±Inf strconv returns alongside ErrRange.
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		p.errorf(SyntaxError, "Invalid numeric literal")
+		return nil
+	}
+	return &ast.FloatLiteral{BaseNode: p.newBaseNode(), Value: value}
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{BaseNode: p.newBaseNode(), Value: unquote(p.curToken.Literal)}
+}
+
+// parseConstant parses a possibly qualified constant name, `true`, `false`
+// and `null` (optionally fully qualified) are literals.
+func (p *Parser) parseConstant() ast.Expression {
+	if p.curTokenIs(token.Namespace) && !p.peekTokenIs(token.NsSeparator) {
+		p.unexpectedError()
+		return nil
+	}
+	base := p.newBaseNode()
+	name := p.parseName()
+	if name == "" {
+		return nil
+	}
+	switch lower := strings.ToLower(strings.TrimPrefix(name, "\\")); lower {
+	case "true", "false":
+		return &ast.BooleanExpression{BaseNode: p.newBaseNode(), Value: lower == "true"}
+	case "null":
+		return &ast.NullExpression{BaseNode: p.newBaseNode(), Value: p.curToken.Literal}
+	}
+	return &ast.Constant{BaseNode: base, Value: name, IsNamespace: strings.Contains(name, "\\")}
+}
+
+// parseMagicConstant parses `__LINE__`, `__DIR__` and the other magic
+// constants, which are keywords rather than names.
+func (p *Parser) parseMagicConstant() ast.Expression {
+	return &ast.Constant{BaseNode: p.newBaseNode(), Value: p.curToken.Literal}
+}
+
+// parseThrowExpression parses PHP 8's `throw expr` in an expression
+// context, it binds looser than any operator.
+func (p *Parser) parseThrowExpression() ast.Expression {
+	exp := &ast.ThrowExpression{BaseNode: p.newBaseNode()}
+	if !p.requireVersion(800, "throw as an expression") {
+		return nil
+	}
+	p.nextToken()
+	if exp.Expression = p.parseExpression(precLowest); exp.Expression == nil {
+		return nil
+	}
+	return exp
+}
+
+var includeKinds = map[token.Type]ast.IncludeKind{
+	token.Include:     ast.Include,
+	token.IncludeOnce: ast.IncludeOnce,
+	token.Require:     ast.Require,
+	token.RequireOnce: ast.RequireOnce,
+	token.Eval:        ast.Eval,
+}
+
+// parseIncludeOrEvalExpression parses `include expr` and its variants, which
+// bind looser than any operator like in PHP, and `eval(expr)`.
+func (p *Parser) parseIncludeOrEvalExpression() ast.Expression {
+	exp := &ast.IncludeOrEvalExpression{BaseNode: p.newBaseNode(), Type: includeKinds[p.curToken.Type]}
+	if exp.Type == ast.Eval {
+		exp.Expression = p.parseParenExpression()
+	} else {
+		p.nextToken()
+		exp.Expression = p.parseExpression(precLowest)
+	}
+	if exp.Expression == nil {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	p.nextToken()
+	exp := p.parseExpression(precLowest)
+	if p.error != nil || !p.expectPeek(token.RParen) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parsePrefixExpression() ast.Expression {
+	exp := &ast.PrefixExpression{BaseNode: p.newBaseNode(), Operator: p.curToken.Literal}
+	precedence := precPrefix
+	if p.curTokenIs(token.Bang) {
+		precedence = precNot
+	}
+	incDec := p.curTokenIsAny(token.Inc, token.Dec)
+	p.nextToken()
+	exp.Right = p.parseExpression(precedence)
+	if p.error != nil {
+		return nil
+	}
+	if incDec && !p.checkWritable(exp.Right) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parsePostfixExpression(left ast.Expression) ast.Expression {
+	if !p.checkWritable(left) {
+		return nil
+	}
+	return &ast.PostfixExpression{BaseNode: p.newBaseNode(), Left: left, Operator: p.curToken.Literal}
+}
+
+func (p *Parser) parseCastExpression() ast.Expression {
+	if p.curTokenIs(token.UnsetCast) && p.Mode.version() >= 800 {
+		p.errorf(SyntaxError, "The (unset) cast is no longer supported")
+		return nil
+	}
+	exp := &ast.CastExpression{BaseNode: p.newBaseNode(), Type: castTypes[p.curToken.Type]}
+	p.nextToken()
+	exp.Expression = p.parseExpression(precPrefix)
+	if p.error != nil {
+		return nil
+	}
+	return exp
+}
+
+var castTypes = map[token.Type]string{
+	token.IntCast:    "int",
+	token.DoubleCast: "float",
+	token.StringCast: "string",
+	token.ArrayCast:  "array",
+	token.ObjectCast: "object",
+	token.BoolCast:   "bool",
+	token.UnsetCast:  "unset",
+}
+
+func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	exp := &ast.InfixExpression{
+		BaseNode: p.newBaseNode(),
+		Left:     left,
+		Operator: p.curToken.Literal,
+	}
+	precedence := p.curPrecedence()
+	if rightAssociative[p.curToken.Type] {
+		precedence--
+	}
+	p.nextToken()
+	exp.Right = p.parseExpression(precedence)
+	if p.error != nil {
+		return nil
+	}
+	return exp
+}
+
+// parseInstanceofExpression parses `expr instanceof class`, the class may
+// be `static` which elsewhere starts a static closure or `static::`.
+func (p *Parser) parseInstanceofExpression(left ast.Expression) ast.Expression {
+	if !p.peekTokenIs(token.Static) {
+		return p.parseInfixExpression(left)
+	}
+	exp := &ast.InfixExpression{BaseNode: p.newBaseNode(), Left: left, Operator: p.curToken.Literal}
+	p.nextToken()
+	tok := p.curToken
+	class := &ast.Constant{BaseNode: p.newBaseNode(), Value: tok.Literal}
+	p.finish(class, tok)
+	if exp.Right = p.parseInfixExpressions(class, tok, precInstanceof); p.error != nil {
+		return nil
+	}
+	return exp
+}
+
+// parseTernaryExpression parses `cond ? a : b` and the short form `cond ?: b`
+func (p *Parser) parseTernaryExpression(condition ast.Expression) ast.Expression {
+	exp := &ast.TernaryExpression{BaseNode: p.newBaseNode(), Condition: condition}
+	if !p.peekTokenIs(token.Colon) {
+		p.nextToken()
+		exp.Consequence = p.parseExpression(precLowest)
+		if p.error != nil {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.Colon) {
+		return nil
+	}
+	p.nextToken()
+	exp.Alternative = p.parseExpression(precTernary)
+	if p.error != nil {
+		return nil
+	}
+	return exp
+}
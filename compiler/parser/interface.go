@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/lexer"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// readSource returns the source given as a string, []byte or io.Reader,
+// or the content of the named file when src is nil.
+func readSource(filename string, src interface{}) (string, error) {
+	if src == nil {
+		buf, err := ioutil.ReadFile(filename)
+		return string(buf), err
+	}
+	switch s := src.(type) {
+	case string:
+		return s, nil
+	case []byte:
+		return string(s), nil
+	case io.Reader:
+		buf, err := ioutil.ReadAll(s)
+		return string(buf), err
+	}
+	return "", errors.New("invalid source")
+}
+
+// ParseFile parses the source of a PHP file. src may be a string, []byte
+// or io.Reader, when it is nil the file named filename is read. The file
+// is added to fset unless fset is nil.
+//
+// The returned error is an ErrorList sorted by position. In AllErrors mode
+// it holds every error and the partial program is returned along with it,
+// otherwise parsing stops at the first error and the program is nil.
+func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode) (*ast.Program, error) {
+	text, err := readSource(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	if fset != nil {
+		fset.AddFile(filename, text)
+	}
+	return parseSource(lexer.New(text), filename, mode)
+}
+
+// ParseStatements parses a list of statements written without the PHP
+// opening tag, e.g. `$a = 1; echo $a;`.
+func ParseStatements(src string, mode Mode) ([]ast.Statement, error) {
+	program, err := parseSource(lexer.NewScript(src), "", mode)
+	if program == nil {
+		return nil, err
+	}
+	return program.Statements, err
+}
+
+// ParseExpr parses a single expression written without the PHP opening
+// tag, e.g. `$a + 1`.
+func ParseExpr(src string) (ast.Expression, error) {
+	l := lexer.NewScript(src)
+	go l.Run()
+	defer l.Drain()
+
+	p := newParser(l, "", 0)
+	exp := p.parseExpression(precLowest)
+	if p.error == nil && !p.peekTokenIs(token.End) {
+		p.peekError(token.End)
+	}
+	if p.error != nil {
+		return nil, ErrorList{p.error}
+	}
+	return exp, nil
+}
+
+// parseSource runs l and parses its tokens as a program. Before PHP 8.0
+// `#[` starts a comment.
+func parseSource(l *lexer.Lexer, filename string, mode Mode) (*ast.Program, error) {
+	l.HashBracketComments = mode.version() < 800
+	go l.Run()
+	defer l.Drain()
+
+	p := newParser(l, filename, mode)
+	program, err := p.ParseProgram()
+	if mode&AllErrors != 0 {
+		p.errs.Sort()
+		return program, p.errs.Err()
+	}
+	if err != nil {
+		return nil, ErrorList{err}
+	}
+	return program, nil
+}
@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+func Test_ParseFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "luban")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.php")
+	if err := ioutil.WriteFile(filename, []byte("<?php echo 1;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	for i, src := range []interface{}{nil, "<?php echo 1;", []byte("<?php echo 1;"), strings.NewReader("<?php echo 1;")} {
+		program, err := ParseFile(fset, filename, src, 0)
+		if err != nil {
+			t.Fatalf("tests[%d] - parser error: %s", i, err)
+		}
+		if s := program.String(); s != "echo 1;" {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, "echo 1;", s)
+		}
+	}
+	if f := fset.File(filename); f == nil || f.Line(1) != "<?php echo 1;" {
+		t.Errorf("expected the file to be added to the file set")
+	}
+	if _, err := ParseFile(nil, filepath.Join(dir, "missing.php"), nil, 0); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
+
+func Test_ParseFileErrors(t *testing.T) {
+	src := "<?php\n$a = ;\n$b = 1;\nfoo(;\n"
+	program, err := ParseFile(nil, "a.php", src, 0)
+	if program != nil {
+		t.Errorf("expected no program without AllErrors")
+	}
+	list, ok := err.(ErrorList)
+	if !ok || len(list) != 1 || list[0].Pos.String() != "a.php:2:6" {
+		t.Fatalf("expected one error at a.php:2:6, got %v", err)
+	}
+	if !errors.Is(err, UnexpectedTokenError) {
+		t.Errorf("expected an UnexpectedTokenError, got %s", list[0].Kind)
+	}
+
+	program, err = ParseFile(nil, "a.php", src, AllErrors)
+	if program == nil || len(program.Statements) != 3 {
+		t.Fatalf("expected a partial program, got %v", program)
+	}
+	if list := err.(ErrorList); len(list) != 2 || list[1].Pos.Line != 4 {
+		t.Errorf("expected 2 errors, got %v", err)
+	}
+}
+
+func Test_ParseExpr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"$a + 1 * 2", "($a + (1 * 2))"},
+		{"foo($a)->b", "foo($a)->b"},
+		{"$a = [1, 2]", "($a = [1, 2])"},
+	}
+	for i, tt := range tests {
+		exp, err := ParseExpr(tt.input)
+		if err != nil {
+			t.Fatalf("tests[%d] - parser error: %s", i, err)
+		}
+		if exp.String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, exp.String())
+		}
+	}
+	for _, input := range []string{"", "$a $b", "1;"} {
+		if _, err := ParseExpr(input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func Test_ParseStatements(t *testing.T) {
+	stmts, err := ParseStatements("$a = 1; echo $a;", 0)
+	if err != nil {
+		t.Fatalf("parser error: %s", err)
+	}
+	if len(stmts) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(stmts))
+	}
+	if _, ok := stmts[1].(*ast.EchoStatement); !ok {
+		t.Errorf("expected *ast.EchoStatement, got %T", stmts[1])
+	}
+	stmts, err = ParseStatements("$a = ; echo 1;", AllErrors)
+	if err == nil || len(stmts) != 2 {
+		t.Errorf("expected a partial list with an error, got %d statements and %v", len(stmts), err)
+	}
+}
+
+func Test_ParseComments(t *testing.T) {
+	src := "<?php\n// one\n$a = 1; /* two */\n/** three */\nfunction f() {}"
+	program, err := ParseFile(nil, "a.php", src, ParseComments)
+	if err != nil {
+		t.Fatalf("parser error: %s", err)
+	}
+	comments := []string{}
+	for _, c := range program.Comments {
+		comments = append(comments, strings.TrimSpace(c.Literal))
+	}
+	if s := strings.Join(comments, ","); s != "// one,/* two */,/** three */" {
+		t.Errorf("unexpected comments %q", s)
+	}
+	if len(program.Trivia) != 0 {
+		t.Errorf("expected no trivia, got %d tokens", len(program.Trivia))
+	}
+
+	program, _ = ParseFile(nil, "a.php", src, ParseTrivia)
+	var out strings.Builder
+	for _, tok := range program.Trivia {
+		out.WriteString(tok.Literal)
+	}
+	if s := out.String(); !strings.HasPrefix(s, "<?php\n// one") || !strings.Contains(s, "/** three */") {
+		t.Errorf("unexpected trivia %q", s)
+	}
+	if program.Comments != nil {
+		t.Errorf("expected no comments")
+	}
+}
+
+func Test_TargetVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		mode    Mode
+		message string
+	}{
+		{`<?php $a?->b;`, PHP74, "Cannot use the nullsafe operator before PHP 8.0"},
+		{`<?php foo(a: 1);`, PHP74, "Cannot use named arguments before PHP 8.0"},
+		{`<?php function f(int|string $a) {}`, PHP74, "Cannot use union types before PHP 8.0"},
+		{`<?php class A { function __construct(public $a) {} }`, PHP74, "Cannot use constructor property promotion before PHP 8.0"},
+		{`<?php match ($a) {};`, PHP74, `unexpected token "}"`},
+		{`<?php strlen(...);`, PHP80, "Cannot use first-class callable syntax before PHP 8.1"},
+		{`<?php enum A {}`, PHP80, `unexpected identifier "A"`},
+		{`<?php try {} catch (E) {}`, PHP74, "Cannot use catch without a variable before PHP 8.0"},
+		{`<?php $a = $b ?? throw $e;`, PHP74, "Cannot use throw as an expression before PHP 8.0"},
+		{`<?php class A { function f(): static {} }`, PHP74, "Cannot use the static return type before PHP 8.0"},
+		{`<?php function f(mixed $a) {}`, PHP74, "Cannot use the mixed type before PHP 8.0"},
+		{`<?php function f(): never {}`, PHP80, "Cannot use the never type before PHP 8.1"},
+		{`<?php $a::class;`, PHP74, "Cannot use ::class on objects before PHP 8.0"},
+		{`<?php function f($a,) {}`, PHP74, "Cannot use a trailing comma in parameter lists before PHP 8.0"},
+		{`<?php function () use ($a,) {};`, PHP74, "Cannot use a trailing comma in closure use lists before PHP 8.0"},
+	}
+	for i, tt := range tests {
+		_, err := ParseFile(nil, "a.php", tt.input, tt.mode)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %v", i, tt.message, err)
+		}
+		if _, err := ParseFile(nil, "a.php", tt.input, 0); err != nil {
+			t.Errorf("tests[%d] - unexpected error for the latest version: %s", i, err)
+		}
+	}
+	if _, err := ParseFile(nil, "a.php", `<?php match($a); enum($b); readonly($c); A::class; static::class; throw $e; f(1,);`, PHP74); err != nil {
+		t.Errorf("expected keywords of later versions to be names, got %s", err)
+	}
+
+	// the syntax removed in PHP 8.0
+	program, err := ParseFile(nil, "a.php", "<?php $a = (unset) $b; $s{0}; $s{$i}{1} = 1; ($a . $b){0};", PHP74)
+	if err != nil {
+		t.Fatalf("parser error: %s", err)
+	}
+	expected := "($a = (unset)$b)$s[0]($s[$i][1] = 1)($a . $b)[0]"
+	if s := program.String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+	for _, input := range []string{"<?php $a = (unset) $b;", "<?php $s{0};"} {
+		if _, err := ParseFile(nil, "a.php", input, PHP80); err == nil {
+			t.Errorf("expected an error for %q in PHP 8.0", input)
+		}
+	}
+	if program, err := ParseFile(nil, "a.php", "<?php #[A] function f() {}\n#[B]\nfunction g() {}", PHP74|ParseComments); err != nil {
+		t.Errorf("parser error: %s", err)
+	} else if len(program.Statements) != 1 || len(program.Comments) != 2 || program.Statements[0].(*ast.FunctionStatement).Attributes != nil {
+		t.Errorf("expected #[ to start a comment in PHP 7.4, got %q", program.String())
+	}
+}
+
+func Test_ParseFileStopsLexer(t *testing.T) {
+	src := "<?php $a = ;" + strings.Repeat(" $b = 1;", 100)
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		ParseFile(nil, "a.php", src, 0)
+	}
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected the lexer goroutines to exit, %d left", n-before)
+	}
+}
@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// kinds of the statements a break, continue or goto may jump out of
+const (
+	jumpLoop = iota
+	jumpSwitch
+	jumpFinally
+)
+
+// jumpContext is a loop, switch or finally block enclosing a statement,
+// id tells apart the sibling blocks of the same kind.
+type jumpContext struct {
+	kind int
+	id   int
+}
+
+// jumpTarget is a label or a goto with the blocks enclosing it
+type jumpTarget struct {
+	name string
+	tok  token.Token
+	path []jumpContext
+}
+
+// jumpScope holds the jump state of a function body or of the top level
+// code of a file, labels are not visible outside of it.
+type jumpScope struct {
+	stack  []jumpContext
+	nextID int
+	labels map[string]*jumpTarget
+	gotos  []*jumpTarget
+	// function is set for the body of a function, where yield is allowed
+	function bool
+}
+
+func newJumpScope() *jumpScope {
+	return &jumpScope{labels: map[string]*jumpTarget{}}
+}
+
+// enterJump is called before the body of a loop, switch or finally block
+func (p *Parser) enterJump(kind int) {
+	s := p.jumps
+	s.nextID++
+	s.stack = append(s.stack, jumpContext{kind: kind, id: s.nextID})
+}
+
+func (p *Parser) leaveJump() {
+	s := p.jumps
+	s.stack = s.stack[:len(s.stack)-1]
+}
+
+// enterFunction starts the jump scope of a function body, the returned
+// scope is to be given back to leaveFunction.
+func (p *Parser) enterFunction() *jumpScope {
+	outer := p.jumps
+	p.jumps = newJumpScope()
+	p.jumps.function = true
+	return outer
+}
+
+// leaveFunction resolves the gotos of the function body and restores the
+// outer scope, it reports false on error.
+func (p *Parser) leaveFunction(outer *jumpScope) bool {
+	ok := p.error == nil && p.resolveGotos()
+	p.jumps = outer
+	return ok
+}
+
+// resolveGotos checks the gotos of the current scope against its labels
+// like PHP's compiler does.
+func (p *Parser) resolveGotos() bool {
+	for _, g := range p.jumps.gotos {
+		label := p.jumps.labels[g.name]
+		if label == nil {
+			p.errorAt(g.tok, SyntaxError, "'goto' to undefined label '%s'", g.name)
+			return false
+		}
+		common := 0
+		for common < len(g.path) && common < len(label.path) && g.path[common] == label.path[common] {
+			common++
+		}
+		for _, c := range label.path[common:] {
+			if c.kind == jumpFinally {
+				p.errorAt(g.tok, SyntaxError, "jump into a finally block is disallowed")
+			} else {
+				p.errorAt(g.tok, SyntaxError, "'goto' into loop or switch statement is disallowed")
+			}
+			return false
+		}
+		for _, c := range g.path[common:] {
+			if c.kind == jumpFinally {
+				p.errorAt(g.tok, SyntaxError, "jump out of a finally block is disallowed")
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// currentPath returns a copy of the blocks enclosing the current statement
+func (p *Parser) currentPath() []jumpContext {
+	return append([]jumpContext(nil), p.jumps.stack...)
+}
+
+// parseLabelStatement parses `name:`, curToken is the name
+func (p *Parser) parseLabelStatement() ast.Statement {
+	stmt := &ast.LabelStatement{BaseNode: p.newBaseNode(), Name: p.curToken.Literal}
+	if p.jumps.labels[stmt.Name] != nil {
+		p.errorf(SyntaxError, "Label '%s' already defined", stmt.Name)
+		return nil
+	}
+	p.jumps.labels[stmt.Name] = &jumpTarget{name: stmt.Name, tok: p.curToken, path: p.currentPath()}
+	p.nextToken()
+	return stmt
+}
+
+// parseGotoStatement parses `goto name;`, the label is resolved at the end
+// of the function body.
+func (p *Parser) parseGotoStatement() ast.Statement {
+	stmt := &ast.GotoStatement{BaseNode: p.newBaseNode()}
+	if !p.expectPeek(token.String) {
+		return nil
+	}
+	stmt.Label = p.curToken.Literal
+	p.jumps.gotos = append(p.jumps.gotos, &jumpTarget{name: stmt.Label, tok: p.curToken, path: p.currentPath()})
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+// checkJumpLevel validates the level of a break or continue, name is the
+// keyword used in the messages.
+func (p *Parser) checkJumpLevel(name string, level ast.Expression) bool {
+	depth := 1
+	if level != nil {
+		lit, ok := level.(*ast.IntegerLiteral)
+		if !ok {
+			p.errorf(SyntaxError, "'%s' operator with non-integer operand is no longer supported", name)
+			return false
+		}
+		if lit.Value < 1 {
+			p.errorf(SyntaxError, "'%s' operator accepts only positive integers", name)
+			return false
+		}
+		depth = lit.Value
+	}
+
+	stack := p.jumps.stack
+	loops := 0
+	for _, c := range stack {
+		if c.kind != jumpFinally {
+			loops++
+		}
+	}
+	if loops == 0 {
+		p.errorf(SyntaxError, "'%s' not in the 'loop' or 'switch' context", name)
+		return false
+	}
+	if depth > loops {
+		p.errorf(SyntaxError, "Cannot '%s' %d level%s", name, depth, plural(depth))
+		return false
+	}
+	for i := len(stack) - 1; depth > 0; i-- {
+		if stack[i].kind == jumpFinally {
+			p.errorf(SyntaxError, "jump out of a finally block is disallowed")
+			return false
+		}
+		depth--
+	}
+	return true
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
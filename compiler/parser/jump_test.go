@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_GotoStatement(t *testing.T) {
+	program := parse(t, `<?php
+goto end;
+while (1) {
+	inner:
+	if ($a) goto inner;
+	goto end;
+}
+end:
+echo 1;
+function f() { goto end; end: }
+`)
+	// only the simple statements are compared, "" skips a statement
+	expected := []string{"goto end;", "", "end:", "echo 1;", ""}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("expected %d statements, got %d", len(expected), len(program.Statements))
+	}
+	for i, stmt := range program.Statements {
+		if expected[i] != "" && stmt.String() != expected[i] {
+			t.Errorf("statement %d: expected=%q, got=%q", i, expected[i], stmt.String())
+		}
+	}
+}
+
+func Test_JumpErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "'break' not in the 'loop' or 'switch' context in php shell code on line 1"},
+		{"\ncontinue;", "'continue' not in the 'loop' or 'switch' context in php shell code on line 2"},
+		{"while (1) { function f() { break; } }", "'break' not in the 'loop' or 'switch' context"},
+		{"while (1) { $f = function () { break; }; }", "'break' not in the 'loop' or 'switch' context"},
+		{"while (1) {\n break 2;\n}", "Cannot 'break' 2 levels in php shell code on line 2"},
+		{"foreach ($a as $b) { switch ($b) { case 1: continue 3; } }", "Cannot 'continue' 3 levels"},
+		{"while (1) { break 0; }", "'break' operator accepts only positive integers"},
+		{"while (1) { continue -1; }", "'continue' operator with non-integer operand is no longer supported"},
+		{"while (1) { break $a; }", "'break' operator with non-integer operand is no longer supported"},
+		{"while (1) { try { } finally { break; } }", "jump out of a finally block is disallowed"},
+		{"\ngoto a;\nwhile (1) { a: }", "'goto' into loop or switch statement is disallowed in php shell code on line 2"},
+		{"goto a; switch ($b) { case 1: a: }", "'goto' into loop or switch statement is disallowed"},
+		{"while (1) { a: } while (1) { goto a; }", "'goto' into loop or switch statement is disallowed"},
+		{"goto a; try { } finally { a: }", "jump into a finally block is disallowed"},
+		{"try { } finally { goto a; } a:", "jump out of a finally block is disallowed"},
+		{"\n\ngoto a;", "'goto' to undefined label 'a' in php shell code on line 3"},
+		{"a: function f() { goto a; }", "'goto' to undefined label 'a'"},
+		{"a: a:", "Label 'a' already defined"},
+	}
+	for _, tt := range tests {
+		err := parseError(t, "<?php "+tt.input)
+		if !strings.Contains(err.Message, tt.expected) {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
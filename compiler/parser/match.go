@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// parseMatchExpression parses
+//
+//	match (expr) { cond, ... => expr, default => expr, }
+//
+// trailing commas are allowed after the conditions and the arms.
+func (p *Parser) parseMatchExpression() ast.Expression {
+	exp := &ast.MatchExpression{BaseNode: p.newBaseNode(), Arms: []*ast.MatchArm{}}
+	if exp.Subject = p.parseParenExpression(); exp.Subject == nil {
+		return nil
+	}
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	hasDefault := false
+	for !p.peekTokenIs(token.RBrace) {
+		p.nextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		if arm.IsDefault() {
+			if hasDefault {
+				p.errorf(SyntaxError, "Match expressions may only contain one default arm")
+				return nil
+			}
+			hasDefault = true
+		}
+		exp.Arms = append(exp.Arms, arm)
+		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	p.nextToken()
+	return exp
+}
+
+// parseMatchArm parses `cond, ... => expr` or `default => expr`
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{BaseNode: p.newBaseNode()}
+	if p.curTokenIs(token.Default) {
+		if p.peekTokenIs(token.Comma) { // default, => is allowed
+			p.nextToken()
+		}
+	} else {
+		arm.Conditions = []ast.Expression{}
+		for {
+			cond := p.parseExpression(precLowest)
+			if cond == nil {
+				return nil
+			}
+			arm.Conditions = append(arm.Conditions, cond)
+			if !p.peekTokenIs(token.Comma) {
+				break
+			}
+			p.nextToken()
+			if p.peekTokenIs(token.DoubleArrow) {
+				break
+			}
+			p.nextToken()
+		}
+	}
+	if !p.expectPeek(token.DoubleArrow) {
+		return nil
+	}
+	p.nextToken()
+	if arm.Body = p.parseExpression(precLowest); arm.Body == nil {
+		return nil
+	}
+	p.finish(arm, arm.Token)
+	return arm
+}
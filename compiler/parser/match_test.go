@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_MatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`<?php match ($a) { 1, 2 => $b, default => $c };`, "match ($a) { 1, 2 => $b, default => $c }"},
+		{`<?php match (true) { $a > 1, => 1, default, => 2, };`, "match (true) { ($a > 1) => 1, default => 2 }"},
+		{`<?php $x = match ($a) {};`, "($x = match ($a) { })"},
+		{`<?php match ($a) { 1 => match ($b) { default => 2 } };`, "match ($a) { 1 => match ($b) { default => 2 } }"},
+	}
+	for i, tt := range tests {
+		program := parse(t, tt.input)
+		if s := program.Statements[0].String(); s != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, s)
+		}
+	}
+
+	program := parse(t, `<?php match ($a) { 1, 2 => $b, default => $c };`)
+	match, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("expected *ast.MatchExpression, got %T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if len(match.Arms) != 2 || len(match.Arms[0].Conditions) != 2 || !match.Arms[1].IsDefault() {
+		t.Errorf("unexpected arms: %s", match)
+	}
+}
+
+func Test_MatchErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`<?php match ($a) { default => 1, default => 2 };`, "Match expressions may only contain one default arm"},
+		{`<?php match ($a) { 1 => 2; };`, "unexpected token \";\""},
+		{`<?php match ($a) { 1 };`, "unexpected token \"}\""},
+		{`<?php match { 1 => 2 };`, "unexpected token \"{\", expecting \"(\""},
+	}
+	for i, tt := range tests {
+		err := parseError(t, tt.input)
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %q", i, tt.message, err.Message)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// namespace declaration styles of a file, they can't be mixed
+const (
+	namespaceNone = iota
+	namespaceUnbraced
+	namespaceBraced
+)
+
+// parseTopStatement parses the statements only allowed at the top level of
+// a file or of a namespace: namespace, use and const.
+func (p *Parser) parseTopStatement() ast.Statement {
+	var stmt ast.Statement
+	switch p.curToken.Type {
+	case token.Namespace:
+		if p.peekTokenIs(token.NsSeparator) { // namespace\foo();
+			break
+		}
+		return p.parseNamespaceStatement()
+	case token.Declare:
+		stmt := p.parseDeclareStatement(!p.hasCode && !p.inNamespace)
+		if ds, ok := stmt.(*ast.DeclareStatement); ok && ds.Body != nil {
+			p.hasCode = true
+		}
+		return stmt
+	case token.Use:
+		stmt = p.parseUseStatement()
+	case token.Const:
+		stmt = p.parseConstStatement()
+	}
+	if stmt == nil && p.error == nil {
+		stmt = p.parseStatement()
+	}
+	if stmt != nil {
+		if p.namespaceKind == namespaceBraced && !p.inNamespace {
+			p.errorf(SyntaxError, "No code may exist outside of namespace {}")
+			return nil
+		}
+		p.hasCode = true
+	}
+	return stmt
+}
+
+// parseNamespaceStatement parses `namespace Name;` and `namespace [Name] { ... }`
+func (p *Parser) parseNamespaceStatement() ast.Statement {
+	stmt := &ast.NamespaceStatement{BaseNode: p.newBaseNode()}
+	if !p.peekTokenIs(token.LBrace) {
+		p.nextToken()
+		if stmt.Name = p.parseName(); stmt.Name == "" {
+			return nil
+		}
+	}
+	braced := p.peekTokenIs(token.LBrace)
+
+	if p.namespaceKind == namespaceNone || p.namespaceKind == namespaceUnbraced {
+		if p.namespaceKind == namespaceUnbraced && braced {
+			p.errorf(SyntaxError, "Cannot mix bracketed namespace declarations with unbracketed namespace declarations")
+			return nil
+		}
+	} else if !braced {
+		p.errorf(SyntaxError, "Cannot mix bracketed namespace declarations with unbracketed namespace declarations")
+		return nil
+	} else if p.inNamespace {
+		p.errorf(SyntaxError, "Namespace declarations cannot be nested")
+		return nil
+	}
+	if p.namespaceKind == namespaceNone && p.hasCode {
+		p.errorf(SyntaxError, "Namespace declaration statement has to be the very first statement or after any declare call in the script")
+		return nil
+	}
+	if strings.ToLower(stmt.Name) == "namespace" {
+		p.errorf(SyntaxError, "Cannot use '%s' as namespace name", stmt.Name)
+		return nil
+	}
+	p.useAliases = map[string]bool{}
+	p.hasCode = true
+
+	if !braced {
+		p.namespaceKind = namespaceUnbraced
+		if !p.expectSemicolon() {
+			return nil
+		}
+		return stmt
+	}
+
+	p.namespaceKind = namespaceBraced
+	p.inNamespace = true
+	p.nextToken()
+	stmt.Body = &ast.BlockStatement{BaseNode: p.newBaseNode(), Statements: []ast.Statement{}}
+	depth := p.depth
+	p.nextToken()
+	for !p.curTokenIs(token.RBrace) {
+		if p.curTokenIs(token.End) {
+			p.unexpectedError()
+			return nil
+		}
+		from := p.curToken
+		s := p.parseTopStatement()
+		if p.error != nil {
+			bad, closed := p.recoverStatement(from, depth, token.RBrace)
+			if bad == nil {
+				return nil
+			}
+			stmt.Body.Statements = append(stmt.Body.Statements, bad)
+			if closed {
+				continue
+			}
+		} else if s != nil {
+			p.finish(s, from)
+			stmt.Body.Statements = append(stmt.Body.Statements, s)
+		}
+		p.nextToken()
+	}
+	p.finish(stmt.Body, stmt.Body.Token)
+	p.inNamespace = false
+	return stmt
+}
+
+// parseUseStatement parses
+//
+//	use [function|const] A\B [as C], ...;
+//	use [function|const] A\{[function|const] B [as C], ...};
+func (p *Parser) parseUseStatement() ast.Statement {
+	stmt := &ast.UseStatement{BaseNode: p.newBaseNode()}
+	p.nextToken()
+	stmt.Type = p.parseUseKind()
+
+	for {
+		from := p.curToken
+		item := &ast.UseItem{BaseNode: p.newBaseNode()}
+		name, group := p.parseUseName()
+		if name == "" {
+			return nil
+		}
+		if group {
+			if len(stmt.Uses) > 0 {
+				p.unexpectedError()
+				return nil
+			}
+			stmt.Prefix = name
+			if stmt.Uses = p.parseGroupUses(stmt.Type, name); stmt.Uses == nil {
+				return nil
+			}
+			break
+		}
+		item.Name = name
+		if !p.parseUseAlias(item, stmt.Type, "") {
+			return nil
+		}
+		p.finish(item, from)
+		stmt.Uses = append(stmt.Uses, item)
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken()
+		p.nextToken()
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+// parseUseKind consumes the optional `function` or `const` of a use
+func (p *Parser) parseUseKind() ast.UseKind {
+	switch p.curToken.Type {
+	case token.Function:
+		p.nextToken()
+		return ast.UseFunction
+	case token.Const:
+		p.nextToken()
+		return ast.UseConst
+	}
+	return ast.UseNormal
+}
+
+// parseUseName parses an imported name, it reports true when the name is
+// the prefix of a group use, curToken is `{` in that case.
+func (p *Parser) parseUseName() (string, bool) {
+	var out strings.Builder
+	if p.curTokenIs(token.NsSeparator) {
+		out.WriteString("\\")
+		if !p.expectPeek(token.String) {
+			return "", false
+		}
+	} else if !p.curTokenIs(token.String) {
+		p.unexpectedError()
+		return "", false
+	}
+	out.WriteString(p.curToken.Literal)
+	for p.peekTokenIs(token.NsSeparator) {
+		p.nextToken()
+		if p.peekTokenIs(token.LBrace) {
+			p.nextToken()
+			return out.String(), true
+		}
+		if !p.expectPeek(token.String) {
+			return "", false
+		}
+		out.WriteString("\\")
+		out.WriteString(p.curToken.Literal)
+	}
+	return out.String(), false
+}
+
+// parseGroupUses parses the items of `use A\{...}`, curToken is `{`
+func (p *Parser) parseGroupUses(kind ast.UseKind, prefix string) []*ast.UseItem {
+	uses := []*ast.UseItem{}
+	for !p.peekTokenIs(token.RBrace) {
+		p.nextToken()
+		item := &ast.UseItem{BaseNode: p.newBaseNode()}
+		if kind == ast.UseNormal {
+			item.Type = p.parseUseKind()
+		}
+		if !p.curTokenIs(token.String) {
+			p.unexpectedError()
+			return nil
+		}
+		if item.Name = p.parseName(); item.Name == "" {
+			return nil
+		}
+		itemKind := kind
+		if item.Type != ast.UseNormal {
+			itemKind = item.Type
+		}
+		if !p.parseUseAlias(item, itemKind, prefix) {
+			return nil
+		}
+		p.finish(item, item.Token)
+		uses = append(uses, item)
+		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	p.nextToken()
+	return uses
+}
+
+// parseUseAlias parses the optional `as Alias` of an imported name and
+// checks the alias is not already in use, nor a special class name. prefix
+// is the common prefix of a group use.
+func (p *Parser) parseUseAlias(item *ast.UseItem, kind ast.UseKind, prefix string) bool {
+	if p.peekTokenIs(token.As) {
+		p.nextToken()
+		if !p.expectPeek(token.String) {
+			return false
+		}
+		item.Alias = p.curToken.Literal
+	}
+	alias := item.Alias
+	if alias == "" {
+		alias = item.Name[strings.LastIndex(item.Name, "\\")+1:]
+	}
+	name := strings.TrimPrefix(item.Name, "\\")
+	if prefix != "" {
+		name = strings.TrimPrefix(prefix, "\\") + "\\" + item.Name
+	}
+	if kind == ast.UseNormal && reservedClassNames[strings.ToLower(alias)] {
+		p.errorf(SyntaxError, "Cannot use %s as %s because '%s' is a special class name", name, alias, alias)
+		return false
+	}
+	key := kind.String() + " " + alias
+	if kind != ast.UseConst {
+		key = strings.ToLower(key)
+	}
+	if p.useAliases[key] {
+		p.errorf(SyntaxError, "Cannot use %s as %s because the name is already in use", name, alias)
+		return false
+	}
+	p.useAliases[key] = true
+	return true
+}
+
+// parseConstStatement parses `const A = expr, B = expr;`
+func (p *Parser) parseConstStatement() ast.Statement {
+	stmt := &ast.ConstStatement{BaseNode: p.newBaseNode()}
+	for {
+		if !p.expectPeek(token.String) {
+			return nil
+		}
+		c := &ast.ConstantItem{BaseNode: p.newBaseNode(), Name: p.curToken.Literal}
+		if !p.expectPeek(token.Assign) {
+			return nil
+		}
+		p.nextToken()
+		if c.Value = p.parseExpression(precLowest); c.Value == nil {
+			return nil
+		}
+		p.finish(c, c.Token)
+		stmt.Constants = append(stmt.Constants, c)
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+// parseDeclareStatement parses
+//
+//	declare(name=value, ...);
+//	declare(name=value, ...) statement
+//	declare(name=value, ...): statements enddeclare;
+//
+// first tells if no statement other than declare precedes it in the file.
+func (p *Parser) parseDeclareStatement(first bool) ast.Statement {
+	stmt := &ast.DeclareStatement{BaseNode: p.newBaseNode()}
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	for {
+		if !p.expectPeek(token.String) {
+			return nil
+		}
+		d := &ast.ConstantItem{BaseNode: p.newBaseNode(), Name: p.curToken.Literal}
+		if !p.expectPeek(token.Assign) {
+			return nil
+		}
+		p.nextToken()
+		if d.Value = p.parseExpression(precLowest); d.Value == nil {
+			return nil
+		}
+		if !p.checkDeclareDirective(d, first) {
+			return nil
+		}
+		p.finish(d, d.Token)
+		stmt.Directives = append(stmt.Directives, d)
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(token.RParen) {
+		return nil
+	}
+
+	p.nextToken()
+	switch p.curToken.Type {
+	case token.Semicolon:
+		return stmt
+	case token.Colon:
+		stmt.Body = p.parseStatementList(token.Enddeclare)
+		if stmt.Body == nil || !p.expectSemicolon() {
+			return nil
+		}
+	default:
+		if stmt.Body = p.parseBodyStatement(); stmt.Body == nil {
+			return nil
+		}
+	}
+	for _, d := range stmt.Directives {
+		if strings.ToLower(d.Name) == "strict_types" {
+			p.errorf(SyntaxError, "strict_types declaration must not use block mode")
+			return nil
+		}
+	}
+	return stmt
+}
+
+func (p *Parser) checkDeclareDirective(d *ast.ConstantItem, first bool) bool {
+	switch d.Value.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral:
+	default:
+		p.errorf(SyntaxError, "declare(%s) value must be a literal", d.Name)
+		return false
+	}
+	switch strings.ToLower(d.Name) {
+	case "strict_types":
+		if !first {
+			p.errorf(SyntaxError, "strict_types declaration must be the very first statement in the script")
+			return false
+		}
+		if v, ok := d.Value.(*ast.IntegerLiteral); !ok || v.Value != 0 && v.Value != 1 {
+			p.errorf(SyntaxError, "strict_types declaration must have 0 or 1 as its value")
+			return false
+		}
+	case "encoding":
+		if !first {
+			p.errorf(SyntaxError, "Encoding declaration pragma must be the very first statement in the script")
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_NamespaceStatement(t *testing.T) {
+	program := parse(t, `<?php
+declare(strict_types=1);
+namespace App\Models;
+use Foo\Bar, \Baz as Qux;
+use function Foo\helper;
+use const Foo\VERSION as V;
+use Foo\{Model, function render, const DEBUG as D,};
+const A = 1, B = \Foo\BAR;
+namespace\C;
+\true;
+`)
+	expected := []string{
+		"declare(strict_types=1);",
+		`namespace App\Models;`,
+		`use Foo\Bar, \Baz as Qux;`,
+		`use function Foo\helper;`,
+		`use const Foo\VERSION as V;`,
+		`use Foo\{Model, function render, const DEBUG as D};`,
+		`const A = 1, B = \Foo\BAR;`,
+		`namespace\C`,
+		"true",
+	}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("expected %d statements, got %d", len(expected), len(program.Statements))
+	}
+	for i, stmt := range program.Statements {
+		if stmt.String() != expected[i] {
+			t.Errorf("statements[%d] - expected=%q, got=%q", i, expected[i], stmt.String())
+		}
+	}
+	ns := program.Statements[1].(*ast.NamespaceStatement)
+	if ns.IsBraced() {
+		t.Errorf("expected unbraced namespace")
+	}
+	use := program.Statements[5].(*ast.UseStatement)
+	if use.Prefix != "Foo" || use.Uses[1].Type != ast.UseFunction || use.Uses[2].Alias != "D" {
+		t.Errorf("unexpected group use: %s", use)
+	}
+	c := program.Statements[7].(*ast.ExpressionStatement).Expression.(*ast.Constant)
+	if !c.IsNamespace {
+		t.Errorf("expected a qualified constant, got %s", c)
+	}
+}
+
+func Test_BracedNamespaceStatement(t *testing.T) {
+	program := parse(t, `<?php
+declare(ticks=1);
+namespace A {
+	use B\C;
+	function foo() {}
+}
+namespace {
+	use B\C;
+	declare(ticks=1): $a; enddeclare;
+}
+`)
+	if len(program.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(program.Statements))
+	}
+	for i, name := range []string{"A", ""} {
+		ns, ok := program.Statements[i+1].(*ast.NamespaceStatement)
+		if !ok {
+			t.Fatalf("expected *ast.NamespaceStatement, got %T", program.Statements[i+1])
+		}
+		if !ns.IsBraced() || ns.Name != name || len(ns.Body.Statements) != 2 {
+			t.Errorf("unexpected namespace: %s", ns)
+		}
+	}
+}
+
+func Test_NamespaceErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`<?php namespace A; namespace B {}`, "Cannot mix bracketed namespace declarations with unbracketed namespace declarations"},
+		{`<?php namespace A {} namespace B;`, "Cannot mix bracketed namespace declarations with unbracketed namespace declarations"},
+		{`<?php namespace A { namespace B {} }`, "Namespace declarations cannot be nested"},
+		{`<?php $a; namespace A;`, "Namespace declaration statement has to be the very first statement or after any declare call in the script"},
+		{`<?php namespace A {} $a;`, "No code may exist outside of namespace {}"},
+		{`<?php namespace namespace;`, "Cannot use 'namespace' as namespace name"},
+		{`<?php use A\B; use C\B;`, "Cannot use C\\B as B because the name is already in use"},
+		{`<?php use function A\b; use function C\B;`, "Cannot use C\\B as B because the name is already in use"},
+		{`<?php use A as self;`, "Cannot use A as self because 'self' is a special class name"},
+		{`<?php use A\B as int;`, "Cannot use A\\B as int because 'int' is a special class name"},
+		{`<?php use \A\B as Mixed;`, "Cannot use A\\B as Mixed because 'Mixed' is a special class name"},
+		{`<?php use A\Parent;`, "Cannot use A\\Parent as Parent because 'Parent' is a special class name"},
+		{`<?php use A\{B, C as string};`, "Cannot use A\\C as string because 'string' is a special class name"},
+		{`<?php $a; declare(strict_types=1);`, "strict_types declaration must be the very first statement in the script"},
+		{`<?php declare(strict_types=1) {}`, "strict_types declaration must not use block mode"},
+		{`<?php declare(strict_types=2);`, "strict_types declaration must have 0 or 1 as its value"},
+		{`<?php $a; declare(encoding='UTF-8');`, "Encoding declaration pragma must be the very first statement in the script"},
+		{`<?php declare(ticks=A);`, "declare(ticks) value must be a literal"},
+		{`<?php function f() { use A; }`, "unexpected token \"use\""},
+	}
+	for i, tt := range tests {
+		err := parseError(t, tt.input)
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %q", i, tt.message, err.Message)
+		}
+	}
+	// only class names are reserved
+	parse(t, `<?php use function A\int; use const A\self; use A\{function string};`)
+}
@@ -8,94 +8,217 @@ import (
 	"github.com/eaglewu/luban/compiler/token"
 )
 
+// Mode is a set of flags controlling the parser
+type Mode uint
+
 const (
-	_ int = iota
-	// EndOfFileError represents normal EOF error
-	EndOfFileError
-	// WrongTokenError means that token is not what we expected
-	WrongTokenError
-	// UnexpectedTokenError means that token is not expected to appear in current condition
-	UnexpectedTokenError
-	// UnexpectedEndError means we get unexpected "end" keyword (this is mainly created for REPL)
-	UnexpectedEndError
-	// MethodDefinitionError means there's an error on method definition's method name
-	MethodDefinitionError
-	// InvalidAssignmentError means user assigns value to wrong type of expressions
-	InvalidAssignmentError
-	// SyntaxError means there's a grammatical in the source code
-	SyntaxError
-	// ArgumentError means there's a method parameter's definition error
-	ArgumentError
+	// AllErrors makes the parser recover from errors and report all of them
+	AllErrors Mode = 1 << iota
+	// ParseComments keeps the comments in Program.Comments
+	ParseComments
+	// ParseTrivia keeps the comments, whitespace and PHP opening tags in
+	// Program.Trivia
+	ParseTrivia
+	// PHP74 targets PHP 7.4, the syntax of later versions is rejected
+	PHP74
+	// PHP80 targets PHP 8.0, the syntax of later versions is rejected
+	PHP80
 )
 
-// Error represents parser's parsing error
-type Error struct {
-	// Message contains the readable message of error
-	Message string
-	errType int
+// version returns the targeted PHP version as major*100+minor, the
+// latest supported one when no version flag is set.
+func (m Mode) version() int {
+	switch {
+	case m&PHP74 != 0:
+		return 704
+	case m&PHP80 != 0:
+		return 800
+	}
+	return 801
 }
 
 // The Parser structure holds the parser's internal state.
 type Parser struct {
 	Lexer *lexer.Lexer
-	error *Error
+	Mode  Mode
+	// Filename is the file name used in error messages, it defaults to
+	// "php shell code" like PHP's interactive shell
+	Filename string
+	error    *Error
+	// errs holds the errors recovered from in AllErrors mode
+	errs ErrorList
+	// depth is the number of open braces up to curToken
+	depth int
+	// delimiters holds the braces, brackets, parentheses and heredocs
+	// open up to curToken, closed is the last one closed
+	delimiters []token.Token
+	closed     token.Token
+	// curNesting and peekNesting are the nesting errors of curToken and
+	// peekToken, reported in place of the errors on these tokens
+	curNesting  string
+	peekNesting string
+	// exprEnd is set when curToken ends the expression just parsed
+	exprEnd bool
 
+	prevToken token.Token
 	curToken  token.Token
 	peekToken token.Token
+
+	prefixParseFns map[token.Type]prefixParseFn
+	infixParseFns  map[token.Type]infixParseFn
+
+	// namespaceKind is the namespace declaration style used by the file
+	namespaceKind int
+	// inNamespace is set while parsing the body of a braced namespace
+	inNamespace bool
+	// hasCode is set once a statement other than declare has been parsed
+	hasCode bool
+	// useAliases holds the names imported in the current namespace
+	useAliases map[string]bool
+	// arrayDepth is the nesting level of the array being parsed
+	arrayDepth int
+	// jumps tracks the loops and labels of the function being parsed
+	jumps *jumpScope
+	// comments and trivia are kept for the ParseComments and ParseTrivia modes
+	comments []token.Token
+	trivia   []token.Token
 }
 
 // New parser
 func New(l *lexer.Lexer) *Parser {
+	return newParser(l, "", 0)
+}
+
+// newParser returns a parser reading the tokens of l, the lexer is to be
+// run by the caller.
+func newParser(l *lexer.Lexer, filename string, mode Mode) *Parser {
 	p := &Parser{
-		Lexer: l,
+		Lexer:      l,
+		Mode:       mode,
+		Filename:   filename,
+		useAliases: map[string]bool{},
+		jumps:      newJumpScope(),
 	}
+	p.registerExpressionFns()
 	p.nextToken()
 	p.nextToken()
 	return p
 }
 
 func (p *Parser) nextToken() {
+	p.prevToken = p.curToken
 	p.curToken = p.peekToken
+	p.curNesting = p.peekNesting
+	p.exprEnd = false
+	switch p.curToken.Type {
+	case token.LBrace, token.CurlyOpen, token.DollarOpenCurlyBraces:
+		p.depth++
+	case token.RBrace:
+		p.depth--
+	}
+	p.trackDelimiter()
 again:
 	tok := p.Lexer.NextToken()
 
 	switch tok.Type {
-	case token.Comment:
-		fallthrough
-	case token.DocComment:
-		fallthrough
-	case token.OpenTag:
+	case token.Comment, token.DocComment:
+		if p.Mode&ParseComments != 0 {
+			p.comments = append(p.comments, tok)
+		}
 		fallthrough
-	case token.Whitespace:
+	case token.OpenTag, token.Whitespace:
+		if p.Mode&ParseTrivia != 0 {
+			p.trivia = append(p.trivia, tok)
+		}
 		goto again
 
+	case token.Match:
+		if p.Mode.version() < 800 {
+			tok.Type = token.String
+		}
+
+	case token.Enum, token.Readonly:
+		if p.Mode.version() < 801 {
+			tok.Type = token.String
+		}
+
 	case token.CloseTag:
 		tok.Type = token.Semicolon
 		break
+
+	case token.End:
+		if tok.Line == 0 { // the lexer is done
+			end := p.curToken.End("")
+			tok.Line, tok.Column, tok.Offset = end.Line, end.Column, end.Offset
+		}
+
+	case token.Error:
+		if p.error == nil {
+			pos := tok.Pos(p.filename())
+			p.error = &Error{Message: tok.Literal, Kind: SyntaxError, Pos: pos, End: pos}
+		}
 	}
 
+	p.peekNesting = p.nestingError(tok)
 	p.peekToken = tok
 }
 
-// ParseProgram parse source input to structure of ast.Program
+// ParseProgram parse source input to structure of ast.Program. In AllErrors
+// mode the statements that fail to parse are replaced by ast.BadStatement,
+// the partial program is returned along with the first error and Errors
+// holds the complete list.
 func (p *Parser) ParseProgram() (*ast.Program, *Error) {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
-	for typ := p.curToken.Type; typ != token.End; p.nextToken() {
-		if typ == token.Error {
-			return nil, &Error{Message: p.curToken.Literal}
+	for ; !p.curTokenIs(token.End); p.nextToken() {
+		if p.curTokenIs(token.Error) {
+			if p.Mode&AllErrors != 0 {
+				// the error is recorded when the token is peeked
+				if p.error != nil {
+					p.errs.Add(p.error)
+					p.error = nil
+				}
+				break
+			}
+			pos := p.curToken.Pos(p.filename())
+			return nil, &Error{Message: p.curToken.Literal, Kind: SyntaxError, Pos: pos, End: pos}
 		}
-		stmt := p.parseStatement()
+		from := p.curToken
+		stmt := p.parseTopStatement()
 		if p.error != nil {
-			return nil, p.error
+			if p.Mode&AllErrors == 0 {
+				return nil, p.error
+			}
+			if stmt, _ = p.recoverStatement(from, 0, token.End); stmt == nil {
+				p.errs.Add(p.error)
+				p.error = nil
+				break
+			}
 		}
 		if stmt != nil {
+			p.finish(stmt, from)
 			program.Statements = append(program.Statements, stmt)
 		}
 	}
+	if !p.resolveGotos() {
+		if p.Mode&AllErrors == 0 {
+			return nil, p.error
+		}
+		p.errs.Add(p.error)
+		p.error = nil
+	}
+	program.Comments, program.Trivia = p.comments, p.trivia
+	if len(p.errs) > 0 {
+		return program, p.errs[0]
+	}
 	return program, nil
 }
 
+// Errors returns the errors recovered from while parsing in AllErrors mode
+func (p *Parser) Errors() ErrorList {
+	return p.errs
+}
+
 func (p *Parser) curTokenIs(t token.Type) bool {
 	return p.curToken.Type == t
 }
@@ -104,6 +227,15 @@ func (p *Parser) peekTokenIs(t token.Type) bool {
 	return p.peekToken.Type == t
 }
 
+func (p *Parser) curTokenIsAny(types ...token.Type) bool {
+	for _, t := range types {
+		if p.curToken.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Parser) expectPeek(t token.Type) bool {
 	if p.peekTokenIs(t) {
 		p.nextToken()
@@ -113,10 +245,61 @@ func (p *Parser) expectPeek(t token.Type) bool {
 	return false
 }
 
+// peekError reports peekToken as unexpected in place of t. Like PHP the
+// expected token is left out after an expression, which could also be
+// followed by an operator.
 func (p *Parser) peekError(t token.Type) {
-	msg := fmt.Sprintf(
-		"unexpected '%s', expecting '%s' in php shell code on line %d",
-		p.peekToken.Type, t, p.peekToken.Line,
-	)
-	p.error = &Error{Message: msg, errType: UnexpectedTokenError}
+	msg := "syntax error, unexpected " + p.peekToken.Unexpected()
+	if !p.exprEnd {
+		msg += ", expecting " + t.Expected()
+	}
+	p.errorAt(p.peekToken, UnexpectedTokenError, "%s", msg)
+}
+
+func (p *Parser) unexpectedError() {
+	p.errorAt(p.curToken, UnexpectedTokenError, "syntax error, unexpected %s", p.curToken.Unexpected())
+}
+
+// requireVersion reports an error at curToken when the targeted PHP
+// version is older than version, feature completes "Cannot use".
+func (p *Parser) requireVersion(version int, feature string) bool {
+	if p.Mode.version() >= version {
+		return true
+	}
+	p.errorf(SyntaxError, "Cannot use %s before PHP %d.%d", feature, version/100, version%100)
+	return false
+}
+
+// errorf records an error at curToken
+func (p *Parser) errorf(kind ErrorKind, format string, args ...interface{}) {
+	p.errorAt(p.curToken, kind, format, args...)
+}
+
+// errorAt records an error spanning the given token. The first error is
+// kept, like the lexer errors recorded when their token is peeked which
+// would otherwise be reported as an unexpected token. Like PHP the
+// unclosed and unmatched delimiters are reported in place of the error.
+func (p *Parser) errorAt(tok token.Token, kind ErrorKind, format string, args ...interface{}) {
+	if p.error != nil {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if nesting := p.nesting(tok); nesting != "" {
+		kind, msg = SyntaxError, nesting
+	}
+	msg = fmt.Sprintf("%s in %s on line %d", msg, p.filename(), tok.Line)
+	p.error = &Error{
+		Message: msg,
+		Kind:    kind,
+		Pos:     tok.Pos(p.filename()),
+		End:     tok.End(p.filename()),
+		Labels:  p.delimiterLabels(tok),
+	}
+}
+
+func (p *Parser) filename() string {
+	if p.Filename == "" {
+		return "php shell code"
+	}
+	return p.Filename
 }
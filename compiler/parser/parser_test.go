@@ -1 +1,191 @@
 package parser
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/lexer"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	l := lexer.New(input)
+	go l.Run()
+	program, err := New(l).ParseProgram()
+	if err != nil {
+		t.Fatalf("parser error: %s", err.Message)
+	}
+	return program
+}
+
+func parseError(t *testing.T, input string) *Error {
+	t.Helper()
+	l := lexer.New(input)
+	go l.Run()
+	_, err := New(l).ParseProgram()
+	if err == nil {
+		t.Fatalf("expected parser error for %q", input)
+	}
+	return err
+}
+
+func Test_OperatorPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"<?php 1 + 2 * 3;", "(1 + (2 * 3))"},
+		{"<?php $a . $b + $c;", "($a . ($b + $c))"},
+		{"<?php -$a ** 2;", "(-($a ** 2))"},
+		{"<?php 2 ** 3 ** 2;", "(2 ** (3 ** 2))"},
+		{"<?php !$a instanceof B;", "(!($a instanceof B))"},
+		{"<?php $a instanceof static && $b instanceof self;", "(($a instanceof static) && ($b instanceof self))"},
+		{"<?php $a instanceof parent || $b instanceof static::$c;", "(($a instanceof parent) || ($b instanceof static::$c))"},
+		{"<?php $a ?? $b ?? $c;", "($a ?? ($b ?? $c))"},
+		{"<?php $a || $b && $c;", "($a || ($b && $c))"},
+		{"<?php $a or $b and $c;", "($a or ($b and $c))"},
+		{"<?php $a ? $b : $c ?: $d;", "(($a ? $b : $c) ?: $d)"},
+		{"<?php (1 + 2) * 3;", "((1 + 2) * 3)"},
+		{"<?php $i++ + --$j;", "(($i++) + (--$j))"},
+		{"<?php (int) $a . $b;", "((int)$a . $b)"},
+	}
+	for i, tt := range tests {
+		program := parse(t, tt.input)
+		if got := program.String(); got != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func Test_Literals(t *testing.T) {
+	program := parse(t, `<?php 0x1F; 0b11; 017; 1.5; 'it\'s'; "a\tb\x41\101\u{1F600}"; TRUE; null; PHP_EOL; 9223372036854775808; 1e400; -1.5e999;`)
+	stmts := program.Statements
+	expectInt := func(i, v int) {
+		lit, ok := stmts[i].(*ast.ExpressionStatement).Expression.(*ast.IntegerLiteral)
+		if !ok || lit.Value != v {
+			t.Errorf("statements[%d] - expected integer %d, got %s", i, v, stmts[i])
+		}
+	}
+	expectInt(0, 31)
+	expectInt(1, 3)
+	expectInt(2, 15)
+	if f := stmts[3].(*ast.ExpressionStatement).Expression.(*ast.FloatLiteral); f.Value != 1.5 {
+		t.Errorf("expected float 1.5, got %v", f.Value)
+	}
+	if s := stmts[4].(*ast.ExpressionStatement).Expression.(*ast.StringLiteral); s.Value != "it's" {
+		t.Errorf("expected %q, got %q", "it's", s.Value)
+	}
+	if s := stmts[5].(*ast.ExpressionStatement).Expression.(*ast.StringLiteral); s.Value != "a\tbAA😀" {
+		t.Errorf("expected %q, got %q", "a\tbAA😀", s.Value)
+	}
+	if b := stmts[6].(*ast.ExpressionStatement).Expression.(*ast.BooleanExpression); !b.Value {
+		t.Errorf("expected true")
+	}
+	if _, ok := stmts[7].(*ast.ExpressionStatement).Expression.(*ast.NullExpression); !ok {
+		t.Errorf("expected null, got %s", stmts[7])
+	}
+	if c := stmts[8].(*ast.ExpressionStatement).Expression.(*ast.Constant); c.Value != "PHP_EOL" {
+		t.Errorf("expected constant PHP_EOL, got %s", c.Value)
+	}
+	if _, ok := stmts[9].(*ast.ExpressionStatement).Expression.(*ast.FloatLiteral); !ok {
+		t.Errorf("expected integer overflow to float, got %s", stmts[9])
+	}
+	if f := stmts[10].(*ast.ExpressionStatement).Expression.(*ast.FloatLiteral); !math.IsInf(f.Value, 1) {
+		t.Errorf("expected float overflow to INF, got %v", f.Value)
+	}
+	if e, ok := stmts[11].(*ast.ExpressionStatement).Expression.(*ast.PrefixExpression); !ok || !math.IsInf(e.Right.(*ast.FloatLiteral).Value, 1) {
+		t.Errorf("expected negated INF, got %s", stmts[11])
+	}
+}
+
+func Test_MagicConstants(t *testing.T) {
+	names := []string{
+		"__LINE__", "__FILE__", "__DIR__", "__CLASS__", "__TRAIT__",
+		"__METHOD__", "__FUNCTION__", "__NAMESPACE__", "__dir__",
+	}
+	program := parse(t, "<?php "+strings.Join(names, "; ")+";")
+	for i, name := range names {
+		c, ok := program.Statements[i].(*ast.ExpressionStatement).Expression.(*ast.Constant)
+		if !ok || c.Value != name || c.Kind() != ast.KindMagicConst {
+			t.Errorf("statements[%d] - expected magic constant %s, got %s", i, name, program.Statements[i])
+		}
+	}
+}
+
+func Test_IncludeOrEvalExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		kind     ast.IncludeKind
+		expected string
+	}{
+		{`<?php include $a;`, ast.Include, "(include $a)"},
+		{`<?php include_once $a . $b;`, ast.IncludeOnce, "(include_once ($a . $b))"},
+		{`<?php require $a or $b;`, ast.Require, "(require ($a or $b))"},
+		{`<?php require_once($a);`, ast.RequireOnce, "(require_once $a)"},
+		{`<?php eval($code);`, ast.Eval, "eval($code)"},
+		{`<?php require __DIR__ . "/x.php";`, ast.Require, "(require (__DIR__ . '/x.php'))"},
+	}
+	for i, tt := range tests {
+		program := parse(t, tt.input)
+		exp, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IncludeOrEvalExpression)
+		if !ok {
+			t.Fatalf("tests[%d] - expected *ast.IncludeOrEvalExpression, got %s", i, program.Statements[0])
+		}
+		if exp.Type != tt.kind || exp.String() != tt.expected {
+			t.Errorf("tests[%d] - expected %s %q, got %s %q", i, tt.kind, tt.expected, exp.Type, exp.String())
+		}
+	}
+
+	program := parse(t, `<?php $a ?? include $b;`)
+	if s := program.Statements[0].String(); s != "($a ?? (include $b))" {
+		t.Errorf("expected include as operand, got %q", s)
+	}
+	if err := parseError(t, `<?php eval $a;`); !strings.Contains(err.Message, "unexpected variable \"$a\", expecting \"(\"") {
+		t.Errorf("unexpected error: %s", err.Message)
+	}
+}
+
+func Test_SyntaxErrorMessages(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"<?php\n$a = 1\n$b = 2;", `syntax error, unexpected variable "$b" in file.php on line 3`},
+		{"<?php foo(1;", `syntax error, unexpected token ";" in file.php on line 1`},
+		{"<?php function foo {}", `syntax error, unexpected token "{", expecting "(" in file.php on line 1`},
+		{"<?php\nif (1) {\n", `Unclosed '{' on line 2 in file.php on line 3`},
+		{"<?php $a = );", `Unmatched ')' in file.php on line 1`},
+		{"<?php }", `Unmatched '}' in file.php on line 1`},
+		{"<?php foo(1];", `Unclosed '(' does not match ']' in file.php on line 1`},
+		{"<?php\nfoo(\n  [1, 2);", `Unclosed '[' does not match ')' in file.php on line 3`},
+		{"<?php\nfoo(\n  1];", `Unclosed '(' on line 2 does not match ']' in file.php on line 3`},
+		{"<?php\n#[A(1]\nfunction f() {}", `Unclosed '(' does not match ']' in file.php on line 2`},
+		{"<?php if (1) { \"{$a", `Unclosed '{' in file.php on line 1`},
+		{"<?php\nfoo(<<<EOT\nabc\n", `Unclosed '(' on line 2 in file.php on line 4`},
+		{"<?php\n$a = <<<EOT\nabc\n", `syntax error, unexpected end of file in file.php on line 4`},
+		{"<?php class { }", `syntax error, unexpected token "{", expecting identifier in file.php on line 1`},
+		{"<?php 'abc' 'def';", `syntax error, unexpected single-quoted string "def" in file.php on line 1`},
+		{"<?php abstract FUNCTION f();", `syntax error, unexpected token "function" in file.php on line 1`},
+		{"<?php function f() {} f() = 1;", `Can't use function return value in write context in file.php on line 1`},
+	}
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		go l.Run()
+		p := New(l)
+		p.Filename = "file.php"
+		_, err := p.ParseProgram()
+		if err == nil {
+			t.Fatalf("tests[%d] - expected parser error for %q", i, tt.input)
+		}
+		if err.Message != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, err.Message)
+		}
+	}
+
+	// the lexer error is kept rather than reported as an unexpected token
+	if err := parseError(t, `<?php "$a[$]";`); !strings.HasPrefix(err.Message, "Unexpected character in input:") {
+		t.Errorf("expected the lexer error, got %q", err.Message)
+	}
+}
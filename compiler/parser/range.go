@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// rangeSetter is implemented by the nodes embedding *ast.BaseNode
+type rangeSetter interface {
+	SetRange(pos, end token.Position)
+}
+
+// finish sets the range of n from the first character of from to the end
+// of curToken, the last token of the node. The range of a node already
+// finished is kept, so that the enclosing rules returning a node as is,
+// like `(expr)`, don't extend it.
+func (p *Parser) finish(n ast.Node, from token.Token) {
+	p.setRange(n, from.Pos(p.filename()), p.curToken.End(p.filename()))
+}
+
+// setRange sets the range of n unless it is already set
+func (p *Parser) setRange(n ast.Node, pos, end token.Position) {
+	if p.error != nil || n == nil || n.End().IsValid() {
+		return
+	}
+	if s, ok := n.(rangeSetter); ok {
+		s.SetRange(pos, end)
+	}
+}
+
+// finishBlock sets the range of a statement list ended by one of the end
+// tokens of an alternative syntax, like `endif`, which is not part of it:
+// the list covers its opening `:` and its statements.
+func (p *Parser) finishBlock(block *ast.BlockStatement) {
+	end := block.Token.End(p.filename())
+	if n := len(block.Statements); n > 0 {
+		end = block.Statements[n-1].End()
+	}
+	p.setRange(block, block.Token.Pos(p.filename()), end)
+}
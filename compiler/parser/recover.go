@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// syncTokens are the tokens starting a statement or a class member, the
+// parser resumes before them after an error.
+var syncTokens = map[token.Type]bool{
+	token.If: true, token.Else: true, token.Elseif: true, token.Endif: true,
+	token.While: true, token.Endwhile: true, token.Do: true,
+	token.For: true, token.Endfor: true, token.Foreach: true, token.Endforeach: true,
+	token.Switch: true, token.Endswitch: true, token.Case: true, token.Default: true,
+	token.Break: true, token.Continue: true, token.Goto: true, token.Return: true,
+	token.Try: true, token.Throw: true, token.Declare: true, token.Enddeclare: true,
+	token.Echo: true, token.Global: true, token.Unset: true,
+	token.Namespace: true, token.Use: true, token.Const: true, token.Function: true,
+	token.Abstract: true, token.Final: true, token.Class: true,
+	token.Interface: true, token.Trait: true, token.Enum: true,
+	token.Public: true, token.Protected: true, token.Private: true,
+	token.Var: true, token.Readonly: true,
+}
+
+// recoverStatement records the pending error in AllErrors mode and skips
+// the rest of the statement started by from, depth is the brace depth of
+// the statement list and ends its end tokens. It returns the BadStatement
+// standing for the skipped tokens and whether curToken is the end of the
+// list, or nil when the error is not recoverable.
+func (p *Parser) recoverStatement(from token.Token, depth int, ends ...token.Type) (ast.Statement, bool) {
+	if p.Mode&AllErrors == 0 || p.curTokenIs(token.End) {
+		return nil, false
+	}
+	p.errs.Add(p.error)
+	p.error = nil
+	p.synchronize(depth)
+	bad := &ast.BadStatement{BaseNode: &ast.BaseNode{Token: from}, Last: p.curToken}
+	bad.SetRange(from.Pos(p.filename()), p.curToken.End(p.filename()))
+	if p.depth < depth {
+		if p.curTokenIsAny(ends...) {
+			// the closing token is not part of the statement
+			bad.Last = p.prevToken
+			bad.SetRange(from.Pos(p.filename()), p.prevToken.End(p.filename()))
+			return bad, true
+		}
+		// a stray closing brace
+		p.depth = depth
+	}
+	return bad, false
+}
+
+// synchronize advances to the last token of the erroneous statement: a
+// semicolon or closing brace at depth, or the token before the next
+// statement keyword or before the brace closing the list.
+func (p *Parser) synchronize(depth int) {
+	for {
+		switch {
+		case p.curTokenIs(token.End), p.curTokenIs(token.Error), p.depth < depth:
+			return
+		case p.depth > depth:
+		case p.curTokenIs(token.Semicolon), p.curTokenIs(token.RBrace):
+			return
+		case p.peekTokenIs(token.RBrace), syncTokens[p.peekToken.Type]:
+			return
+		}
+		p.nextToken()
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/lexer"
+)
+
+func parseAll(input string) (*ast.Program, ErrorList) {
+	l := lexer.New(input)
+	go l.Run()
+	p := New(l)
+	p.Mode = AllErrors
+	program, _ := p.ParseProgram()
+	return program, p.Errors()
+}
+
+// statementKinds describes the statements of a block as their types,
+// nested bodies are written in braces.
+func statementKinds(stmts []ast.Statement) string {
+	kinds := []string{}
+	for _, stmt := range stmts {
+		kind := strings.TrimPrefix(fmt.Sprintf("%T", stmt), "*ast.")
+		switch s := stmt.(type) {
+		case *ast.FunctionStatement:
+			kind += "{" + statementKinds(s.Body.Statements) + "}"
+		case *ast.ClassStatement:
+			kind += "{" + statementKinds(s.Body.Statements) + "}"
+		case *ast.NamespaceStatement:
+			kind += "{" + statementKinds(s.Body.Statements) + "}"
+		}
+		kinds = append(kinds, kind)
+	}
+	return strings.Join(kinds, " ")
+}
+
+func Test_Recover(t *testing.T) {
+	tests := []struct {
+		input    string
+		kinds    string
+		messages []string
+	}{
+		{
+			`<?php $a = ; $b = 1; foo($c $d); echo 1;`,
+			"BadStatement ExpressionStatement BadStatement EchoStatement",
+			[]string{"unexpected token \";\"", "unexpected variable \"$d\""},
+		},
+		{
+			`<?php function f() { $a = ; return 1; } $b = 2;`,
+			"FunctionStatement{BadStatement ReturnStatement} ExpressionStatement",
+			[]string{"unexpected token \";\""},
+		},
+		{
+			`<?php function f() { $a = } echo 1;`,
+			"FunctionStatement{BadStatement} EchoStatement",
+			[]string{"unexpected token \"}\""},
+		},
+		{
+			`<?php $a = 1 if ($a) { $b = ; } echo 2;`,
+			"BadStatement IfStatement EchoStatement",
+			[]string{"unexpected token \"if\"", "unexpected token \";\""},
+		},
+		{
+			`<?php class A { public $a public function f() {} function f() {} const X = 1; }`,
+			"ClassStatement{BadStatement MethodStatement BadStatement ClassConstStatement}",
+			[]string{"unexpected token \"public\"", "Cannot redeclare A::f()"},
+		},
+		{
+			`<?php namespace A { foo(; } namespace B { bar(); }`,
+			"NamespaceStatement{BadStatement} NamespaceStatement{ExpressionStatement}",
+			[]string{"unexpected token \";\""},
+		},
+		{
+			`<?php } $a = 1; goto x;`,
+			"BadStatement ExpressionStatement GotoStatement",
+			[]string{"Unmatched '}'", "'goto' to undefined label 'x'"},
+		},
+		{
+			`<?php $a = 1; function f() { $b = ;`,
+			"ExpressionStatement",
+			[]string{"unexpected token \";\"", "Unclosed '{'"},
+		},
+	}
+	for i, tt := range tests {
+		program, errs := parseAll(tt.input)
+		if program == nil {
+			t.Fatalf("tests[%d] - expected a partial program", i)
+		}
+		if kinds := statementKinds(program.Statements); kinds != tt.kinds {
+			t.Errorf("tests[%d] - expected statements %q, got %q", i, tt.kinds, kinds)
+		}
+		if len(errs) != len(tt.messages) {
+			t.Errorf("tests[%d] - expected %d errors, got %d", i, len(tt.messages), len(errs))
+			continue
+		}
+		for j, msg := range tt.messages {
+			if !strings.Contains(errs[j].Message, msg) {
+				t.Errorf("tests[%d] - errors[%d] expected to contain %q, got %q", i, j, msg, errs[j].Message)
+			}
+		}
+	}
+}
+
+func Test_RecoverBadStatement(t *testing.T) {
+	program, _ := parseAll("<?php\n$a = 1 +\n* 2;\n$b;")
+	bad, ok := program.Statements[0].(*ast.BadStatement)
+	if !ok {
+		t.Fatalf("expected *ast.BadStatement, got %T", program.Statements[0])
+	}
+	if bad.Token.Literal != "$a" || bad.Last.Literal != ";" || bad.Last.Line != 3 {
+		t.Errorf("expected bad statement from $a to ; on line 3, got %q to %q", bad.Token.Literal, bad.Last.Literal)
+	}
+}
+
+func Test_RecoverDisabled(t *testing.T) {
+	l := lexer.New(`<?php $a = ; $b = ;`)
+	go l.Run()
+	p := New(l)
+	program, err := p.ParseProgram()
+	if program != nil || err == nil || len(p.Errors()) != 0 {
+		t.Errorf("expected the parser to stop at the first error, got %v %v", program, p.Errors())
+	}
+}
@@ -11,33 +11,621 @@ func (p *Parser) parseStatement() ast.Statement {
 	case token.LBrace: // '{' inner_statement_list '}'
 		return p.parseInnerStatement()
 
-	case token.Include:
-		return p.parseIncludeStatement(false)
-	case token.IncludeOnce:
-		return p.parseIncludeStatement(true)
-	case token.Eval:
-		return p.parseEvalStatement()
-	case token.Require:
-		return p.parseIncludeStatement(false)
-	case token.RequireOnce:
-		return p.parseIncludeStatement(true)
-	default:
+	case token.Semicolon: // empty statement
 		return nil
+
+	case token.InlineHtml:
+		return &ast.InlineHtmlStatement{BaseNode: p.newBaseNode(), Value: p.curToken.Literal}
+	case token.OpenTagWithEcho: // <?= expr_list ?>
+		return p.parseEchoStatement()
+
+	case token.If:
+		return p.parseIfStatement()
+	case token.While:
+		return p.parseWhileStatement()
+	case token.Do:
+		return p.parseDoStatement()
+	case token.For:
+		return p.parseForStatement()
+	case token.Foreach:
+		return p.parseForeachStatement()
+	case token.Switch:
+		return p.parseSwitchStatement()
+	case token.Break:
+		return p.parseBreakStatement()
+	case token.Continue:
+		return p.parseContinueStatement()
+	case token.Goto:
+		return p.parseGotoStatement()
+	case token.Attribute:
+		return p.parseAttributedStatement()
+	case token.Abstract, token.Final, token.Class:
+		return p.parseClassStatement()
+	case token.Interface:
+		return p.parseInterfaceStatement()
+	case token.Trait:
+		return p.parseTraitStatement()
+	case token.Enum:
+		return p.parseEnumStatement()
+	case token.Return:
+		return p.parseReturnStatement()
+	case token.Echo:
+		return p.parseEchoStatement()
+	case token.Global:
+		return p.parseGlobalStatement()
+	case token.Static:
+		if p.peekTokenIs(token.Variable) {
+			return p.parseStaticStatement()
+		}
+		return p.parseExpressionStatement()
+	case token.Unset:
+		return p.parseUnsetStatement()
+	case token.Try:
+		return p.parseTryStatement()
+	case token.Throw:
+		return p.parseThrowStatement()
+	case token.Declare:
+		return p.parseDeclareStatement(false)
+	case token.Function:
+		if p.peekTokenIs(token.String) || p.peekTokenIs(token.Ampersand) {
+			return p.parseFunctionStatement(nil)
+		}
+		return p.parseExpressionStatement()
+	case token.String:
+		if p.peekTokenIs(token.Colon) {
+			return p.parseLabelStatement()
+		}
+		return p.parseExpressionStatement()
+	default:
+		return p.parseExpressionStatement()
 	}
 }
 
 func (p *Parser) parseInnerStatement() ast.Statement {
-	return nil
+	return p.parseBlockStatement()
+}
+
+// parseBlockStatement parses `{ inner_statement_list }`, on return
+// curToken is the closing brace.
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := p.parseStatementList(token.RBrace)
+	if p.error != nil {
+		return nil
+	}
+	return block
+}
+
+// parseStatementList parses statements following curToken until one of
+// the end tokens, which is left as curToken.
+func (p *Parser) parseStatementList(ends ...token.Type) *ast.BlockStatement {
+	block := &ast.BlockStatement{BaseNode: p.newBaseNode(), Statements: []ast.Statement{}}
+	depth := p.depth
+	p.nextToken()
+	for !p.curTokenIsAny(ends...) {
+		if p.curTokenIs(token.End) {
+			p.unexpectedError()
+			return nil
+		}
+		from := p.curToken
+		stmt := p.parseStatement()
+		if p.error != nil {
+			bad, closed := p.recoverStatement(from, depth, ends...)
+			if bad == nil {
+				return nil
+			}
+			block.Statements = append(block.Statements, bad)
+			if closed {
+				continue
+			}
+		} else if stmt != nil {
+			p.finish(stmt, from)
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+	if block.Token.Type == token.LBrace {
+		p.finish(block, block.Token)
+	} else {
+		p.finishBlock(block)
+	}
+	return block
+}
+
+// parseBodyStatement parses the body of a control structure in brace
+// syntax, a single statement is wrapped into a block.
+func (p *Parser) parseBodyStatement() *ast.BlockStatement {
+	if p.curTokenIs(token.LBrace) {
+		return p.parseBlockStatement()
+	}
+	from := p.curToken
+	block := &ast.BlockStatement{BaseNode: p.newBaseNode(), Statements: []ast.Statement{}}
+	stmt := p.parseStatement()
+	if p.error != nil {
+		return nil
+	}
+	if stmt != nil {
+		p.finish(stmt, from)
+		block.Statements = append(block.Statements, stmt)
+	}
+	p.finish(block, from)
+	return block
+}
+
+func (p *Parser) expectSemicolon() bool {
+	return p.expectPeek(token.Semicolon)
+}
+
+// parseParenExpression parses `( expr )`, curToken is the token before `(`
+func (p *Parser) parseParenExpression() ast.Expression {
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	p.nextToken()
+	exp := p.parseExpression(precLowest)
+	if p.error != nil || !p.expectPeek(token.RParen) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseExpressionStatement() ast.Statement {
+	stmt := &ast.ExpressionStatement{BaseNode: p.newBaseNode()}
+	stmt.Expression = p.parseExpression(precLowest)
+	if p.error != nil || !p.expectSemicolon() {
+		return nil
+	}
+	stmt.Expression.MarkAsStmt()
+	return stmt
+}
+
+// parseIfStatement parses
+//
+//	if (expr) statement [elseif (expr) statement]* [else statement]
+//	if (expr): statements [elseif (expr): statements]* [else: statements] endif;
+func (p *Parser) parseIfStatement() ast.Statement {
+	stmt := &ast.IfStatement{BaseNode: p.newBaseNode()}
+	cond := &ast.ConditionalExpression{BaseNode: p.newBaseNode()}
+	if cond.Condition = p.parseParenExpression(); cond.Condition == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.Colon) {
+		return p.parseAltIfStatement(stmt, cond)
+	}
+
+	p.nextToken()
+	if cond.Consequence = p.parseBodyStatement(); cond.Consequence == nil {
+		return nil
+	}
+	p.finish(cond, cond.Token)
+	stmt.Conditionals = append(stmt.Conditionals, cond)
+
+	for p.peekTokenIs(token.Elseif) {
+		p.nextToken()
+		cond := &ast.ConditionalExpression{BaseNode: p.newBaseNode()}
+		if cond.Condition = p.parseParenExpression(); cond.Condition == nil {
+			return nil
+		}
+		p.nextToken()
+		if cond.Consequence = p.parseBodyStatement(); cond.Consequence == nil {
+			return nil
+		}
+		p.finish(cond, cond.Token)
+		stmt.Conditionals = append(stmt.Conditionals, cond)
+	}
+
+	if p.peekTokenIs(token.Else) {
+		p.nextToken()
+		p.nextToken()
+		if stmt.Alternative = p.parseBodyStatement(); stmt.Alternative == nil {
+			return nil
+		}
+	}
+	return stmt
+}
+
+func (p *Parser) parseAltIfStatement(stmt *ast.IfStatement, cond *ast.ConditionalExpression) ast.Statement {
+	p.nextToken()
+	for {
+		if cond.Consequence = p.parseStatementList(token.Elseif, token.Else, token.Endif); cond.Consequence == nil {
+			return nil
+		}
+		p.setRange(cond, cond.Token.Pos(p.filename()), cond.Consequence.End())
+		stmt.Conditionals = append(stmt.Conditionals, cond)
+		if !p.curTokenIs(token.Elseif) {
+			break
+		}
+		cond = &ast.ConditionalExpression{BaseNode: p.newBaseNode()}
+		if cond.Condition = p.parseParenExpression(); cond.Condition == nil {
+			return nil
+		}
+		if !p.expectPeek(token.Colon) {
+			return nil
+		}
+	}
+
+	if p.curTokenIs(token.Else) {
+		if !p.expectPeek(token.Colon) {
+			return nil
+		}
+		if stmt.Alternative = p.parseStatementList(token.Endif); stmt.Alternative == nil {
+			return nil
+		}
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+// parseWhileStatement parses
+//
+//	while (expr) statement
+//	while (expr): statements endwhile;
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{BaseNode: p.newBaseNode()}
+	if stmt.Condition = p.parseParenExpression(); stmt.Condition == nil {
+		return nil
+	}
+	p.enterJump(jumpLoop)
+	defer p.leaveJump()
+	p.nextToken()
+	if p.curTokenIs(token.Colon) {
+		stmt.Body = p.parseStatementList(token.Endwhile)
+		if stmt.Body == nil || !p.expectSemicolon() {
+			return nil
+		}
+		return stmt
+	}
+	if stmt.Body = p.parseBodyStatement(); stmt.Body == nil {
+		return nil
+	}
+	return stmt
+}
+
+// parseDoStatement parses `do statement while (expr);`
+func (p *Parser) parseDoStatement() ast.Statement {
+	stmt := &ast.DoStatement{BaseNode: p.newBaseNode()}
+	p.nextToken()
+	p.enterJump(jumpLoop)
+	stmt.Body = p.parseBodyStatement()
+	p.leaveJump()
+	if stmt.Body == nil {
+		return nil
+	}
+	if !p.expectPeek(token.While) {
+		return nil
+	}
+	if stmt.Condition = p.parseParenExpression(); stmt.Condition == nil {
+		return nil
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+// parseForStatement parses
+//
+//	for (exprs; exprs; exprs) statement
+//	for (exprs; exprs; exprs): statements endfor;
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{BaseNode: p.newBaseNode()}
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	if stmt.Init = p.parseExpressionList(token.Semicolon); stmt.Init == nil {
+		return nil
+	}
+	if stmt.Condition = p.parseExpressionList(token.Semicolon); stmt.Condition == nil {
+		return nil
+	}
+	if stmt.Loop = p.parseExpressionList(token.RParen); stmt.Loop == nil {
+		return nil
+	}
+	p.enterJump(jumpLoop)
+	defer p.leaveJump()
+	p.nextToken()
+	if p.curTokenIs(token.Colon) {
+		stmt.Body = p.parseStatementList(token.Endfor)
+		if stmt.Body == nil || !p.expectSemicolon() {
+			return nil
+		}
+		return stmt
+	}
+	if stmt.Body = p.parseBodyStatement(); stmt.Body == nil {
+		return nil
+	}
+	return stmt
+}
+
+// parseForeachStatement parses
+//
+//	foreach (expr as [key =>] [&]value) statement
+//	foreach (expr as [key =>] [&]value): statements endforeach;
+//
+// value can be a list() or [] destructuring.
+func (p *Parser) parseForeachStatement() ast.Statement {
+	stmt := &ast.ForeachStatement{BaseNode: p.newBaseNode()}
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	p.nextToken()
+	if stmt.Expression = p.parseExpression(precLowest); stmt.Expression == nil {
+		return nil
+	}
+	if !p.expectPeek(token.As) {
+		return nil
+	}
+
+	p.nextToken()
+	value, byRef := p.parseForeachVariable()
+	if value == nil {
+		return nil
+	}
+	if p.peekTokenIs(token.DoubleArrow) {
+		if byRef {
+			p.errorf(SyntaxError, "Key element cannot be a reference")
+			return nil
+		}
+		if _, ok := value.(*ast.ListExpression); ok {
+			p.errorf(SyntaxError, "Cannot use list as key element")
+			return nil
+		}
+		stmt.Key = value
+		p.nextToken()
+		p.nextToken()
+		if value, byRef = p.parseForeachVariable(); value == nil {
+			return nil
+		}
+	}
+	stmt.Value, stmt.ByRef = value, byRef
+	if !p.expectPeek(token.RParen) {
+		return nil
+	}
+	p.enterJump(jumpLoop)
+	defer p.leaveJump()
+
+	p.nextToken()
+	if p.curTokenIs(token.Colon) {
+		stmt.Body = p.parseStatementList(token.Endforeach)
+		if stmt.Body == nil || !p.expectSemicolon() {
+			return nil
+		}
+		return stmt
+	}
+	if stmt.Body = p.parseBodyStatement(); stmt.Body == nil {
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) parseForeachVariable() (ast.Expression, bool) {
+	byRef := false
+	if p.curTokenIs(token.Ampersand) {
+		byRef = true
+		p.nextToken()
+	}
+	var exp ast.Expression
+	if p.curTokenIs(token.List) || p.curTokenIs(token.LBracket) {
+		if byRef {
+			p.errorf(SyntaxError, "Cannot assign reference to non referencable value")
+			return nil, false
+		}
+		exp = p.parseListExpression()
+	} else {
+		exp = p.parseExpression(precLowest)
+	}
+	if exp == nil || p.error != nil || !p.checkWritable(exp) {
+		return nil, false
+	}
+	return exp, byRef
+}
+
+// parseSwitchStatement parses
+//
+//	switch (expr) { case_list }
+//	switch (expr): case_list endswitch;
+func (p *Parser) parseSwitchStatement() ast.Statement {
+	stmt := &ast.SwitchStatement{BaseNode: p.newBaseNode()}
+	if stmt.Condition = p.parseParenExpression(); stmt.Condition == nil {
+		return nil
+	}
+
+	end := token.RBrace
+	p.nextToken()
+	if p.curTokenIs(token.Colon) {
+		end = token.Endswitch
+	} else if !p.curTokenIs(token.LBrace) {
+		p.unexpectedError()
+		return nil
+	}
+	if p.peekTokenIs(token.Semicolon) { // switch ($a) {; case ...
+		p.nextToken()
+	}
+
+	p.enterJump(jumpSwitch)
+	defer p.leaveJump()
+	p.nextToken()
+	hasDefault := false
+	for !p.curTokenIs(end) {
+		if !p.curTokenIs(token.Case) && !p.curTokenIs(token.Default) {
+			p.unexpectedError()
+			return nil
+		}
+		c := &ast.CaseStatement{BaseNode: p.newBaseNode()}
+		if p.curTokenIs(token.Default) {
+			if hasDefault {
+				p.errorf(SyntaxError, "Switch statements may only contain one default clause")
+				return nil
+			}
+			hasDefault = true
+		} else {
+			p.nextToken()
+			if c.Value = p.parseExpression(precLowest); c.Value == nil {
+				return nil
+			}
+		}
+		// case_separator: ':' | ';'
+		if !p.peekTokenIs(token.Semicolon) && !p.expectPeek(token.Colon) {
+			return nil
+		}
+		if p.peekTokenIs(token.Semicolon) {
+			p.nextToken()
+		}
+		if c.Body = p.parseStatementList(token.Case, token.Default, end); c.Body == nil {
+			return nil
+		}
+		p.setRange(c, c.Token.Pos(p.filename()), c.Body.End())
+		stmt.Cases = append(stmt.Cases, c)
+	}
+
+	if end == token.Endswitch && !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) parseBreakStatement() ast.Statement {
+	stmt := &ast.BreakStatement{BaseNode: p.newBaseNode()}
+	if !p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+		if stmt.Level = p.parseExpression(precLowest); stmt.Level == nil {
+			return nil
+		}
+	}
+	if !p.checkJumpLevel("break", stmt.Level) {
+		return nil
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
 }
 
-func (p *Parser) parseIncludeStatement(once bool) ast.Statement {
-	return nil
+func (p *Parser) parseContinueStatement() ast.Statement {
+	stmt := &ast.ContinueStatement{BaseNode: p.newBaseNode()}
+	if !p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+		if stmt.Level = p.parseExpression(precLowest); stmt.Level == nil {
+			return nil
+		}
+	}
+	if !p.checkJumpLevel("continue", stmt.Level) {
+		return nil
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
 }
 
-func (p *Parser) parseRequireStatement(once bool) ast.Statement {
-	return nil
+// parseEchoStatement parses the expression list of `echo` or `<?=`
+func (p *Parser) parseEchoStatement() ast.Statement {
+	stmt := &ast.EchoStatement{BaseNode: p.newBaseNode()}
+	for {
+		p.nextToken()
+		exp := p.parseExpression(precLowest)
+		if exp == nil {
+			return nil
+		}
+		stmt.Expressions = append(stmt.Expressions, exp)
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
 }
 
-func (p *Parser) parseEvalStatement() ast.Statement {
-	return nil
+// parseTryStatement parses `try { } catch (A | B [$e]) { } ... [finally { }]`
+func (p *Parser) parseTryStatement() ast.Statement {
+	stmt := &ast.TryStatement{BaseNode: p.newBaseNode()}
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	if stmt.Body = p.parseBlockStatement(); stmt.Body == nil {
+		return nil
+	}
+	for p.peekTokenIs(token.Catch) {
+		p.nextToken()
+		c := p.parseCatchStatement()
+		if c == nil {
+			return nil
+		}
+		stmt.Catches = append(stmt.Catches, c)
+	}
+	if p.peekTokenIs(token.Finally) {
+		p.nextToken()
+		if !p.expectPeek(token.LBrace) {
+			return nil
+		}
+		p.enterJump(jumpFinally)
+		stmt.Finally = p.parseBlockStatement()
+		p.leaveJump()
+		if stmt.Finally == nil {
+			return nil
+		}
+	}
+	if len(stmt.Catches) == 0 && stmt.Finally == nil {
+		p.errorf(SyntaxError, "Cannot use try without catch or finally")
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) parseCatchStatement() *ast.CatchStatement {
+	c := &ast.CatchStatement{BaseNode: p.newBaseNode()}
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	for {
+		p.nextToken()
+		relative := p.curTokenIs(token.Namespace) && p.peekTokenIs(token.NsSeparator)
+		if !p.curTokenIs(token.String) && !p.curTokenIs(token.NsSeparator) && !relative {
+			p.unexpectedError()
+			return nil
+		}
+		name := p.parseName()
+		if name == "" {
+			return nil
+		}
+		c.Types = append(c.Types, name)
+		if !p.peekTokenIs(token.Bar) {
+			break
+		}
+		p.nextToken()
+	}
+	if p.peekTokenIs(token.Variable) {
+		p.nextToken()
+		c.Variable = p.curToken.Literal[1:]
+		if !p.checkWritable(&ast.Variable{BaseNode: p.newBaseNode(), Name: c.Variable}) {
+			return nil
+		}
+	} else if !p.requireVersion(800, "catch without a variable") {
+		return nil
+	}
+	if !p.expectPeek(token.RParen) || !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	if c.Body = p.parseBlockStatement(); c.Body == nil {
+		return nil
+	}
+	p.finish(c, c.Token)
+	return c
+}
+
+func (p *Parser) parseThrowStatement() ast.Statement {
+	stmt := &ast.ThrowStatement{BaseNode: p.newBaseNode()}
+	p.nextToken()
+	if stmt.Expression = p.parseExpression(precLowest); stmt.Expression == nil {
+		return nil
+	}
+	if !p.expectSemicolon() {
+		return nil
+	}
+	return stmt
 }
@@ -0,0 +1,222 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_IfStatement(t *testing.T) {
+	inputs := []string{
+		`<?php if ($a) { $b; } elseif ($c) { $d; } else if ($e) $f; else { $g; }`,
+		`<?php if ($a): $b; elseif ($c): $d; else: if ($e) $f; else { $g; } endif;`,
+	}
+	for i, input := range inputs {
+		program := parse(t, input)
+		if len(program.Statements) != 1 {
+			t.Fatalf("inputs[%d] - expected 1 statement, got %d", i, len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.IfStatement)
+		if !ok {
+			t.Fatalf("inputs[%d] - expected *ast.IfStatement, got %T", i, program.Statements[0])
+		}
+		if len(stmt.Conditionals) != 2 {
+			t.Fatalf("inputs[%d] - expected 2 conditionals, got %d", i, len(stmt.Conditionals))
+		}
+		if c := stmt.Conditionals[1].Condition.String(); c != "$c" {
+			t.Errorf("inputs[%d] - expected elseif condition $c, got %s", i, c)
+		}
+		if stmt.Alternative == nil || len(stmt.Alternative.Statements) != 1 {
+			t.Fatalf("inputs[%d] - expected else with 1 statement", i)
+		}
+		nested, ok := stmt.Alternative.Statements[0].(*ast.IfStatement)
+		if !ok || nested.Alternative == nil {
+			t.Errorf("inputs[%d] - expected nested if with else, got %s", i, stmt.Alternative.Statements[0])
+		}
+	}
+}
+
+func Test_LoopStatements(t *testing.T) {
+	inputs := []string{
+		`<?php while ($a < 10) { $a++; } while ($a): $a--; endwhile;`,
+		`<?php do { $a++; } while ($a < 10); do $a--; while ($a);`,
+		`<?php for ($i, $j; $i < 10; $i++, $j--) { } for (;;): break; endfor;`,
+	}
+	types := []string{"*ast.WhileStatement", "*ast.DoStatement", "*ast.ForStatement"}
+	for i, input := range inputs {
+		program := parse(t, input)
+		if len(program.Statements) != 2 {
+			t.Fatalf("inputs[%d] - expected 2 statements, got %d", i, len(program.Statements))
+		}
+		for _, stmt := range program.Statements {
+			var body *ast.BlockStatement
+			switch s := stmt.(type) {
+			case *ast.WhileStatement:
+				body = s.Body
+			case *ast.DoStatement:
+				body = s.Body
+			case *ast.ForStatement:
+				body = s.Body
+			}
+			if body == nil {
+				t.Errorf("inputs[%d] - expected %s, got %T", i, types[i], stmt)
+			}
+		}
+	}
+
+	program := parse(t, `<?php for ($i, $j; $i < 10; $i++, $j--) {}`)
+	stmt := program.Statements[0].(*ast.ForStatement)
+	if len(stmt.Init) != 2 || len(stmt.Condition) != 1 || len(stmt.Loop) != 2 {
+		t.Errorf("unexpected for expressions: %s", stmt)
+	}
+}
+
+func Test_ForeachStatement(t *testing.T) {
+	tests := []struct {
+		input string
+		key   string
+		value string
+		byRef bool
+	}{
+		{`<?php foreach ($a as $v) {}`, "", "$v", false},
+		{`<?php foreach ($a as $k => $v) {}`, "$k", "$v", false},
+		{`<?php foreach ($a as $k => &$v) {}`, "$k", "$v", true},
+		{`<?php foreach ($a as &$v): endforeach;`, "", "$v", true},
+		{`<?php foreach ($a as list($x, , list($y, $z))) {}`, "", "list($x, , list($y, $z))", false},
+		{`<?php foreach ($a as $k => [$x, [$y]]) $x;`, "$k", "[$x, [$y]]", false},
+		{`<?php foreach ($a as [$i => $x, $j => [&$y]]) {}`, "", "[$i => $x, $j => [&$y]]", false},
+	}
+	for i, tt := range tests {
+		program := parse(t, tt.input)
+		stmt, ok := program.Statements[0].(*ast.ForeachStatement)
+		if !ok {
+			t.Fatalf("tests[%d] - expected *ast.ForeachStatement, got %T", i, program.Statements[0])
+		}
+		if tt.key == "" && stmt.Key != nil || tt.key != "" && (stmt.Key == nil || stmt.Key.String() != tt.key) {
+			t.Errorf("tests[%d] - key wrong. expected=%q, got=%v", i, tt.key, stmt.Key)
+		}
+		if stmt.Value.String() != tt.value {
+			t.Errorf("tests[%d] - value wrong. expected=%q, got=%q", i, tt.value, stmt.Value)
+		}
+		if stmt.ByRef != tt.byRef {
+			t.Errorf("tests[%d] - byRef wrong. expected=%v, got=%v", i, tt.byRef, stmt.ByRef)
+		}
+	}
+}
+
+func Test_SwitchStatement(t *testing.T) {
+	inputs := []string{
+		`<?php switch ($a) { case 1: case 2; $b; break; default: $c; }`,
+		`<?php switch ($a): ; case 1: case 2; $b; break; default: $c; endswitch;`,
+	}
+	for i, input := range inputs {
+		program := parse(t, input)
+		stmt, ok := program.Statements[0].(*ast.SwitchStatement)
+		if !ok {
+			t.Fatalf("inputs[%d] - expected *ast.SwitchStatement, got %T", i, program.Statements[0])
+		}
+		if len(stmt.Cases) != 3 {
+			t.Fatalf("inputs[%d] - expected 3 cases, got %d", i, len(stmt.Cases))
+		}
+		if len(stmt.Cases[0].Body.Statements) != 0 || len(stmt.Cases[1].Body.Statements) != 2 {
+			t.Errorf("inputs[%d] - unexpected case bodies: %s", i, stmt)
+		}
+		if !stmt.Cases[2].IsDefault() {
+			t.Errorf("inputs[%d] - expected last case to be default", i)
+		}
+	}
+}
+
+func Test_BreakContinue(t *testing.T) {
+	program := parse(t, `<?php while (1) { while (1) { break; continue 2; break (1); } }`)
+	outer := program.Statements[0].(*ast.WhileStatement).Body.Statements
+	body := outer[0].(*ast.WhileStatement).Body.Statements
+	if b := body[0].(*ast.BreakStatement); b.Level != nil {
+		t.Errorf("expected break without level, got %s", b.Level)
+	}
+	if c := body[1].(*ast.ContinueStatement); c.Level.String() != "2" {
+		t.Errorf("expected continue 2, got %s", c)
+	}
+	if b := body[2].(*ast.BreakStatement); b.Level.String() != "1" {
+		t.Errorf("expected break 1, got %s", b)
+	}
+}
+
+func Test_TryStatement(t *testing.T) {
+	program := parse(t, `<?php
+try { $a; } catch (A | \B\C $e) { $b; } catch (D) { } finally { $c; }
+try { } finally { }
+throw $e;
+$a ?? throw $e;
+`)
+	tests := []string{
+		"try {\n$a\n} catch (A | \\B\\C $e) {\n$b\n} catch (D) {\n\n} finally {\n$c\n}",
+		"try {\n\n} finally {\n\n}",
+		"throw $e;",
+		"($a ?? (throw $e))",
+	}
+	if len(program.Statements) != len(tests) {
+		t.Fatalf("expected %d statements, got %d", len(tests), len(program.Statements))
+	}
+	for i, expected := range tests {
+		if program.Statements[i].String() != expected {
+			t.Errorf("statements[%d] - expected=%q, got=%q", i, expected, program.Statements[i].String())
+		}
+	}
+	stmt := program.Statements[0].(*ast.TryStatement)
+	if len(stmt.Catches[0].Types) != 2 || stmt.Catches[1].Variable != "" {
+		t.Errorf("unexpected catches: %s", stmt)
+	}
+}
+
+func Test_ControlFlowErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`<?php if ($a): $b; else { $c; } endif;`, "unexpected token \"{\", expecting \":\""},
+		{`<?php if ($a) { $b; endif;`, "unexpected token \"endif\""},
+		{`<?php while ($a): $b; endfor;`, "unexpected token \"endfor\""},
+		{`<?php foreach ($a as &$k => $v) {}`, "Key element cannot be a reference"},
+		{`<?php foreach ($a as [$x, ...$y]) {}`, "Spread operator is not supported in assignments"},
+		{`<?php switch ($a) { default: default: }`, "Switch statements may only contain one default clause"},
+		{`<?php switch ($a) { $b; }`, "unexpected variable"},
+		{`<?php do { } while ($a)`, "unexpected end of file"},
+		{`<?php try { $a; }`, "Cannot use try without catch or finally"},
+		{`<?php try { } catch ($e) { }`, "unexpected variable"},
+		{`<?php try { } catch (A $e, B $f) { }`, "unexpected token \",\", expecting \")\""},
+	}
+	for i, tt := range tests {
+		err := parseError(t, tt.input)
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %q", i, tt.message, err.Message)
+		}
+	}
+}
+
+func Test_InlineHtmlAndEcho(t *testing.T) {
+	program := parse(t, "<ul>\n<?php foreach ($items as $item): ?>\n  <li><?= $item, $sep ?></li>\n<?php endforeach ?>\n</ul>\n")
+	if len(program.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(program.Statements))
+	}
+	if html, ok := program.Statements[0].(*ast.InlineHtmlStatement); !ok || html.Value != "<ul>\n" {
+		t.Errorf("expected inline html %q, got %s", "<ul>\n", program.Statements[0])
+	}
+	body := program.Statements[1].(*ast.ForeachStatement).Body.Statements
+	expected := []string{"?>  <li><?php ", "echo $item, $sep;", "?></li>\n<?php "}
+	if len(body) != len(expected) {
+		t.Fatalf("expected %d statements in foreach, got %d", len(expected), len(body))
+	}
+	for i, stmt := range body {
+		if stmt.String() != expected[i] {
+			t.Errorf("body[%d] - expected=%q, got=%q", i, expected[i], stmt.String())
+		}
+	}
+	if _, ok := body[1].(*ast.EchoStatement); !ok {
+		t.Errorf("expected *ast.EchoStatement, got %T", body[1])
+	}
+	if err := parseError(t, `<?= $a`); !strings.Contains(err.Message, "unexpected end of file") {
+		t.Errorf("unexpected error: %s", err.Message)
+	}
+}
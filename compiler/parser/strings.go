@@ -0,0 +1,372 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/token"
+)
+
+// parseInterpolatedString parses a double quoted string, a heredoc or a
+// backtick string, curToken is the opening delimiter. Strings without
+// embedded expressions are returned as *ast.StringLiteral.
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	tok := p.curToken
+	end, quote := token.EndHeredoc, byte(0)
+	switch tok.Type {
+	case token.DoubleQuotes:
+		end, quote = token.DoubleQuotes, '"'
+	case token.Backquote:
+		end, quote = token.Backquote, '`'
+	}
+	nowdoc := tok.Type == token.StartHeredoc && strings.Contains(tok.Literal, "'")
+
+	parts := []ast.Expression{}
+	starts := []token.Token{}
+	for !p.peekTokenIs(end) {
+		p.nextToken()
+		if p.error != nil {
+			return nil
+		}
+		from := p.curToken
+		var part ast.Expression
+		switch p.curToken.Type {
+		case token.EncapsedAndWhitespace:
+			value := p.curToken.Literal
+			if tok.Type == token.StartHeredoc && p.peekTokenIs(end) {
+				// the newline before the closing label is not part of the string
+				value = strings.TrimSuffix(strings.TrimSuffix(value, "\n"), "\r")
+				if value == "" {
+					continue
+				}
+			}
+			// the escapes are interpreted once the indentation of a
+			// heredoc is removed
+			part = &ast.StringLiteral{BaseNode: p.newBaseNode(), Value: value}
+		case token.Variable:
+			part = p.parseEncapsVariable()
+		case token.CurlyOpen: // {$expr}
+			p.nextToken()
+			if part = p.parseExpression(precLowest); part != nil && !p.expectPeek(token.RBrace) {
+				return nil
+			}
+		case token.DollarOpenCurlyBraces:
+			part = p.parseDollarCurlyVariable()
+		default:
+			p.unexpectedError()
+			return nil
+		}
+		if part == nil {
+			return nil
+		}
+		p.finish(part, from)
+		parts = append(parts, part)
+		starts = append(starts, from)
+	}
+	p.nextToken()
+
+	if tok.Type == token.StartHeredoc {
+		if parts = p.removeDocIndent(parts, starts); parts == nil {
+			return nil
+		}
+	}
+	for _, part := range parts {
+		if lit, ok := part.(*ast.StringLiteral); ok && !nowdoc {
+			lit.Value = unescape(lit.Value, quote)
+		}
+	}
+
+	if tok.Type == token.Backquote {
+		return &ast.ShellExecExpression{BaseNode: &ast.BaseNode{Token: tok}, Parts: parts}
+	}
+	switch len(parts) {
+	case 0:
+		return &ast.StringLiteral{BaseNode: &ast.BaseNode{Token: tok}}
+	case 1:
+		if lit, ok := parts[0].(*ast.StringLiteral); ok {
+			// the literal covers the whole string, quotes included
+			lit.SetRange(tok.Pos(p.filename()), p.curToken.End(p.filename()))
+			return lit
+		}
+	}
+	return &ast.InterpolatedStringExpression{BaseNode: &ast.BaseNode{Token: tok}, Parts: parts}
+}
+
+// removeDocIndent removes the indentation of the closing label, curToken,
+// from the lines of a heredoc (PHP 7.3), starts holds the first token of
+// each part. The lines start at the first column of the source, each of
+// them must be indented at least as much as the closing label, except the
+// empty ones.
+func (p *Parser) removeDocIndent(parts []ast.Expression, starts []token.Token) []ast.Expression {
+	label := p.curToken.Literal
+	indent := label[:len(label)-len(strings.TrimLeft(label, " \t"))]
+	if indent == "" {
+		return parts
+	}
+	if strings.Trim(indent, indent[:1]) != "" {
+		p.errorf(SyntaxError, "Invalid indentation - tabs and spaces cannot be mixed")
+		return nil
+	}
+	kept := []ast.Expression{}
+	for i, part := range parts {
+		tok := starts[i]
+		lit, ok := part.(*ast.StringLiteral)
+		if !ok {
+			if tok.Column == 1 {
+				p.errorAt(tok, SyntaxError, "Invalid body indentation level (expecting an indentation level of at least %d)", len(indent))
+				return nil
+			}
+			kept = append(kept, part)
+			continue
+		}
+		value, msg := removeIndent(lit.Value, indent, tok.Column == 1, i == len(parts)-1)
+		if msg != "" {
+			p.errorAt(tok, SyntaxError, "%s", msg)
+			return nil
+		}
+		if lit.Value = value; value != "" {
+			kept = append(kept, lit)
+		}
+	}
+	return kept
+}
+
+// removeIndent removes indent from the lines of s, lineStart tells whether
+// s starts a line and last whether it ends the heredoc. It returns the
+// error message for a line indented less than indent.
+func removeIndent(s, indent string, lineStart, last bool) (string, string) {
+	var out strings.Builder
+	for i := 0; i < len(s); lineStart = true {
+		if lineStart {
+			n := 0
+			for n < len(indent) && i+n < len(s) && (s[i+n] == ' ' || s[i+n] == '\t') {
+				if s[i+n] != indent[0] {
+					return "", "Invalid indentation - tabs and spaces cannot be mixed"
+				}
+				n++
+			}
+			if n < len(indent) {
+				// only the empty lines may be indented less
+				if i+n < len(s) && s[i+n] != '\n' && s[i+n] != '\r' || i+n == len(s) && !last {
+					return "", fmt.Sprintf("Invalid body indentation level (expecting an indentation level of at least %d)", len(indent))
+				}
+			}
+			i += n
+		}
+		j := strings.IndexByte(s[i:], '\n')
+		if j < 0 {
+			out.WriteString(s[i:])
+			break
+		}
+		out.WriteString(s[i : i+j+1])
+		i += j + 1
+	}
+	return out.String(), ""
+}
+
+// parseEncapsVariable parses the simple interpolation syntax `$a`,
+// `$a[0]`, `$a[key]`, `$a[$b]` and `$a->b`.
+func (p *Parser) parseEncapsVariable() ast.Expression {
+	v := p.parseVariable()
+	switch {
+	case p.peekTokenIs(token.LBracket):
+		p.nextToken()
+		exp := &ast.IndexExpression{BaseNode: p.newBaseNode(), Left: v}
+		p.nextToken()
+		switch p.curToken.Type {
+		case token.NumString:
+			exp.Index = p.parseNumString()
+		case token.String:
+			exp.Index = &ast.StringLiteral{BaseNode: p.newBaseNode(), Value: p.curToken.Literal}
+		case token.Variable:
+			exp.Index = p.parseVariable()
+		default:
+			p.unexpectedError()
+			return nil
+		}
+		p.finish(exp.Index, p.curToken)
+		if !p.expectPeek(token.RBracket) {
+			return nil
+		}
+		return exp
+	case p.peekTokenIs(token.ObjectOperator):
+		p.nextToken()
+		exp := &ast.PropertyFetchExpression{BaseNode: p.newBaseNode(), Object: v}
+		if !p.expectPeek(token.String) {
+			return nil
+		}
+		exp.Property = &ast.Identifier{BaseNode: p.newBaseNode(), Value: p.curToken.Literal}
+		p.finish(exp.Property, p.curToken)
+		return exp
+	}
+	return v
+}
+
+// parseNumString parses the offset of `"$a[0]"`, like PHP it's an integer
+// only when written in canonical decimal form.
+func (p *Parser) parseNumString() ast.Expression {
+	lit := p.curToken.Literal
+	if lit == "0" || lit[0] != '0' {
+		if v, err := strconv.ParseInt(lit, 10, 64); err == nil {
+			return &ast.IntegerLiteral{BaseNode: p.newBaseNode(), Value: int(v)}
+		}
+	}
+	return &ast.StringLiteral{BaseNode: p.newBaseNode(), Value: lit}
+}
+
+// parseDollarCurlyVariable parses `${name}`, `${name[expr]}` and `${expr}`
+// inside a string, curToken is `${`.
+func (p *Parser) parseDollarCurlyVariable() ast.Expression {
+	tok := p.curToken
+	var exp ast.Expression
+	if p.peekTokenIs(token.StringVarname) {
+		p.nextToken()
+		exp = &ast.Variable{BaseNode: p.newBaseNode(), Name: p.curToken.Literal}
+		p.finish(exp, p.curToken)
+		if p.peekTokenIs(token.LBracket) {
+			p.nextToken()
+			index := &ast.IndexExpression{BaseNode: p.newBaseNode(), Left: exp}
+			p.nextToken()
+			if index.Index = p.parseExpression(precLowest); index.Index == nil || !p.expectPeek(token.RBracket) {
+				return nil
+			}
+			p.finish(index, exp.(*ast.Variable).Token)
+			exp = index
+		}
+	} else {
+		p.nextToken()
+		name := p.parseExpression(precLowest)
+		if name == nil {
+			return nil
+		}
+		exp = &ast.DynamicVariable{BaseNode: &ast.BaseNode{Token: tok}, Name: name}
+	}
+	if !p.expectPeek(token.RBrace) {
+		return nil
+	}
+	return exp
+}
+
+// unquote returns the value of a single or double quoted string literal.
+func unquote(lit string) string {
+	if len(lit) < 2 {
+		return lit
+	}
+	if lit[0] == '\'' {
+		return unescapeSingle(lit[1 : len(lit)-1])
+	}
+	return unescape(lit[1:len(lit)-1], '"')
+}
+
+// unescapeSingle handles the only two escapes allowed in single quoted
+// strings: \' and \\
+func unescapeSingle(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '\'' || s[i+1] == '\\') {
+			i++
+		}
+		out.WriteByte(s[i])
+	}
+	return out.String()
+}
+
+// unescape interprets the escape sequences of double quoted strings,
+// heredocs and backticks. quote is the delimiter that may be escaped,
+// 0 for heredocs where \" is kept as is.
+func unescape(s string, quote byte) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			out.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case 'v':
+			out.WriteByte('\v')
+		case 'e':
+			out.WriteByte(0x1b)
+		case 'f':
+			out.WriteByte('\f')
+		case '\\', '$':
+			out.WriteByte(c)
+		case 'x':
+			n := 0
+			for n < 2 && i+1+n < len(s) && isHexDigit(s[i+1+n]) {
+				n++
+			}
+			if n == 0 {
+				out.WriteString("\\x")
+				break
+			}
+			v, _ := strconv.ParseUint(s[i+1:i+1+n], 16, 8)
+			out.WriteByte(byte(v))
+			i += n
+		case 'u':
+			end := strings.IndexByte(s[i:], '}')
+			if i+1 >= len(s) || s[i+1] != '{' || end < 0 {
+				out.WriteString("\\u")
+				break
+			}
+			v, err := strconv.ParseUint(s[i+2:i+end], 16, 32)
+			if err != nil {
+				out.WriteString("\\u")
+				break
+			}
+			var buf [utf8.UTFMax]byte
+			out.Write(buf[:utf8.EncodeRune(buf[:], rune(v))])
+			i += end
+		default:
+			if c == quote {
+				out.WriteByte(c)
+				break
+			}
+			if '0' <= c && c <= '7' {
+				n := 1
+				for n < 3 && i+n < len(s) && '0' <= s[i+n] && s[i+n] <= '7' {
+					n++
+				}
+				v, _ := strconv.ParseUint(s[i:i+n], 8, 16)
+				out.WriteByte(byte(v))
+				i += n - 1
+				break
+			}
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// parseOverflowInteger converts an integer literal that does not fit
+// into int64 to float, in any of the decimal, hex, octal or binary forms.
+func parseOverflowInteger(lit string) float64 {
+	n, ok := new(big.Int).SetString(lit, 0)
+	if !ok {
+		return 0
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return f
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+func Test_InterpolatedString(t *testing.T) {
+	program := parse(t, `<?php "a\t$b $c[0] $c[01] $d[key] $e[$f] $g->h {$i} ${j} ${k[1]} ${$l}";`)
+	exp, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.InterpolatedStringExpression)
+	if !ok {
+		t.Fatalf("expected *ast.InterpolatedStringExpression, got %T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	expected := []string{
+		"'a\t'", "$b", "' '", "$c[0]", "' '", "$c['01']", "' '", "$d['key']", "' '",
+		"$e[$f]", "' '", "$g->h", "' '", "$i", "' '", "$j", "' '", "$k[1]", "' '", "${$l}",
+	}
+	if len(exp.Parts) != len(expected) {
+		t.Fatalf("expected %d parts, got %d: %s", len(expected), len(exp.Parts), exp)
+	}
+	for i, part := range exp.Parts {
+		if part.String() != expected[i] {
+			t.Errorf("parts[%d] - expected=%q, got=%q", i, expected[i], part.String())
+		}
+	}
+	if lit := exp.Parts[0].(*ast.StringLiteral); lit.Value != "a\t" {
+		t.Errorf("expected unescaped literal part, got %q", lit.Value)
+	}
+	if _, ok := exp.Parts[3].(*ast.IndexExpression).Index.(*ast.IntegerLiteral); !ok {
+		t.Errorf("expected integer offset, got %T", exp.Parts[3].(*ast.IndexExpression).Index)
+	}
+	if _, ok := exp.Parts[5].(*ast.IndexExpression).Index.(*ast.StringLiteral); !ok {
+		t.Errorf("expected string offset, got %T", exp.Parts[5].(*ast.IndexExpression).Index)
+	}
+}
+
+func Test_HeredocAndShellExec(t *testing.T) {
+	program := parse(t, "<?php\n"+
+		"<<<EOT\nHello\\t$name\nEOT;\n"+
+		"<<<\"EOT\"\nplain \\x41\nEOT;\n"+
+		"<<<'EOT'\nraw $name\\t\nEOT;\n"+
+		"<<<EOT\nEOT;\n"+
+		"`ls $dir`;\n")
+	exps := []ast.Expression{}
+	for _, stmt := range program.Statements {
+		exps = append(exps, stmt.(*ast.ExpressionStatement).Expression)
+	}
+	if len(exps) != 5 {
+		t.Fatalf("expected 5 expressions, got %d", len(exps))
+	}
+
+	heredoc, ok := exps[0].(*ast.InterpolatedStringExpression)
+	if !ok || len(heredoc.Parts) != 2 {
+		t.Fatalf("expected interpolated heredoc with 2 parts, got %T %s", exps[0], exps[0])
+	}
+	if lit := heredoc.Parts[0].(*ast.StringLiteral); lit.Value != "Hello\t" {
+		t.Errorf("expected %q, got %q", "Hello\t", lit.Value)
+	}
+	values := map[int]string{1: "plain A", 2: "raw $name\\t", 3: ""}
+	for i, value := range values {
+		lit, ok := exps[i].(*ast.StringLiteral)
+		if !ok {
+			t.Fatalf("exps[%d] - expected *ast.StringLiteral, got %T", i, exps[i])
+		}
+		if lit.Value != value {
+			t.Errorf("exps[%d] - expected %q, got %q", i, value, lit.Value)
+		}
+	}
+
+	shell, ok := exps[4].(*ast.ShellExecExpression)
+	if !ok {
+		t.Fatalf("expected *ast.ShellExecExpression, got %T", exps[4])
+	}
+	if shell.String() != "`ls {$dir}`" {
+		t.Errorf("expected %q, got %q", "`ls {$dir}`", shell.String())
+	}
+}
+
+func Test_FlexibleHeredoc(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"foo(<<<EOT\nx\nEOT);", `foo('x')`},
+		{"[<<<EOT\nx\nEOT, 2];", `['x', 2]`},
+		{"$a = <<<EOT\nx\nEOT;", `($a = 'x')`},
+		{"$a = <<<EOT\nx\nEOT?>", `($a = 'x')`},
+		{"<<<EOT\n    a\n      b\n\n    c\n    EOT;", "'a\n  b\n\nc'"},
+		{"<<<'EOT'\n\ta $b\n\tEOT;", `'a $b'`},
+		{"<<<EOT\n  EOT;", `''`},
+	}
+	for _, tt := range tests {
+		program := parse(t, "<?php "+tt.input)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+
+	program := parse(t, "<?php <<<EOT\n  a $b\n  {$c}\\t\n  EOT;")
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.InterpolatedStringExpression)
+	values := []string{}
+	for _, part := range exp.Parts {
+		if lit, ok := part.(*ast.StringLiteral); ok {
+			values = append(values, lit.Value)
+		}
+	}
+	if s := strings.Join(values, "|"); s != "a |\n|\t" {
+		t.Errorf("expected the indentation removed, got %q", s)
+	}
+}
+
+func Test_InterpolatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`<?php "{$a";`, "unexpected double-quote mark"},
+		{`<?php "${a";`, "unexpected double-quote mark"},
+		{"<?php <<<EOT\n  a\n b\n  EOT;", "Invalid body indentation level (expecting an indentation level of at least 2)"},
+		{"<?php <<<EOT\n  a\n$b\n  EOT;", "Invalid body indentation level (expecting an indentation level of at least 2)"},
+		{"<?php <<<EOT\n  a\n $b\n  EOT;", "Invalid body indentation level (expecting an indentation level of at least 2)"},
+		{"<?php <<<EOT\n\ta\n  EOT;", "Invalid indentation - tabs and spaces cannot be mixed"},
+		{"<?php <<<EOT\n \ta\n \tEOT;", "Invalid indentation - tabs and spaces cannot be mixed"},
+	}
+	for i, tt := range tests {
+		err := parseError(t, tt.input)
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("tests[%d] - expected error containing %q, got %q", i, tt.message, err.Message)
+		}
+	}
+}
@@ -0,0 +1,592 @@
+package printer
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+// Operator precedences, from lowest to highest, the same as the parser's
+const (
+	_ int = iota
+	precLowest
+	precLogicalOr  // or
+	precLogicalXor // xor
+	precLogicalAnd // and
+	precAssign     // = += -= ...
+	precTernary    // ? :
+	precCoalesce   // ??
+	precBooleanOr  // ||
+	precBooleanAnd // &&
+	precBitwiseOr  // |
+	precBitwiseXor // ^
+	precBitwiseAnd // &
+	precEquality   // == != === !== <>  <=>
+	precCompare    // < <= > >=
+	precConcat     // .
+	precShift      // << >>
+	precSum        // + -
+	precProduct    // * / %
+	precNot        // !
+	precInstanceof // instanceof
+	precPrefix     // ~ ++ -- (int) @ -$a
+	precPow        // **
+	precClone      // clone new
+	precPostfix    // $a++ $a--
+	precCall       // () [] -> ?-> ::
+	precPrimary    // variables, literals and the other self-delimited expressions
+)
+
+var precedences = map[string]int{
+	"or":         precLogicalOr,
+	"xor":        precLogicalXor,
+	"and":        precLogicalAnd,
+	"??":         precCoalesce,
+	"||":         precBooleanOr,
+	"&&":         precBooleanAnd,
+	"|":          precBitwiseOr,
+	"^":          precBitwiseXor,
+	"&":          precBitwiseAnd,
+	"==":         precEquality,
+	"!=":         precEquality,
+	"<>":         precEquality,
+	"===":        precEquality,
+	"!==":        precEquality,
+	"<=>":        precEquality,
+	"<":          precCompare,
+	"<=":         precCompare,
+	">":          precCompare,
+	">=":         precCompare,
+	".":          precConcat,
+	"<<":         precShift,
+	">>":         precShift,
+	"+":          precSum,
+	"-":          precSum,
+	"*":          precProduct,
+	"/":          precProduct,
+	"%":          precProduct,
+	"instanceof": precInstanceof,
+	"**":         precPow,
+}
+
+func infixPrecedence(op string) int {
+	if prec, ok := precedences[strings.ToLower(op)]; ok {
+		return prec
+	}
+	return precLowest
+}
+
+// precedence returns the precedence of the operator at the top of exp
+// and the one its rightmost operand is parsed with. The first one tells
+// which operator exp can be the operand of, the second which operators
+// can follow exp without becoming part of its last operand: in `!$a + 1`
+// the addition binds tighter than the negation, so `(!$a) + 1` needs
+// parentheses.
+func precedence(exp ast.Expression) (prec, right int) {
+	switch e := exp.(type) {
+	case *ast.InfixExpression:
+		prec = infixPrecedence(e.Operator)
+		if prec == precCoalesce || prec == precPow { // right associative
+			return prec, prec - 1
+		}
+		return prec, prec
+	case *ast.TernaryExpression:
+		return precTernary, precTernary
+	case *ast.AssignExpression, *ast.AssignRefExpression, *ast.CompoundAssignExpression:
+		return precAssign, precAssign - 1
+	case *ast.PostfixExpression:
+		return precPostfix, precPrimary
+	case *ast.PrefixExpression:
+		if e.Operator == "!" {
+			return precPrimary, precNot
+		}
+		return precPrimary, precPrefix
+	case *ast.CastExpression:
+		return precPrimary, precPrefix
+	case *ast.CloneExpression, *ast.NewExpression:
+		return precPrimary, precClone
+	case *ast.ClosureExpression:
+		// a closure can't be called or dereferenced without parentheses
+		return precPrimary, precClone
+	case *ast.PrintExpression, *ast.YieldExpression, *ast.YieldFromExpression:
+		return precPrimary, precLogicalAnd
+	case *ast.ArrowFunctionExpression, *ast.ThrowExpression:
+		return precPrimary, precLowest
+	case *ast.IncludeOrEvalExpression:
+		if e.Type != ast.Eval {
+			return precPrimary, precLowest
+		}
+	}
+	return precPrimary, precPrimary
+}
+
+// expr prints exp as the operand of an operator requiring at least the
+// precedence min, next is the precedence of the operator following it.
+// exp is parenthesized when it binds looser than min or would take the
+// next operator in its last operand.
+func (p *printer) expr(exp ast.Expression, min, next int) {
+	if exp == nil {
+		p.errorf("missing expression")
+		return
+	}
+	prec, right := precedence(exp)
+	if prec < min || right < next {
+		p.print("(")
+		p.bare(exp, precLowest)
+		p.print(")")
+		return
+	}
+	p.bare(exp, next)
+}
+
+// bare prints exp without parentheses, next is the precedence of the
+// operator following it.
+func (p *printer) bare(exp ast.Expression, next int) {
+	switch e := exp.(type) {
+	case *ast.IntegerLiteral:
+		p.print(strconv.Itoa(e.Value))
+	case *ast.FloatLiteral:
+		p.print(formatFloat(e.Value))
+	case *ast.StringLiteral:
+		p.print(quote(e.Value))
+	case *ast.BooleanExpression:
+		p.print(strconv.FormatBool(e.Value))
+	case *ast.NullExpression:
+		if e.Value == "" {
+			p.print("null")
+			break
+		}
+		p.print(e.Value)
+	case *ast.Identifier:
+		p.print(e.Value)
+	case *ast.Constant:
+		p.print(e.Value)
+	case *ast.Variable:
+		p.print("$", e.Name)
+	case *ast.DynamicVariable:
+		p.dynamicVariable(e)
+	case *ast.InterpolatedStringExpression:
+		p.print(`"`)
+		p.parts(e.Parts, '"')
+		p.print(`"`)
+	case *ast.ShellExecExpression:
+		p.print("`")
+		p.parts(e.Parts, '`')
+		p.print("`")
+	case *ast.ArrayExpression:
+		p.arrayItems(e.Items, e.IsShort, "array(")
+	case *ast.ListExpression:
+		p.arrayItems(e.Items, e.IsShort, "list(")
+
+	case *ast.InfixExpression:
+		prec, right := precedence(e)
+		leftMin, rightMin := prec, prec+1
+		switch {
+		case right < prec: // right associative
+			leftMin, rightMin = prec+1, prec
+		case prec == precEquality || prec == precCompare: // non associative
+			leftMin = prec + 1
+		}
+		p.expr(e.Left, leftMin, prec)
+		p.print(" ", e.Operator, " ")
+		p.expr(e.Right, rightMin, next)
+	case *ast.TernaryExpression:
+		// nested ternaries are parenthesized, PHP 8 rejects them otherwise
+		p.expr(e.Condition, precTernary+1, precTernary)
+		if e.Consequence == nil {
+			p.print(" ?: ")
+		} else {
+			p.print(" ? ")
+			p.expr(e.Consequence, precTernary+1, precLowest)
+			p.print(" : ")
+		}
+		p.expr(e.Alternative, precTernary+1, next)
+	case *ast.AssignExpression:
+		p.expr(e.Left, precCall, precAssign)
+		p.print(" = ")
+		p.expr(e.Right, precAssign, next)
+	case *ast.AssignRefExpression:
+		p.expr(e.Left, precCall, precAssign)
+		p.print(" = &")
+		p.expr(e.Right, precAssign, next)
+	case *ast.CompoundAssignExpression:
+		p.expr(e.Left, precCall, precAssign)
+		p.print(" ", e.Operator, "= ")
+		p.expr(e.Right, precAssign, next)
+	case *ast.PrefixExpression:
+		p.print(e.Operator)
+		start := len(p.out)
+		_, right := precedence(e)
+		p.expr(e.Right, right+1, next)
+		// `- -$a` must not be read as `--$a`
+		if start < len(p.out) && p.out[start] == e.Operator[len(e.Operator)-1] {
+			p.out = append(p.out[:start], append([]byte{' '}, p.out[start:]...)...)
+		}
+	case *ast.PostfixExpression:
+		p.expr(e.Left, precCall, precPostfix)
+		p.print(e.Operator)
+	case *ast.CastExpression:
+		p.print("(", e.Type, ") ")
+		p.expr(e.Expression, precPrefix+1, next)
+	case *ast.CloneExpression:
+		p.print("clone ")
+		p.expr(e.Expression, precClone+1, next)
+	case *ast.PrintExpression:
+		p.print("print ")
+		p.expr(e.Expression, precLogicalAnd+1, next)
+	case *ast.YieldExpression:
+		p.print("yield")
+		if e.Value == nil {
+			break
+		}
+		p.print(" ")
+		if e.Key != nil {
+			p.expr(e.Key, precLogicalAnd+1, precLogicalAnd+1)
+			p.print(" => ")
+		}
+		p.expr(e.Value, precLogicalAnd+1, next)
+	case *ast.YieldFromExpression:
+		p.print("yield from ")
+		p.expr(e.Expression, precLogicalAnd+1, next)
+	case *ast.ThrowExpression:
+		p.print("throw ")
+		p.expr(e.Expression, precLowest, next)
+	case *ast.IncludeOrEvalExpression:
+		if e.Type == ast.Eval {
+			p.print("eval(")
+			p.expr(e.Expression, precLowest, precLowest)
+			p.print(")")
+			break
+		}
+		p.print(e.Type.String(), " ")
+		p.expr(e.Expression, precLowest, next)
+	case *ast.IssetExpression:
+		p.print("isset(")
+		p.exprList(e.Variables)
+		p.print(")")
+	case *ast.EmptyExpression:
+		p.print("empty(")
+		p.expr(e.Expression, precLowest, precLowest)
+		p.print(")")
+	case *ast.ExitExpression:
+		p.print("exit")
+		if e.Expression != nil {
+			p.print("(")
+			p.expr(e.Expression, precLowest, precLowest)
+			p.print(")")
+		}
+
+	case *ast.IndexExpression:
+		p.expr(e.Left, precCall, precCall)
+		p.print("[")
+		if e.Index != nil {
+			p.expr(e.Index, precLowest, precLowest)
+		}
+		p.print("]")
+	case *ast.PropertyFetchExpression:
+		p.expr(e.Object, precCall, precCall)
+		p.print(objectOperator(e.Nullsafe))
+		p.memberName(e.Property)
+	case *ast.StaticPropertyFetchExpression:
+		p.class(e.Class)
+		p.print("::")
+		p.expr(e.Property, precPrimary, precPrimary)
+	case *ast.ClassConstFetchExpression:
+		p.class(e.Class)
+		p.print("::", e.Name)
+	case *ast.CallExpression:
+		p.callee(e.Function)
+		p.arguments(e.Arguments, e.FirstClassCallable)
+	case *ast.MethodCallExpression:
+		p.expr(e.Object, precCall, precCall)
+		p.print(objectOperator(e.Nullsafe))
+		p.memberName(e.Method)
+		p.arguments(e.Arguments, e.FirstClassCallable)
+	case *ast.StaticCallExpression:
+		p.class(e.Class)
+		p.print("::")
+		p.memberName(e.Method)
+		p.arguments(e.Arguments, e.FirstClassCallable)
+	case *ast.NewExpression:
+		p.newExpression(e)
+
+	case *ast.ClosureExpression:
+		p.attributes(e.Attributes, false)
+		if e.Static {
+			p.print("static ")
+		}
+		p.print("function ")
+		if e.ByRef {
+			p.print("&")
+		}
+		p.signature(e.Parameters, e.Uses, e.ReturnType)
+		p.print(" ")
+		p.block(e.Body)
+	case *ast.ArrowFunctionExpression:
+		p.attributes(e.Attributes, false)
+		if e.Static {
+			p.print("static ")
+		}
+		p.print("fn")
+		if e.ByRef {
+			p.print("&")
+		}
+		p.signature(e.Parameters, nil, e.ReturnType)
+		p.print(" => ")
+		p.expr(e.Expression, precLowest, next)
+	case *ast.MatchExpression:
+		p.print("match (")
+		p.expr(e.Subject, precLowest, precLowest)
+		p.print(") {")
+		p.indent++
+		for _, arm := range e.Arms {
+			p.newline()
+			p.matchArm(arm)
+			p.print(",")
+		}
+		p.indent--
+		p.newline()
+		p.print("}")
+	case *ast.ConditionalExpression:
+		p.errorf("unexpected conditional outside of an if statement")
+	default:
+		p.errorf("unexpected expression %T", exp)
+	}
+}
+
+func (p *printer) exprList(list []ast.Expression) {
+	for i, exp := range list {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.expr(exp, precLowest, precLowest)
+	}
+}
+
+// dynamicVariable prints `$$a` or `${expr}`
+func (p *printer) dynamicVariable(v *ast.DynamicVariable) {
+	switch v.Name.(type) {
+	case *ast.Variable, *ast.DynamicVariable:
+		p.print("$")
+		p.expr(v.Name, precPrimary, precPrimary)
+	default:
+		p.print("${")
+		p.expr(v.Name, precLowest, precLowest)
+		p.print("}")
+	}
+}
+
+// callee prints the function called by a CallExpression. Names,
+// variables and calls are printed as they are, anything else goes in
+// parentheses: `$a->b()` would be a method call and `A::B()` a static
+// one.
+func (p *printer) callee(fn ast.Expression) {
+	switch fn.(type) {
+	case *ast.Constant, *ast.Variable, *ast.DynamicVariable, *ast.IndexExpression,
+		*ast.CallExpression, *ast.MethodCallExpression, *ast.StaticCallExpression:
+		p.expr(fn, precCall, precCall)
+	default:
+		p.print("(")
+		p.bare(fn, precLowest)
+		p.print(")")
+	}
+}
+
+// class prints the class operand of `::`, keeping the parentheses of
+// `(A::B)::c()`.
+func (p *printer) class(class ast.Expression) {
+	if _, ok := class.(*ast.ClassConstFetchExpression); ok {
+		p.print("(")
+		p.bare(class, precLowest)
+		p.print(")")
+		return
+	}
+	p.expr(class, precCall, precCall)
+}
+
+// memberName prints the name following `->` or `::`, a variable name
+// or an expression in braces.
+func (p *printer) memberName(name ast.Expression) {
+	switch name.(type) {
+	case *ast.Identifier, *ast.Variable:
+		p.expr(name, precPrimary, precPrimary)
+	default:
+		p.print("{")
+		p.expr(name, precLowest, precLowest)
+		p.print("}")
+	}
+}
+
+func objectOperator(nullsafe bool) string {
+	if nullsafe {
+		return "?->"
+	}
+	return "->"
+}
+
+func (p *printer) arguments(args []*ast.Argument, firstClassCallable bool) {
+	if firstClassCallable {
+		p.print("(...)")
+		return
+	}
+	p.print("(")
+	for i, arg := range args {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.argument(arg)
+	}
+	p.print(")")
+}
+
+func (p *printer) argument(arg *ast.Argument) {
+	if arg.Name != "" {
+		p.print(arg.Name, ": ")
+	}
+	if arg.Unpack {
+		p.print("...")
+	}
+	p.expr(arg.Value, precLowest, precLowest)
+}
+
+func (p *printer) arrayItems(items []*ast.ArrayItem, isShort bool, open string) {
+	if isShort {
+		open = "["
+	}
+	p.print(open)
+	for i, item := range items {
+		if i > 0 {
+			p.print(", ")
+		}
+		if item != nil {
+			p.arrayItem(item)
+		}
+	}
+	if isShort {
+		p.print("]")
+	} else {
+		p.print(")")
+	}
+}
+
+func (p *printer) arrayItem(item *ast.ArrayItem) {
+	if item.Key != nil {
+		p.expr(item.Key, precLowest, precLowest)
+		p.print(" => ")
+	}
+	if item.ByRef {
+		p.print("&")
+	}
+	if item.Unpack {
+		p.print("...")
+	}
+	p.expr(item.Value, precLowest, precLowest)
+}
+
+func (p *printer) matchArm(arm *ast.MatchArm) {
+	if arm.Conditions == nil {
+		p.print("default")
+	} else {
+		p.exprList(arm.Conditions)
+	}
+	p.print(" => ")
+	p.expr(arm.Body, precLowest, precLowest)
+}
+
+// newExpression prints `new Class(args)` and `new class(args) {...}`. The
+// class names and the variables are printed as is, the other expressions
+// are parenthesized.
+func (p *printer) newExpression(e *ast.NewExpression) {
+	p.print("new ")
+	if class := e.AnonymousClass; class != nil {
+		p.attributes(class.Attributes, false)
+		p.print("class")
+		if e.Arguments != nil {
+			p.arguments(e.Arguments, false)
+		}
+		p.classRest(class)
+		return
+	}
+	if isClassReference(e.Class) {
+		p.bare(e.Class, precCall)
+	} else {
+		p.print("(")
+		p.expr(e.Class, precLowest, precLowest)
+		p.print(")")
+	}
+	if e.Arguments != nil {
+		p.arguments(e.Arguments, false)
+	}
+}
+
+// isClassReference reports whether exp can follow `new` without
+// parentheses: a name or a variable with dimension and property fetches.
+func isClassReference(exp ast.Expression) bool {
+	switch e := exp.(type) {
+	case *ast.Constant, *ast.Variable, *ast.DynamicVariable:
+		return true
+	case *ast.IndexExpression:
+		return e.Index != nil && isClassReference(e.Left)
+	case *ast.PropertyFetchExpression:
+		return isClassReference(e.Object)
+	case *ast.StaticPropertyFetchExpression:
+		return isClassReference(e.Class)
+	}
+	return false
+}
+
+// parts prints the parts of an interpolated string delimited by quote,
+// the embedded expressions are printed in braces.
+func (p *printer) parts(parts []ast.Expression, quote byte) {
+	for _, part := range parts {
+		if lit, ok := part.(*ast.StringLiteral); ok {
+			p.print(escape(lit.Value, quote))
+			continue
+		}
+		p.print("{")
+		p.expr(part, precLowest, precLowest)
+		p.print("}")
+	}
+}
+
+// quote returns s as a single quoted string literal
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// escape escapes the characters of s having a meaning in a string
+// delimited by quote: the backslash, the delimiter and `$`.
+func escape(s string, quote byte) string {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '$', quote:
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// formatFloat returns the shortest literal of v read back as the same
+// float, with a dot or an exponent so that it isn't read as an integer.
+func formatFloat(v float64) string {
+	if math.IsInf(v, 0) {
+		// the integer literals too large for a float
+		s := "1" + strings.Repeat("0", 309)
+		if v < 0 {
+			s = "-" + s
+		}
+		return s
+	}
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eN") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,687 @@
+// Package printer prints AST nodes as PHP source. The output is valid PHP
+// which parses back into the same tree, formatting and comments of the
+// original source are not kept.
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/eaglewu/luban/compiler/ast"
+)
+
+// Fprint writes the PHP source of node to w. A *ast.Program is printed
+// with its opening tag, the other nodes are printed as they would appear
+// inside a program. Nodes that can't be printed, like the BadStatement of
+// a program with errors, are reported in the returned error.
+func Fprint(w io.Writer, node ast.Node) error {
+	p := &printer{}
+	p.node(node)
+	if _, err := w.Write(p.out); err != nil {
+		return err
+	}
+	return p.err
+}
+
+// Sprint returns the PHP source of node, see Fprint
+func Sprint(node ast.Node) (string, error) {
+	var out strings.Builder
+	err := Fprint(&out, node)
+	return out.String(), err
+}
+
+type printer struct {
+	out    []byte
+	indent int
+	err    error
+}
+
+func (p *printer) print(s ...string) {
+	for _, s := range s {
+		p.out = append(p.out, s...)
+	}
+}
+
+// newline starts a new line at the current indentation
+func (p *printer) newline() {
+	p.out = append(p.out, '\n')
+	for i := 0; i < p.indent; i++ {
+		p.out = append(p.out, '\t')
+	}
+}
+
+func (p *printer) errorf(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("printer: "+format, args...)
+	}
+}
+
+func (p *printer) node(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.Program:
+		p.program(n)
+	case *ast.ConditionalExpression:
+		p.ifStatement(&ast.IfStatement{Conditionals: []*ast.ConditionalExpression{n}})
+	case *ast.Argument:
+		p.argument(n)
+	case *ast.ArrayItem:
+		p.arrayItem(n)
+	case *ast.MatchArm:
+		p.matchArm(n)
+	case ast.Expression:
+		p.expr(n, precLowest, precLowest)
+	case *ast.CaseStatement:
+		p.caseStatement(n)
+	case *ast.CatchStatement:
+		p.catch(n)
+	case ast.Statement:
+		p.stmt(n)
+	case *ast.Parameter:
+		p.parameter(n)
+	case *ast.TypeHint:
+		p.typeHint(n)
+	case *ast.AttributeGroup:
+		p.attributeGroup(n)
+	case *ast.Attribute:
+		p.attribute(n)
+	case *ast.ClosureUse:
+		p.closureUse(n)
+	case *ast.StaticVar:
+		p.staticVar(n)
+	case *ast.PropertyItem:
+		p.propertyItem(n)
+	case *ast.ConstantItem:
+		p.constantItem(n)
+	case *ast.UseItem:
+		p.useItem(n)
+	default:
+		p.errorf("unexpected node %T", node)
+	}
+}
+
+// program prints the statements of a PHP file, the inline HTML is printed
+// out of the PHP tags.
+func (p *printer) program(program *ast.Program) {
+	inPHP := false
+	for _, s := range program.Statements {
+		if html, ok := s.(*ast.InlineHtmlStatement); ok {
+			if inPHP {
+				p.newline()
+				p.closeTag(html.Value)
+			}
+			p.print(html.Value)
+			inPHP = false
+			continue
+		}
+		if inPHP {
+			p.newline()
+		} else {
+			p.print("<?php")
+			p.newline()
+			inPHP = true
+		}
+		p.stmt(s)
+	}
+	if inPHP {
+		p.newline()
+	}
+}
+
+// closeTag prints the `?>` preceding the inline HTML value, the newline
+// right after it is part of the tag.
+func (p *printer) closeTag(value string) {
+	p.print("?>")
+	if strings.HasPrefix(value, "\n") || strings.HasPrefix(value, "\r\n") {
+		p.print("\n")
+	}
+}
+
+func (p *printer) stmt(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.ExpressionStatement:
+		p.expressionStatement(s)
+	case *ast.BlockStatement:
+		p.block(s)
+	case *ast.IfStatement:
+		p.ifStatement(s)
+	case *ast.WhileStatement:
+		p.print("while (")
+		p.expr(s.Condition, precLowest, precLowest)
+		p.print(") ")
+		p.block(s.Body)
+	case *ast.DoStatement:
+		p.print("do ")
+		p.block(s.Body)
+		p.print(" while (")
+		p.expr(s.Condition, precLowest, precLowest)
+		p.print(");")
+	case *ast.ForStatement:
+		p.forStatement(s)
+	case *ast.ForeachStatement:
+		p.foreachStatement(s)
+	case *ast.SwitchStatement:
+		p.switchStatement(s)
+	case *ast.CaseStatement:
+		p.caseStatement(s)
+	case *ast.BreakStatement:
+		p.jump("break", s.Level)
+	case *ast.ContinueStatement:
+		p.jump("continue", s.Level)
+	case *ast.ReturnStatement:
+		p.jump("return", s.ReturnValue)
+	case *ast.LabelStatement:
+		p.print(s.Name, ":")
+	case *ast.GotoStatement:
+		p.print("goto ", s.Label, ";")
+	case *ast.TryStatement:
+		p.tryStatement(s)
+	case *ast.CatchStatement:
+		p.catch(s)
+	case *ast.ThrowStatement:
+		p.print("throw ")
+		p.expr(s.Expression, precLowest, precLowest)
+		p.print(";")
+	case *ast.InlineHtmlStatement:
+		p.closeTag(s.Value)
+		p.print(s.Value, "<?php")
+	case *ast.EchoStatement:
+		p.print("echo ")
+		p.exprList(s.Expressions)
+		p.print(";")
+	case *ast.GlobalStatement:
+		p.print("global ")
+		p.exprList(s.Names)
+		p.print(";")
+	case *ast.StaticStatement:
+		p.print("static ")
+		for i, v := range s.Vars {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.staticVar(v)
+		}
+		p.print(";")
+	case *ast.UnsetStatement:
+		p.print("unset(")
+		p.exprList(s.Variables)
+		p.print(");")
+	case *ast.FunctionStatement:
+		p.attributes(s.Attributes, true)
+		p.print("function ")
+		if s.ByRef {
+			p.print("&")
+		}
+		p.print(s.Name)
+		p.signature(s.Parameters, nil, s.ReturnType)
+		p.print(" ")
+		p.block(s.Body)
+	case *ast.ClassStatement:
+		p.attributes(s.Attributes, true)
+		if s.Modifiers != 0 {
+			p.print(s.Modifiers.String(), " ")
+		}
+		p.print("class ", s.Name)
+		p.classRest(s)
+	case *ast.InterfaceStatement:
+		p.attributes(s.Attributes, true)
+		p.print("interface ", s.Name)
+		if len(s.Extends) > 0 {
+			p.print(" extends ", strings.Join(s.Extends, ", "))
+		}
+		p.print(" ")
+		p.block(s.Body)
+	case *ast.TraitStatement:
+		p.attributes(s.Attributes, true)
+		p.print("trait ", s.Name, " ")
+		p.block(s.Body)
+	case *ast.EnumStatement:
+		p.attributes(s.Attributes, true)
+		p.print("enum ", s.Name)
+		if s.BackingType != nil {
+			p.print(": ")
+			p.typeHint(s.BackingType)
+		}
+		if len(s.Interfaces) > 0 {
+			p.print(" implements ", strings.Join(s.Interfaces, ", "))
+		}
+		p.print(" ")
+		p.block(s.Body)
+	case *ast.PropertyStatement:
+		p.attributes(s.Attributes, true)
+		if s.Modifiers == 0 {
+			p.print("var ")
+		} else {
+			p.print(s.Modifiers.String(), " ")
+		}
+		if s.Type != nil {
+			p.typeHint(s.Type)
+			p.print(" ")
+		}
+		for i, item := range s.Properties {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.propertyItem(item)
+		}
+		p.print(";")
+	case *ast.ClassConstStatement:
+		p.attributes(s.Attributes, true)
+		if s.Modifiers != 0 {
+			p.print(s.Modifiers.String(), " ")
+		}
+		p.print("const ")
+		p.constantItems(s.Constants)
+		p.print(";")
+	case *ast.EnumCaseStatement:
+		p.attributes(s.Attributes, true)
+		p.print("case ", s.Name)
+		if s.Value != nil {
+			p.print(" = ")
+			p.expr(s.Value, precLowest, precLowest)
+		}
+		p.print(";")
+	case *ast.MethodStatement:
+		p.attributes(s.Attributes, true)
+		if s.Modifiers != 0 {
+			p.print(s.Modifiers.String(), " ")
+		}
+		p.print("function ")
+		if s.ByRef {
+			p.print("&")
+		}
+		p.print(s.Name)
+		p.signature(s.Parameters, nil, s.ReturnType)
+		if s.Body == nil {
+			p.print(";")
+			break
+		}
+		p.print(" ")
+		p.block(s.Body)
+	case *ast.TraitUseStatement:
+		p.print("use ", strings.Join(s.Traits, ", "))
+		if len(s.Adaptations) == 0 {
+			p.print(";")
+			break
+		}
+		p.print(" ")
+		p.statements(s.Adaptations)
+	case *ast.TraitPrecedenceStatement:
+		p.print(s.Trait, "::", s.Method, " insteadof ", strings.Join(s.InsteadOf, ", "), ";")
+	case *ast.TraitAliasStatement:
+		if s.Trait != "" {
+			p.print(s.Trait, "::")
+		}
+		p.print(s.Method, " as")
+		if s.Modifiers != 0 {
+			p.print(" ", s.Modifiers.String())
+		}
+		if s.Alias != "" {
+			p.print(" ", s.Alias)
+		}
+		p.print(";")
+	case *ast.NamespaceStatement:
+		p.print("namespace")
+		if s.Name != "" {
+			p.print(" ", s.Name)
+		}
+		if s.Body == nil {
+			p.print(";")
+			break
+		}
+		p.print(" ")
+		p.block(s.Body)
+	case *ast.UseStatement:
+		p.useStatement(s)
+	case *ast.DeclareStatement:
+		p.print("declare(")
+		for i, d := range s.Directives {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(d.Name, "=")
+			p.expr(d.Value, precLowest, precLowest)
+		}
+		p.print(")")
+		if s.Body == nil {
+			p.print(";")
+			break
+		}
+		p.print(" ")
+		p.block(s.Body)
+	case *ast.ConstStatement:
+		p.print("const ")
+		p.constantItems(s.Constants)
+		p.print(";")
+	case *ast.BadStatement:
+		p.errorf("cannot print the bad statement at line %d", s.Line())
+		p.print("/* bad statement */")
+	case nil:
+		p.errorf("missing statement")
+	default:
+		p.errorf("unexpected statement %T", stmt)
+	}
+}
+
+// expressionStatement prints `expr;`, the expressions read as another
+// statement at the start of a statement are parenthesized.
+func (p *printer) expressionStatement(s *ast.ExpressionStatement) {
+	start := len(p.out)
+	p.expr(s.Expression, precLowest, precLowest)
+	if text := string(p.out[start:]); strings.HasPrefix(text, "function &") || strings.HasPrefix(text, "throw ") {
+		p.out = append(p.out[:start], "("+text+")"...)
+	}
+	p.print(";")
+}
+
+// block prints `{ statements }` with the statements indented, the
+// alternative syntax is printed with braces too.
+func (p *printer) block(block *ast.BlockStatement) {
+	if block == nil {
+		p.errorf("missing block")
+		p.print("{}")
+		return
+	}
+	p.statements(block.Statements)
+}
+
+func (p *printer) statements(list []ast.Statement) {
+	if len(list) == 0 {
+		p.print("{}")
+		return
+	}
+	p.print("{")
+	p.indent++
+	for _, s := range list {
+		p.newline()
+		p.stmt(s)
+	}
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+func (p *printer) ifStatement(s *ast.IfStatement) {
+	for i, c := range s.Conditionals {
+		if i == 0 {
+			p.print("if (")
+		} else {
+			p.print(" elseif (")
+		}
+		p.expr(c.Condition, precLowest, precLowest)
+		p.print(") ")
+		p.block(c.Consequence)
+	}
+	if s.Alternative != nil {
+		p.print(" else ")
+		p.block(s.Alternative)
+	}
+}
+
+func (p *printer) forStatement(s *ast.ForStatement) {
+	p.print("for (")
+	p.exprList(s.Init)
+	for _, list := range [][]ast.Expression{s.Condition, s.Loop} {
+		p.print(";")
+		if len(list) > 0 {
+			p.print(" ")
+			p.exprList(list)
+		}
+	}
+	p.print(") ")
+	p.block(s.Body)
+}
+
+func (p *printer) foreachStatement(s *ast.ForeachStatement) {
+	p.print("foreach (")
+	p.expr(s.Expression, precLowest, precLowest)
+	p.print(" as ")
+	if s.Key != nil {
+		p.expr(s.Key, precLowest, precLowest)
+		p.print(" => ")
+	}
+	if s.ByRef {
+		p.print("&")
+	}
+	p.expr(s.Value, precLowest, precLowest)
+	p.print(") ")
+	p.block(s.Body)
+}
+
+func (p *printer) switchStatement(s *ast.SwitchStatement) {
+	p.print("switch (")
+	p.expr(s.Condition, precLowest, precLowest)
+	p.print(") {")
+	p.indent++
+	for _, c := range s.Cases {
+		p.newline()
+		p.caseStatement(c)
+	}
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+// caseStatement prints `case expr:` or `default:` and the statements
+// following it.
+func (p *printer) caseStatement(c *ast.CaseStatement) {
+	if c.Value == nil {
+		p.print("default:")
+	} else {
+		p.print("case ")
+		p.expr(c.Value, precLowest, precLowest)
+		p.print(":")
+	}
+	if c.Body == nil {
+		return
+	}
+	p.indent++
+	for _, s := range c.Body.Statements {
+		p.newline()
+		p.stmt(s)
+	}
+	p.indent--
+}
+
+// jump prints break, continue and return with their optional operand
+func (p *printer) jump(keyword string, exp ast.Expression) {
+	p.print(keyword)
+	if exp != nil {
+		p.print(" ")
+		p.expr(exp, precLowest, precLowest)
+	}
+	p.print(";")
+}
+
+func (p *printer) tryStatement(s *ast.TryStatement) {
+	p.print("try ")
+	p.block(s.Body)
+	for _, c := range s.Catches {
+		p.print(" ")
+		p.catch(c)
+	}
+	if s.Finally != nil {
+		p.print(" finally ")
+		p.block(s.Finally)
+	}
+}
+
+func (p *printer) catch(c *ast.CatchStatement) {
+	p.print("catch (", strings.Join(c.Types, " | "))
+	if c.Variable != "" {
+		p.print(" $", c.Variable)
+	}
+	p.print(") ")
+	p.block(c.Body)
+}
+
+func (p *printer) useStatement(s *ast.UseStatement) {
+	p.print("use ")
+	if s.Type != ast.UseNormal {
+		p.print(s.Type.String(), " ")
+	}
+	if s.Prefix != "" {
+		p.print(s.Prefix, "\\{")
+	}
+	for i, use := range s.Uses {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.useItem(use)
+	}
+	if s.Prefix != "" {
+		p.print("}")
+	}
+	p.print(";")
+}
+
+func (p *printer) useItem(use *ast.UseItem) {
+	if use.Type != ast.UseNormal {
+		p.print(use.Type.String(), " ")
+	}
+	p.print(use.Name)
+	if use.Alias != "" {
+		p.print(" as ", use.Alias)
+	}
+}
+
+// classRest prints a class declaration from its extends clause on, it's
+// shared by the named and the anonymous classes.
+func (p *printer) classRest(s *ast.ClassStatement) {
+	switch {
+	case s.SuperClassName != "":
+		p.print(" extends ", s.SuperClassName)
+	case s.SuperClass != nil:
+		p.print(" extends ")
+		p.expr(s.SuperClass, precLowest, precLowest)
+	}
+	if len(s.Interfaces) > 0 {
+		p.print(" implements ", strings.Join(s.Interfaces, ", "))
+	}
+	p.print(" ")
+	p.block(s.Body)
+}
+
+// attributes prints the attribute groups preceding a declaration, each on
+// its own line when lines is set.
+func (p *printer) attributes(groups []*ast.AttributeGroup, lines bool) {
+	for _, g := range groups {
+		p.attributeGroup(g)
+		if lines {
+			p.newline()
+		} else {
+			p.print(" ")
+		}
+	}
+}
+
+func (p *printer) attributeGroup(g *ast.AttributeGroup) {
+	p.print("#[")
+	for i, attr := range g.Attributes {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.attribute(attr)
+	}
+	p.print("]")
+}
+
+func (p *printer) attribute(attr *ast.Attribute) {
+	p.print(attr.Name)
+	if attr.Arguments != nil {
+		p.arguments(attr.Arguments, false)
+	}
+}
+
+// signature prints the parameters, closure uses and return type of a
+// function.
+func (p *printer) signature(params []*ast.Parameter, uses []*ast.ClosureUse, ret *ast.TypeHint) {
+	p.print("(")
+	for i, param := range params {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.parameter(param)
+	}
+	p.print(")")
+	if len(uses) > 0 {
+		p.print(" use (")
+		for i, use := range uses {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.closureUse(use)
+		}
+		p.print(")")
+	}
+	if ret != nil {
+		p.print(": ")
+		p.typeHint(ret)
+	}
+}
+
+func (p *printer) parameter(param *ast.Parameter) {
+	p.attributes(param.Attributes, false)
+	if param.Modifiers != 0 {
+		p.print(param.Modifiers.String(), " ")
+	}
+	if param.Type != nil {
+		p.typeHint(param.Type)
+		p.print(" ")
+	}
+	if param.ByRef {
+		p.print("&")
+	}
+	if param.Variadic {
+		p.print("...")
+	}
+	p.print("$", param.Name)
+	if param.Default != nil {
+		p.print(" = ")
+		p.expr(param.Default, precLowest, precLowest)
+	}
+}
+
+func (p *printer) typeHint(typ *ast.TypeHint) {
+	if typ.Nullable {
+		p.print("?")
+	}
+	p.print(strings.Join(typ.Types, "|"))
+}
+
+func (p *printer) closureUse(use *ast.ClosureUse) {
+	if use.ByRef {
+		p.print("&")
+	}
+	p.print("$", use.Name)
+}
+
+func (p *printer) staticVar(v *ast.StaticVar) {
+	p.print("$", v.Name)
+	if v.Default != nil {
+		p.print(" = ")
+		p.expr(v.Default, precLowest, precLowest)
+	}
+}
+
+func (p *printer) propertyItem(item *ast.PropertyItem) {
+	p.print("$", item.Name)
+	if item.Default != nil {
+		p.print(" = ")
+		p.expr(item.Default, precLowest, precLowest)
+	}
+}
+
+func (p *printer) constantItems(items []*ast.ConstantItem) {
+	for i, item := range items {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.constantItem(item)
+	}
+}
+
+func (p *printer) constantItem(item *ast.ConstantItem) {
+	p.print(item.Name, " = ")
+	p.expr(item.Value, precLowest, precLowest)
+}
@@ -0,0 +1,380 @@
+package printer_test
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/eaglewu/luban/compiler/ast"
+	"github.com/eaglewu/luban/compiler/parser"
+	"github.com/eaglewu/luban/compiler/printer"
+)
+
+// roundTrip uses every kind of node
+const roundTrip = `<?php
+declare(strict_types=1);
+namespace App;
+use Foo\Bar as Baz, Foo\Qux;
+use function Foo\strlen;
+use const Foo\{A, B as C};
+use Foo\{D, function e, const F};
+const X = 1, Y = 2 * X;
+#[Attr(1, name: 2), Other]
+#[Third]
+function &f(int|string $a = 1, ?Foo &...$rest): ?int {
+	static $s = 1, $t;
+	global $g, $$h;
+	if ($a) { echo 1; } elseif ($b) { echo 2; } else if ($c) { echo 3; } else { echo 4, 5; }
+	if ($a): echo 1; elseif ($b): echo 2; else: echo 3; endif;
+	while ($a) { break; }
+	while ($a): while ($b): break 2; endwhile; endwhile;
+	do { continue 1; } while ($a);
+	for ($i = 0, $j = 1; $i < 10; $i++, $j--) {}
+	for (;;) { break; }
+	foreach ($arr as $k => &$v) {}
+	foreach ($arr as [$a, [, $b]]) {}
+	foreach ($arr as list('x' => $x)) {}
+	switch ($a) { case 1: case 2: echo 1; break; default: }
+	switch ($a): default: echo 1; endswitch;
+	try { throw new Exception('x'); } catch (A | B $e) {} catch (C) {} finally {}
+	label:
+	goto label;
+	unset($a[0], $b->c);
+	{ $nested; }
+	return $a;
+}
+abstract class A extends B implements C, D {
+	use T1, T2 { T1::foo insteadof T2; T2::foo as protected bar; baz as qux; }
+	use T3;
+	final public const Z = 1;
+	public static ?int $p = 1, $q;
+	var $v;
+	protected readonly int|string $r;
+	abstract public function m(): static;
+	public function __construct(#[Sensitive] private readonly int $x = 0) {}
+	final public static function &s() { return self::$p; }
+	public function gen() { yield; $a = yield $b; yield $k => $v + 1; $c = (yield) ?? 1; yield from self::s(); $d = (yield $e) . 'x'; }
+}
+final class Fin {}
+interface I extends J, K { public function i(); const I = 1; }
+trait T { public function t() {} }
+enum E: string implements I { case A = 'a'; case B = 'b'; public function f() { return self::A; } }
+enum U { case X; }
+namespace Other;
+?>
+<html>
+  <p><?= $title ?></p>
+<?php if ($a): ?>
+<b>yes</b>
+<?php endif; ?>
+<?php
+$x = [1, 'k' => &$y, ...$z, [2, 3]];
+$arr = array(1, array(2), 'a' => array());
+[$a, , [$b]] = $c;
+list($a, list(, $b)) = $c;
+['a' => $a, 'b' => $b] = $c;
+$$name = ${'a' . 'b'};
+$$$deep = 1;
+$q = $o?->p->q + A::$sp + A::$$dyn;
+A::C; A::m(...); $o->m(1); strlen(...); $o->{'a' . 'b'}(); $o->$m(); A::{'m'}(); $o->p->q();
+static::f(); self::$x; parent::g(); namespace\h(); \i\j();
+$fn = static function ($a) use ($b, &$c): int { return 1; };
+$fb = function &() { return $x; };
+$af = fn($x) => $x * 2;
+$ar = static fn&(array $a): array => $a;
+$at = #[Pure] fn() => 1;
+$ac = #[Pure] static function () {};
+new class(1) extends B implements C { public $p; };
+new #[Attr] class {};
+new A; new A(); new A(1, 2); new static; new $cls; new $o->cls; new $o::$cls; new $arr['c']; new ('A' . 'B');
+(new A)->m();
+(function () {})();
+(fn() => 1)();
+$cl = clone $o;
+$r = -$a + !$b - ~$c + +$d;
+$a++; $a--; ++$a; --$a;
+$t = $a ? $b : ($c ?: $d);
+$i = (int) $f . (string) $g . (bool) $h . (float) $k . (array) $l . (object) $m;
+$a =& $b; $a .= 'x'; $a ??= 1; $a **= 2; $a <<= 1; $a >>= 1; $a |= 1; $a &= 1; $a ^= 1; $a %= 2;
+$s = "a $b {$c->d} ${e} $f->g $h[0] $i[k] $j[$l] {$m[1][2]} \$n \" \\ {$o()}" . "x{$p}";
+$hd = <<<EOT
+  a $b
+  "c" \$d {$e}
+EOT;
+$nd = <<<'EOT'
+raw $a \n
+EOT;
+$id = f(<<<EOT
+    a $b
+      c
+    EOT, 1);
+$sh = ` + "`ls $dir \\` \"a\"`" + `;
+$lit = 'it\'s' . "tab\there" . 'back\\slash' . "dollar\$" . '';
+include 'a.php'; include_once 'b.php'; require 'c.php'; require_once 'd.php'; eval('$x;');
+print 1;
+isset($a, $b['c']); empty($a); exit; exit(1); die('x');
+$m = match ($a) { 1, 2 => 'a', default => throw new E() };
+$m = match (true) { $a > 1, => 1, };
+$f = 1.5; $b = true; $n = null; $n = NULL; $big = 9223372036854775808; $e = 1e100; $h = 0x1F; $o = 017; $bin = 0b11;
+$f = 1.0; $f = .5; $f = 7E-10;
+FOO; \BAR; namespace\BAZ;
+$a = $b and $c or $d xor $e;
+$a = $b AND $c;
+$x = $a instanceof B && !$c instanceof D;
+$x = $a instanceof static || $a instanceof self || $a instanceof parent || $a instanceof $b;
+$x = $a <=> $b === $c <> $d;
+$x = ($a = 1) + ($b = 2);
+$x = !$a = f();
+$x = $a ?? $b ?? $c;
+$x = ($a ?? $b) ?? $c;
+$x = 2 ** 3 ** 4;
+$x = (2 ** 3) ** 4;
+$x = -2 ** 2;
+$x = (-2) ** 2;
+$x = $a - ($b - $c);
+$x = $a - $b - $c;
+$x = $a . $b + $c << $d;
+$x = ($a . $b) + ($c << $d);
+$x = $a ? $b : ($c ? $d : $e);
+$x = ($a ? $b : $c) ? $d : $e;
+$x = $a ? ($b ? $c : $d) : $e;
+$x = - -$a + + +$b - - --$c + + ++$d;
+$x = -(-1);
+$x = !($a && $b) || @f();
+$x = $a * !$b + $c;
+$x = $a * !($b + $c);
+$x = $a . (print $b) . $c;
+$x = print $a and $b;
+$x = (print $a) and $b;
+$x = $a && print $b;
+$x = (clone $a)->b;
+$x = clone $a->b;
+$x = (include 'a.php') . 'b';
+$x = $a ?? throw new E;
+$x = fn() => $a ?? $b;
+$x = (fn() => $a) ?? $b;
+$x = $a->b[0]->c()::D;
+$x = [$a, 'm']();
+$x = ($o->p)(); $x = ($o?->p)(1); $x = (A::$p)(); $x = (A::$$p)();
+$x = 'strlen'('a');
+$x = (A::B)(); $x = (A::B)::c(); $x = (A::B)::$c; $x = (A::B)::C;
+$x = "a"[0];
+$x = ($a ?: $b) ?: $c;
+$x = $a == ($b == $c);
+$x = ($a == $b) == $c;
+$x = ($a < $b) > $c;
+$a[] = 1;
+$a->b[] = 2;
+`
+
+func Test_RoundTrip(t *testing.T) {
+	inputs := []string{
+		roundTrip,
+		"<html><?php echo 1;",
+		"<?php echo 1; ?>\n<p>\n",
+		"<?php echo 1; ?>\n\n<p>",
+		"<?php\nnamespace A {\n\tfunction f() {}\n}\nnamespace {\n\tf();\n}\n",
+		"<?php declare(ticks=1) { $a; } declare(ticks=1): $b; enddeclare;",
+		"<?php function &f() {} (function &() {})(); function () {}; (throw $e);",
+	}
+	for i, input := range inputs {
+		program, err := parser.ParseFile(nil, "a.php", input, 0)
+		if err != nil {
+			t.Fatalf("inputs[%d] - parser error: %s", i, err)
+		}
+		printed, err := printer.Sprint(program)
+		if err != nil {
+			t.Fatalf("inputs[%d] - printer error: %s", i, err)
+		}
+		reparsed, err := parser.ParseFile(nil, "a.php", printed, 0)
+		if err != nil {
+			t.Fatalf("inputs[%d] - the printed source doesn't parse: %s\n%s", i, err, printed)
+		}
+		if path := diff(reflect.ValueOf(program), reflect.ValueOf(reparsed), "Program"); path != "" {
+			t.Errorf("inputs[%d] - the printed source parses to a different tree at %s:\n%s", i, path, printed)
+		}
+		if again, _ := printer.Sprint(reparsed); again != printed {
+			t.Errorf("inputs[%d] - expected the same source printed twice, got:\n%s\nthen:\n%s", i, printed, again)
+		}
+	}
+}
+
+// diff returns the path of the first difference between two trees, the
+// *ast.BaseNode holding the tokens and positions are ignored and the nil
+// slices are equal to the empty ones.
+func diff(a, b reflect.Value, path string) string {
+	if a.Kind() != b.Kind() {
+		return path
+	}
+	switch a.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return path
+			}
+			return ""
+		}
+		if a.Elem().Type() != b.Elem().Type() {
+			return path + " (" + a.Elem().Type().String() + " vs " + b.Elem().Type().String() + ")"
+		}
+		return diff(a.Elem(), b.Elem(), path)
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if a.Field(i).Type() == reflect.TypeOf(&ast.BaseNode{}) {
+				continue
+			}
+			if p := diff(a.Field(i), b.Field(i), path+"."+a.Type().Field(i).Name); p != "" {
+				return p
+			}
+		}
+		return ""
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return path + " (length)"
+		}
+		for i := 0; i < a.Len(); i++ {
+			if p := diff(a.Index(i), b.Index(i), path+"["+strconv.Itoa(i)+"]"); p != "" {
+				return p
+			}
+		}
+		return ""
+	case reflect.Float64:
+		if a.Float() != b.Float() {
+			return path
+		}
+		return ""
+	}
+	if a.Interface() != b.Interface() {
+		return fmt.Sprintf("%s (%#v vs %#v)", path, a.Interface(), b.Interface())
+	}
+	return ""
+}
+
+func Test_Parentheses(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(1 + 2) * 3`, `(1 + 2) * 3`},
+		{`1 + (2 * 3)`, `1 + 2 * 3`},
+		{`(1 - 2) - 3`, `1 - 2 - 3`},
+		{`1 - (2 - 3)`, `1 - (2 - 3)`},
+		{`(2 ** 3) ** 4`, `(2 ** 3) ** 4`},
+		{`2 ** (3 ** 4)`, `2 ** 3 ** 4`},
+		{`($a ?? $b) ?? $c`, `($a ?? $b) ?? $c`},
+		{`(-$a) ** 2`, `(-$a) ** 2`},
+		{`-($a ** 2)`, `-$a ** 2`},
+		{`(!$a) + 1`, `!$a + 1`},
+		{`!($a + 1)`, `!($a + 1)`},
+		{`$a . (print $b) . $c`, `$a . (print $b) . $c`},
+		{`$a . (print $b . $c)`, `$a . print $b . $c`},
+		{`!($a instanceof B)`, `!$a instanceof B`},
+		{`!$a = 1`, `!($a = 1)`},
+		{`$a = ($b and $c)`, `$a = ($b and $c)`},
+		{`($a = $b) and $c`, `$a = $b and $c`},
+		{`$a ? $b : $c ? $d : $e`, `($a ? $b : $c) ? $d : $e`},
+		{`(new A)->b()`, `(new A)->b()`},
+		{`(new A())::C`, `(new A())::C`},
+		{`(clone $a)->b`, `(clone $a)->b`},
+		{`($a->b)()`, `($a->b)()`},
+		{`($a?->b)()`, `($a?->b)()`},
+		{`(A::$b)()`, `(A::$b)()`},
+		{`(A::B)()`, `(A::B)()`},
+		{`(A::B)::c()`, `(A::B)::c()`},
+		{`A::b()()`, `A::b()()`},
+		{`$a[0]()`, `$a[0]()`},
+		{`'f'()`, `('f')()`},
+		{`(new A)()`, `(new A)()`},
+		{`($a->b)->c()`, `$a->b->c()`},
+		{`(function () {})()`, `(function () {})()`},
+		{`(fn() => 1) + 2`, `(fn() => 1) + 2`},
+		{`-(-$a)`, `- -$a`},
+		{`-(--$a)`, `- --$a`},
+		{`+(+$a)`, `+ +$a`},
+		{`($a++)[0]`, `($a++)[0]`},
+		{`(print $a) . $b`, `(print $a) . $b`},
+		{`(throw $e) . $a`, `(throw $e) . $a`},
+		{`$a . (throw $e)`, `$a . throw $e`},
+		{`new ($a . 'B')`, `new ($a . 'B')`},
+		{`new $a->b['c']`, `new $a->b['c']`},
+		{`$a == $b == $c`, `($a == $b) == $c`},
+		{`(int) ($a + 1)`, `(int) ($a + 1)`},
+		{`((int) $a) + 1`, `(int) $a + 1`},
+	}
+	for i, tt := range tests {
+		exp, err := parser.ParseExpr(tt.input)
+		if err != nil {
+			t.Fatalf("tests[%d] - parser error: %s", i, err)
+		}
+		s, err := printer.Sprint(exp)
+		if err != nil || s != tt.expected {
+			t.Errorf("tests[%d] - expected %q, got %q (%v)", i, tt.expected, s, err)
+			continue
+		}
+		reparsed, err := parser.ParseExpr(s)
+		if err != nil {
+			t.Errorf("tests[%d] - %q doesn't parse: %s", i, s, err)
+			continue
+		}
+		if path := diff(reflect.ValueOf(exp), reflect.ValueOf(reparsed), "Expression"); path != "" {
+			t.Errorf("tests[%d] - %q parses to a different tree at %s", i, s, path)
+		}
+	}
+}
+
+func Test_Print(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"<?php if ($a) echo 1; elseif ($b) {} else { }",
+			"<?php\nif ($a) {\n\techo 1;\n} elseif ($b) {} else {}\n",
+		},
+		{
+			"<?php do echo 1; while ($a < 10);",
+			"<?php\ndo {\n\techo 1;\n} while ($a < 10);\n",
+		},
+		{
+			`<?php echo 'it\'s', "a\n$b", "\$c";`,
+			"<?php\necho 'it\\'s', \"a\n{$b}\", '$c';\n",
+		},
+		{
+			"<?php switch ($a) { case 1: f(); break; default: g(); }",
+			"<?php\nswitch ($a) {\n\tcase 1:\n\t\tf();\n\t\tbreak;\n\tdefault:\n\t\tg();\n}\n",
+		},
+		{
+			"<?php class A { #[B] public function f(int $a): ?int { return $a; } }",
+			"<?php\nclass A {\n\t#[B]\n\tpublic function f(int $a): ?int {\n\t\treturn $a;\n\t}\n}\n",
+		},
+		{
+			"a<?php echo 1; ?>\nb<?php if ($a) { ?>c<?php }",
+			"a<?php\necho 1;\n?>b<?php\nif ($a) {\n\t?>c<?php\n}\n",
+		},
+	}
+	for i, tt := range tests {
+		program, err := parser.ParseFile(nil, "a.php", tt.input, 0)
+		if err != nil {
+			t.Fatalf("tests[%d] - parser error: %s", i, err)
+		}
+		if s, err := printer.Sprint(program); err != nil || s != tt.expected {
+			t.Errorf("tests[%d] - expected:\n%s\ngot:\n%s (%v)", i, tt.expected, s, err)
+		}
+	}
+}
+
+func Test_PrintErrors(t *testing.T) {
+	program, _ := parser.ParseFile(nil, "a.php", "<?php $a = ; $b;", parser.AllErrors)
+	s, err := printer.Sprint(program)
+	if err == nil || !strings.Contains(err.Error(), "bad statement") {
+		t.Errorf("expected a bad statement error, got %v", err)
+	}
+	if !strings.Contains(s, "$b;") {
+		t.Errorf("expected the other statements to be printed, got %q", s)
+	}
+	if _, err := printer.Sprint(&ast.ExpressionStatement{}); err == nil {
+		t.Errorf("expected an error for a missing expression")
+	}
+}
@@ -0,0 +1,137 @@
+package token
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Position describes a location in a source file. Line and Column start at
+// 1, Column counts bytes.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// IsValid reports whether the position is set
+func (pos Position) IsValid() bool {
+	return pos.Line > 0
+}
+
+// String returns "file:line:column", or a part of it when some fields
+// are not set.
+func (pos Position) String() string {
+	s := pos.Filename
+	if pos.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d", pos.Line)
+		if pos.Column != 0 {
+			s += fmt.Sprintf(":%d", pos.Column)
+		}
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// Pos returns the position of the first character of the token
+func (t Token) Pos(filename string) Position {
+	return Position{Filename: filename, Offset: t.Offset, Line: t.Line, Column: t.Column}
+}
+
+// End returns the position right after the last character of the token
+func (t Token) End(filename string) Position {
+	pos := t.Pos(filename)
+	if t.Type == Error { // the literal is a message
+		return pos
+	}
+	pos.Offset += len(t.Literal)
+	for i := 0; i < len(t.Literal); i++ {
+		if t.Literal[i] == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return pos
+}
+
+// SourceFile is a source file added to a FileSet
+type SourceFile struct {
+	name  string
+	src   string
+	lines []int // offsets of the first byte of each line
+}
+
+// Name returns the file name as given to AddFile
+func (f *SourceFile) Name() string {
+	return f.name
+}
+
+// Source returns the content of the file
+func (f *SourceFile) Source() string {
+	return f.src
+}
+
+// LineCount returns the number of lines in the file
+func (f *SourceFile) LineCount() int {
+	return len(f.lines)
+}
+
+// Line returns the text of the 1-based line, without its line terminator
+func (f *SourceFile) Line(line int) string {
+	if line < 1 || line > len(f.lines) {
+		return ""
+	}
+	end := len(f.src)
+	if line < len(f.lines) {
+		end = f.lines[line]
+	}
+	return strings.TrimRight(f.src[f.lines[line-1]:end], "\r\n")
+}
+
+// Position returns the position of the byte offset in the file
+func (f *SourceFile) Position(offset int) Position {
+	line := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > offset })
+	return Position{Filename: f.name, Offset: offset, Line: line, Column: offset - f.lines[line-1] + 1}
+}
+
+// FileSet holds the source of the files parsed together, so that the
+// positions reported on them can be shown in context.
+type FileSet struct {
+	mutex sync.RWMutex
+	files map[string]*SourceFile
+}
+
+// NewFileSet creates an empty file set
+func NewFileSet() *FileSet {
+	return &FileSet{files: map[string]*SourceFile{}}
+}
+
+// AddFile adds a file to the set, replacing the file of the same name
+func (s *FileSet) AddFile(filename, src string) *SourceFile {
+	f := &SourceFile{name: filename, src: src, lines: []int{0}}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			f.lines = append(f.lines, i+1)
+		}
+	}
+	s.mutex.Lock()
+	s.files[filename] = f
+	s.mutex.Unlock()
+	return f
+}
+
+// File returns the file of the given name, or nil
+func (s *FileSet) File(filename string) *SourceFile {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.files[filename]
+}
@@ -30,6 +30,7 @@ const (
 	SlEqual
 	SrEqual
 	PowEqual
+	CoalesceEqual
 	Coalesce
 	BooleanOr
 	BooleanAnd
@@ -62,6 +63,7 @@ const (
 	Static
 	Abstract
 	Final
+	Readonly
 	Private
 	Protected
 	Public
@@ -89,12 +91,14 @@ const (
 	As
 	Switch
 	Endswitch
+	Match
 	Case
 	Default
 	Break
 	Continue
 	Goto
 	Function
+	Fn
 	Const
 	Return
 	Try
@@ -112,9 +116,12 @@ const (
 	Class
 	Trait
 	Interface
+	Enum
 	Extends
 	Implements
 	ObjectOperator
+	NullsafeObjectOperator
+	Attribute
 	List
 	Array
 	Callable
@@ -175,6 +182,8 @@ const (
 
 type Token struct {
 	Line    int
+	Column  int // byte column of the first character, starting at 1
+	Offset  int // byte offset of the first character in the source
 	Type    Type
 	Literal string
 }
@@ -205,6 +214,7 @@ var tokenName = map[Type]string{
 	SlEqual:                "SlEqual",
 	SrEqual:                "SrEqual",
 	PowEqual:               "PowEqual",
+	CoalesceEqual:          "CoalesceEqual",
 	Coalesce:               "Coalesce",
 	BooleanOr:              "BooleanOr",
 	BooleanAnd:             "BooleanAnd",
@@ -237,6 +247,7 @@ var tokenName = map[Type]string{
 	Static:                 "Static",
 	Abstract:               "Abstract",
 	Final:                  "Final",
+	Readonly:               "Readonly",
 	Private:                "Private",
 	Protected:              "Protected",
 	Public:                 "Public",
@@ -264,12 +275,14 @@ var tokenName = map[Type]string{
 	As:                     "As",
 	Switch:                 "Switch",
 	Endswitch:              "Endswitch",
+	Match:                  "Match",
 	Case:                   "Case",
 	Default:                "Default",
 	Break:                  "Break",
 	Continue:               "Continue",
 	Goto:                   "Goto",
 	Function:               "Function",
+	Fn:                     "Fn",
 	Const:                  "Const",
 	Return:                 "Return",
 	Try:                    "Try",
@@ -287,9 +300,12 @@ var tokenName = map[Type]string{
 	Class:                  "Class",
 	Trait:                  "Trait",
 	Interface:              "Interface",
+	Enum:                   "Enum",
 	Extends:                "Extends",
 	Implements:             "Implements",
 	ObjectOperator:         "ObjectOperator",
+	NullsafeObjectOperator: "NullsafeObjectOperator",
+	Attribute:              "Attribute",
 	List:                   "List",
 	Array:                  "Array",
 	Callable:               "Callable",
@@ -355,6 +371,236 @@ func (t Type) String() string {
 	return "Unknown"
 }
 
+// phpTexts holds the text of the tokens having a single form, as PHP
+// writes them in syntax errors.
+var phpTexts = map[Type]string{
+	Include:                "include",
+	IncludeOnce:            "include_once",
+	Eval:                   "eval",
+	Require:                "require",
+	RequireOnce:            "require_once",
+	LogicalOr:              "or",
+	LogicalXor:             "xor",
+	LogicalAnd:             "and",
+	Print:                  "print",
+	Yield:                  "yield",
+	DoubleArrow:            "=>",
+	YieldFrom:              "yield from",
+	PlusEqual:              "+=",
+	MinusEqual:             "-=",
+	MulEqual:               "*=",
+	DivEqual:               "/=",
+	ConcatEqual:            ".=",
+	ModEqual:               "%=",
+	AndEqual:               "&=",
+	OrEqual:                "|=",
+	XorEqual:               "^=",
+	SlEqual:                "<<=",
+	SrEqual:                ">>=",
+	PowEqual:               "**=",
+	CoalesceEqual:          "??=",
+	Coalesce:               "??",
+	BooleanOr:              "||",
+	BooleanAnd:             "&&",
+	IsEqual:                "==",
+	IsNotEqual:             "!=",
+	IsIdentical:            "===",
+	IsNotIdentical:         "!==",
+	Spaceship:              "<=>",
+	IsSmallerOrEqual:       "<=",
+	IsGreaterOrEqual:       ">=",
+	Sl:                     "<<",
+	Sr:                     ">>",
+	Instanceof:             "instanceof",
+	Inc:                    "++",
+	Dec:                    "--",
+	IntCast:                "(int)",
+	DoubleCast:             "(double)",
+	StringCast:             "(string)",
+	ArrayCast:              "(array)",
+	ObjectCast:             "(object)",
+	BoolCast:               "(bool)",
+	UnsetCast:              "(unset)",
+	Pow:                    "**",
+	New:                    "new",
+	Clone:                  "clone",
+	Elseif:                 "elseif",
+	Else:                   "else",
+	Endif:                  "endif",
+	Static:                 "static",
+	Abstract:               "abstract",
+	Final:                  "final",
+	Readonly:               "readonly",
+	Private:                "private",
+	Protected:              "protected",
+	Public:                 "public",
+	Exit:                   "exit",
+	If:                     "if",
+	Echo:                   "echo",
+	Do:                     "do",
+	While:                  "while",
+	Endwhile:               "endwhile",
+	For:                    "for",
+	Endfor:                 "endfor",
+	Foreach:                "foreach",
+	Endforeach:             "endforeach",
+	Declare:                "declare",
+	Enddeclare:             "enddeclare",
+	As:                     "as",
+	Switch:                 "switch",
+	Endswitch:              "endswitch",
+	Match:                  "match",
+	Case:                   "case",
+	Default:                "default",
+	Break:                  "break",
+	Continue:               "continue",
+	Goto:                   "goto",
+	Function:               "function",
+	Fn:                     "fn",
+	Const:                  "const",
+	Return:                 "return",
+	Try:                    "try",
+	Catch:                  "catch",
+	Finally:                "finally",
+	Throw:                  "throw",
+	Use:                    "use",
+	Insteadof:              "insteadof",
+	Global:                 "global",
+	Var:                    "var",
+	Unset:                  "unset",
+	Isset:                  "isset",
+	Empty:                  "empty",
+	HaltCompiler:           "__halt_compiler",
+	Class:                  "class",
+	Trait:                  "trait",
+	Interface:              "interface",
+	Enum:                   "enum",
+	Extends:                "extends",
+	Implements:             "implements",
+	ObjectOperator:         "->",
+	NullsafeObjectOperator: "?->",
+	Attribute:              "#[",
+	List:                   "list",
+	Array:                  "array",
+	Callable:               "callable",
+	Line:                   "__LINE__",
+	File:                   "__FILE__",
+	Dir:                    "__DIR__",
+	ClassC:                 "__CLASS__",
+	TraitC:                 "__TRAIT__",
+	MethodC:                "__METHOD__",
+	FuncC:                  "__FUNCTION__",
+	OpenTag:                "<?php",
+	OpenTagWithEcho:        "<?=",
+	CloseTag:               "?>",
+	DollarOpenCurlyBraces:  "${",
+	CurlyOpen:              "{$",
+	PaamayimNekudotayim:    "::",
+	Namespace:              "namespace",
+	NsC:                    "__NAMESPACE__",
+	NsSeparator:            "\\",
+	Ellipsis:               "...",
+	Semicolon:              ";",
+	Colon:                  ":",
+	Comma:                  ",",
+	Dot:                    ".",
+	LBracket:               "[",
+	RBracket:               "]",
+	LParen:                 "(",
+	RParen:                 ")",
+	Bar:                    "|",
+	Caret:                  "^",
+	Ampersand:              "&",
+	Plus:                   "+",
+	Minus:                  "-",
+	Asterisk:               "*",
+	Slash:                  "/",
+	Assign:                 "=",
+	Modulo:                 "%",
+	Bang:                   "!",
+	Tilde:                  "~",
+	Dollar:                 "$",
+	Lt:                     "<",
+	Gt:                     ">",
+	QuestionMark:           "?",
+	At:                     "@",
+	DoubleQuotes:           "\"",
+	LBrace:                 "{",
+	RBrace:                 "}",
+	Backquote:              "`",
+}
+
+// phpKinds holds the names PHP gives to the tokens carrying a value.
+var phpKinds = map[Type]string{
+	End:                    "end of file",
+	Lnumber:                "integer",
+	Dnumber:                "floating-point number",
+	String:                 "identifier",
+	Variable:               "variable",
+	InlineHtml:             "non-PHP code",
+	EncapsedAndWhitespace:  "string content",
+	ConstantEncapsedString: "quoted string",
+	StringVarname:          "variable name",
+	NumString:              "number",
+	Comment:                "comment",
+	DocComment:             "doc comment",
+	Whitespace:             "whitespace",
+	StartHeredoc:           "heredoc start",
+	EndHeredoc:             "heredoc end",
+}
+
+// Expected describes the token type the way PHP lists the expected tokens
+// of a syntax error: `variable`, `identifier` or `";"`.
+func (t Type) Expected() string {
+	if kind, ok := phpKinds[t]; ok {
+		return kind
+	}
+	if text, ok := phpTexts[t]; ok {
+		return `"` + text + `"`
+	}
+	return t.String()
+}
+
+// Unexpected describes the token the way PHP names an unexpected token in
+// a syntax error: `end of file`, `token ";"` or `variable "$a"`. Values
+// are cut at the end of line and after 30 bytes.
+func (t Token) Unexpected() string {
+	switch t.Type {
+	case End:
+		return "end of file"
+	case DoubleQuotes:
+		return "double-quote mark"
+	}
+	if text, ok := phpTexts[t.Type]; ok {
+		return "token \"" + text + "\""
+	}
+	kind, ok := phpKinds[t.Type]
+	if !ok {
+		kind = t.Type.String()
+	}
+	value := t.Literal
+	if i := strings.IndexAny(value, "\r\n"); i >= 0 {
+		value = value[:i]
+	}
+	if t.Type == ConstantEncapsedString && value != "" {
+		if value[0] == '"' {
+			kind = "double-quoted string"
+		} else if value[0] == '\'' {
+			kind = "single-quoted string"
+		}
+	}
+	if value != "" && (value[0] == '"' || value[0] == '\'') {
+		value = value[1:]
+	}
+	if value != "" && (value[len(value)-1] == '"' || value[len(value)-1] == '\'') {
+		value = value[:len(value)-1]
+	}
+	if len(value) > 33 {
+		value = value[:30] + "..."
+	}
+	return kind + " \"" + value + "\""
+}
+
 var keywords = map[string]Type{
 	"abstract":     Abstract,
 	"and":          BooleanAnd,
@@ -389,6 +635,7 @@ var keywords = map[string]Type{
 	"finally":      Finally,
 	"for":          For,
 	"foreach":      Foreach,
+	"fn":           Fn,
 	"function":     Function,
 	"global":       Global,
 	"goto":         Goto,
@@ -426,6 +673,7 @@ var identifiers = map[string]Type{
 	"exit":       Exit,
 	"die":        Exit,
 	"function":   Function,
+	"fn":         Fn,
 	"const":      Const,
 	"return":     Return,
 	"yield":      Yield,
@@ -444,11 +692,13 @@ var identifiers = map[string]Type{
 	"endfor":     Endfor,
 	"foreach":    Foreach,
 	"endforeach": Endforeach,
-	"declare":    Enddeclare,
+	"declare":    Declare,
+	"enddeclare": Enddeclare,
 	"instanceof": Instanceof,
 	"as":         As,
 	"switch":     Switch,
 	"endswitch":  Endswitch,
+	"match":      Match,
 	"case":       Case,
 	"default":    Default,
 	"break":      Break,
@@ -480,6 +730,7 @@ var identifiers = map[string]Type{
 	"static":          Static,
 	"abstract":        Abstract,
 	"final":           Final,
+	"readonly":        Readonly,
 	"private":         Private,
 	"protected":       Protected,
 	"public":          Public,
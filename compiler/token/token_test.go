@@ -26,3 +26,81 @@ func Test_MemoryAligment(t *testing.T) {
 	fmt.Printf("Sizeof A: %d\n", unsafe.Sizeof(A{}))
 	fmt.Printf("Sizeof B: %d\n", unsafe.Sizeof(B{}))
 }
+
+func Test_Unexpected(t *testing.T) {
+	tests := []struct {
+		tok      Token
+		expected string
+	}{
+		{NewToken(End, "", 1), "end of file"},
+		{NewToken(Semicolon, "?>", 1), `token ";"`},
+		{NewToken(Function, "FUNCTION", 1), `token "function"`},
+		{NewToken(NsSeparator, `\`, 1), `token "\"`},
+		{NewToken(DoubleQuotes, `"`, 1), "double-quote mark"},
+		{NewToken(String, "foo", 1), `identifier "foo"`},
+		{NewToken(Variable, "$x", 1), `variable "$x"`},
+		{NewToken(Lnumber, "12", 1), `integer "12"`},
+		{NewToken(InlineHtml, "<p>\n", 1), `non-PHP code "<p>"`},
+		{NewToken(ConstantEncapsedString, `"abc"`, 1), `double-quoted string "abc"`},
+		{NewToken(ConstantEncapsedString, `'a`+"\n"+`b'`, 1), `single-quoted string "a"`},
+		{NewToken(EncapsedAndWhitespace, "0123456789012345678901234567890123", 1), `string content "012345678901234567890123456789..."`},
+	}
+	for i, tt := range tests {
+		if s := tt.tok.Unexpected(); s != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, s)
+		}
+	}
+}
+
+func Test_Expected(t *testing.T) {
+	tests := []struct {
+		typ      Type
+		expected string
+	}{
+		{RParen, `")"`},
+		{DoubleArrow, `"=>"`},
+		{Function, `"function"`},
+		{String, "identifier"},
+		{Variable, "variable"},
+		{End, "end of file"},
+	}
+	for i, tt := range tests {
+		if s := tt.typ.Expected(); s != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, s)
+		}
+	}
+}
+
+func Test_TokenPosition(t *testing.T) {
+	tok := Token{Line: 2, Column: 5, Offset: 12, Type: ConstantEncapsedString, Literal: "'a\nbc'"}
+	if s := tok.Pos("a.php").String(); s != "a.php:2:5" {
+		t.Errorf("expected a.php:2:5, got %s", s)
+	}
+	end := tok.End("a.php")
+	if end.String() != "a.php:3:4" || end.Offset != 18 {
+		t.Errorf("expected a.php:3:4 at offset 18, got %s at offset %d", end, end.Offset)
+	}
+	if s := (Position{}).String(); s != "-" {
+		t.Errorf("expected -, got %s", s)
+	}
+}
+
+func Test_FileSet(t *testing.T) {
+	fset := NewFileSet()
+	f := fset.AddFile("a.php", "<?php\r\n$a = 1;\n\necho $a;")
+	if fset.File("a.php") != f || fset.File("b.php") != nil {
+		t.Fatalf("unexpected file lookup")
+	}
+	if f.LineCount() != 4 {
+		t.Errorf("expected 4 lines, got %d", f.LineCount())
+	}
+	lines := []string{"", "<?php", "$a = 1;", "", "echo $a;", ""}
+	for i, expected := range lines {
+		if s := f.Line(i); s != expected {
+			t.Errorf("line %d - expected=%q, got=%q", i, expected, s)
+		}
+	}
+	if pos := f.Position(21); pos.String() != "a.php:4:6" {
+		t.Errorf("expected a.php:4:6, got %s", pos)
+	}
+}